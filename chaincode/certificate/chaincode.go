@@ -0,0 +1,498 @@
+// Package certificate là chaincode lưu văn bằng trên Hyperledger Fabric.
+// Bản ghi dùng chung models.CertificateOnChain và models.CertificateBatchOnChain với backend.
+package certificate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+)
+
+const (
+	configKey = "config"
+
+	certificateObjectType = "certificate"
+	batchObjectType       = "batch"
+	serialObjectType      = "serial" // Chỉ mục số hiệu theo trường, chống cấp trùng số hiệu
+)
+
+// Khóa transient map chứa dữ liệu riêng của văn bằng, dữ liệu trong transient không được ghi vào giao dịch
+const (
+	TransientPrivateCollection = "private_collection"
+	TransientPrivateDetails    = "private_details"
+)
+
+// ErrNotFound đứng đầu thông báo lỗi khi không có bản ghi, FabricClient dựa vào đó để phân loại lỗi
+var ErrNotFound = errors.New("record_not_found")
+
+// ErrPrivateDataDenied đứng đầu thông báo lỗi khi peer không giữ dữ liệu riêng hoặc định danh không thuộc collection
+var ErrPrivateDataDenied = errors.New("private_data_denied")
+
+// Config là cấu hình lưu khi khởi tạo chaincode
+type Config struct {
+	IssuerMSPs []string `json:"issuer_msps"` // Các MSP được ghi văn bằng, rỗng thì không giới hạn MSP
+}
+
+// Chaincode cài đặt các hàm backend gọi qua FabricClient
+type Chaincode struct{}
+
+// Init nhận danh sách MSP được phép ghi văn bằng, không truyền tham số thì giữ cấu hình cũ
+func (cc *Chaincode) Init(stub Stub) Response {
+	_, args := stub.GetFunctionAndParameters()
+	if len(args) == 0 {
+		return success(nil)
+	}
+	raw, err := json.Marshal(&Config{IssuerMSPs: args})
+	if err != nil {
+		return failure(err)
+	}
+	if err := stub.PutState(configKey, raw); err != nil {
+		return failure(err)
+	}
+	return success(nil)
+}
+
+func (cc *Chaincode) Invoke(stub Stub) Response {
+	fn, args := stub.GetFunctionAndParameters()
+	var (
+		payload []byte
+		err     error
+	)
+	switch fn {
+	case "IssueCertificate":
+		if err = requireArgs(fn, args, 1); err == nil {
+			payload, err = cc.IssueCertificate(stub, args[0])
+		}
+	case "ReadCertificate":
+		if err = requireArgs(fn, args, 1); err == nil {
+			payload, err = cc.ReadCertificate(stub, args[0])
+		}
+	case "GetAllCertificates":
+		payload, err = cc.GetAllCertificates(stub)
+	case "UpdateCertificate":
+		if err = requireArgs(fn, args, 1); err == nil {
+			payload, err = cc.UpdateCertificate(stub, args[0])
+		}
+	case "RevokeCertificate":
+		if err = requireArgs(fn, args, 4); err == nil {
+			payload, err = cc.RevokeCertificate(stub, args[0], args[1], args[2], args[3])
+		}
+	case "GetCertificateHistory":
+		if err = requireArgs(fn, args, 1); err == nil {
+			payload, err = cc.GetCertificateHistory(stub, args[0])
+		}
+	case "AnchorBatch":
+		if err = requireArgs(fn, args, 1); err == nil {
+			payload, err = cc.AnchorBatch(stub, args[0])
+		}
+	case "ReadBatch":
+		if err = requireArgs(fn, args, 1); err == nil {
+			payload, err = cc.ReadBatch(stub, args[0])
+		}
+	case "ReadCertificatePrivateDetails":
+		if err = requireArgs(fn, args, 1); err == nil {
+			payload, err = cc.ReadCertificatePrivateDetails(stub, args[0])
+		}
+	default:
+		err = fmt.Errorf("hàm %s không tồn tại", fn)
+	}
+	if err != nil {
+		return failure(err)
+	}
+	return success(payload)
+}
+
+func requireArgs(fn string, args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("%s cần %d tham số, nhận %d", fn, n, len(args))
+	}
+	return nil
+}
+
+// IssueCertificate ghi văn bằng mới, trả về tx_id.
+// Văn bằng từng neo theo lô cũng được ghi bằng hàm này khi cần bản ghi riêng, lô không chứa bản ghi văn bằng nên không trùng khóa.
+// Dữ liệu riêng gửi qua transient map được ghi vào private data collection, bản ghi công khai chỉ giữ mã băm.
+func (cc *Chaincode) IssueCertificate(stub Stub, certJSON string) ([]byte, error) {
+	cert, err := parseCertificate(certJSON)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := cc.authorizeIssuer(stub, cert.UniversityCode)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := stub.CreateCompositeKey(certificateObjectType, []string{cert.CertID})
+	if err != nil {
+		return nil, err
+	}
+	existing, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("văn bằng %s đã tồn tại trên sổ cái", cert.CertID)
+	}
+	if err := claimSerial(stub, cert, ""); err != nil {
+		return nil, err
+	}
+	if err := putPrivateDetails(stub, key, cert); err != nil {
+		return nil, err
+	}
+
+	cert.Revoked = false
+	cert.RevokeReasonCode = ""
+	cert.RevokedDate = ""
+	cert.SubmittedBy = identity.String()
+	return putCertificate(stub, key, cert)
+}
+
+func (cc *Chaincode) ReadCertificate(stub Stub, certID string) ([]byte, error) {
+	key, err := stub.CreateCompositeKey(certificateObjectType, []string{certID})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, certID)
+	}
+	return raw, nil
+}
+
+func (cc *Chaincode) GetAllCertificates(stub Stub) ([]byte, error) {
+	iter, err := stub.GetStateByPartialCompositeKey(certificateObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	certs := []*models.CertificateOnChain{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var cert models.CertificateOnChain
+		if err := json.Unmarshal(kv.Value, &cert); err != nil {
+			return nil, fmt.Errorf("bản ghi %s không hợp lệ: %w", kv.Key, err)
+		}
+		certs = append(certs, &cert)
+	}
+	return json.Marshal(certs)
+}
+
+// UpdateCertificate ghi phiên bản mới của văn bằng chưa thu hồi, không được đổi trường cấp.
+// Phiên bản không kèm dữ liệu riêng thì bản ghi công khai không còn trỏ tới dữ liệu riêng cũ.
+func (cc *Chaincode) UpdateCertificate(stub Stub, certJSON string) ([]byte, error) {
+	cert, err := parseCertificate(certJSON)
+	if err != nil {
+		return nil, err
+	}
+	key, current, err := getCertificate(stub, cert.CertID)
+	if err != nil {
+		return nil, err
+	}
+	if current.UniversityCode != "" && cert.UniversityCode != current.UniversityCode {
+		return nil, fmt.Errorf("không được đổi trường cấp của văn bằng %s", cert.CertID)
+	}
+	identity, err := cc.authorizeIssuer(stub, cert.UniversityCode)
+	if err != nil {
+		return nil, err
+	}
+	if current.Revoked {
+		return nil, fmt.Errorf("văn bằng %s đã bị thu hồi", cert.CertID)
+	}
+	if err := claimSerial(stub, cert, current.SerialNumber); err != nil {
+		return nil, err
+	}
+	if err := putPrivateDetails(stub, key, cert); err != nil {
+		return nil, err
+	}
+
+	cert.Revoked = false
+	cert.RevokeReasonCode = ""
+	cert.RevokedDate = ""
+	cert.SubmittedBy = identity.String()
+	return putCertificate(stub, key, cert)
+}
+
+// RevokeCertificate đánh dấu thu hồi trên bản ghi hiện tại; số quyết định chỉ lưu trong MongoDB như sổ cái cục bộ
+func (cc *Chaincode) RevokeCertificate(stub Stub, certID, reasonCode, decisionNumber, revokedDate string) ([]byte, error) {
+	key, current, err := getCertificate(stub, certID)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := cc.authorizeIssuer(stub, current.UniversityCode)
+	if err != nil {
+		return nil, err
+	}
+	if current.Revoked {
+		return nil, fmt.Errorf("văn bằng %s đã bị thu hồi", certID)
+	}
+	if reasonCode == "" || revokedDate == "" {
+		return nil, fmt.Errorf("thiếu mã lý do hoặc ngày thu hồi")
+	}
+
+	current.Revoked = true
+	current.RevokeReasonCode = reasonCode
+	current.RevokedDate = revokedDate
+	current.SubmittedBy = identity.String()
+	return putCertificate(stub, key, current)
+}
+
+// GetCertificateHistory trả mọi lần ghi lên văn bằng theo định dạng models.LedgerHistoryEntry
+func (cc *Chaincode) GetCertificateHistory(stub Stub, certID string) ([]byte, error) {
+	key, err := stub.CreateCompositeKey(certificateObjectType, []string{certID})
+	if err != nil {
+		return nil, err
+	}
+	iter, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	history := []*models.LedgerHistoryEntry{}
+	for iter.HasNext() {
+		mod, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		entry := &models.LedgerHistoryEntry{TxID: mod.TxID, Timestamp: mod.Timestamp, IsDelete: mod.IsDelete}
+		if !mod.IsDelete && len(mod.Value) > 0 {
+			var value models.CertificateOnChain
+			if err := json.Unmarshal(mod.Value, &value); err != nil {
+				return nil, fmt.Errorf("bản ghi lịch sử %s không hợp lệ: %w", mod.TxID, err)
+			}
+			entry.Value = &value
+		}
+		history = append(history, entry)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, certID)
+	}
+	return json.Marshal(history)
+}
+
+// AnchorBatch ghi gốc Merkle của lô văn bằng, trả về tx_id
+func (cc *Chaincode) AnchorBatch(stub Stub, batchJSON string) ([]byte, error) {
+	var batch models.CertificateBatchOnChain
+	if err := json.Unmarshal([]byte(batchJSON), &batch); err != nil {
+		return nil, fmt.Errorf("lô văn bằng không hợp lệ: %w", err)
+	}
+	if batch.BatchID == "" || batch.MerkleRoot == "" || batch.UniversityCode == "" {
+		return nil, fmt.Errorf("thiếu batch_id, merkle_root hoặc university_code")
+	}
+	identity, err := cc.authorizeIssuer(stub, batch.UniversityCode)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := stub.CreateCompositeKey(batchObjectType, []string{batch.BatchID})
+	if err != nil {
+		return nil, err
+	}
+	existing, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("lô %s đã tồn tại trên sổ cái", batch.BatchID)
+	}
+
+	batch.SubmittedBy = identity.String()
+	raw, err := json.Marshal(&batch)
+	if err != nil {
+		return nil, err
+	}
+	if err := stub.PutState(key, raw); err != nil {
+		return nil, err
+	}
+	return []byte(stub.GetTxID()), nil
+}
+
+func (cc *Chaincode) ReadBatch(stub Stub, batchID string) ([]byte, error) {
+	key, err := stub.CreateCompositeKey(batchObjectType, []string{batchID})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("%w: không tìm thấy lô %s trên sổ cái", ErrNotFound, batchID)
+	}
+	return raw, nil
+}
+
+// ReadCertificatePrivateDetails trả dữ liệu riêng của phiên bản hiện tại sau khi đối chiếu với mã băm công khai.
+// Peer không thuộc collection không giữ dữ liệu riêng, còn collection memberOnlyRead từ chối định danh của tổ chức khác.
+func (cc *Chaincode) ReadCertificatePrivateDetails(stub Stub, certID string) ([]byte, error) {
+	key, cert, err := getCertificate(stub, certID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.PrivateCollection == "" {
+		return nil, fmt.Errorf("%w: văn bằng %s không có dữ liệu riêng trên sổ cái", ErrNotFound, certID)
+	}
+	raw, err := stub.GetPrivateData(cert.PrivateCollection, key)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not have read access") {
+			return nil, fmt.Errorf("%w: %v", ErrPrivateDataDenied, err)
+		}
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("%w: peer không giữ dữ liệu riêng của văn bằng %s trong collection %s", ErrPrivateDataDenied, certID, cert.PrivateCollection)
+	}
+	if privateDataHash(raw) != cert.PrivateDataHash {
+		return nil, fmt.Errorf("dữ liệu riêng của văn bằng %s không khớp mã băm trên sổ cái", certID)
+	}
+	return raw, nil
+}
+
+// authorizeIssuer chỉ cho định danh thuộc MSP được phép và mang university_code của trường ghi dữ liệu của trường đó
+func (cc *Chaincode) authorizeIssuer(stub Stub, universityCode string) (*clientIdentity, error) {
+	identity, err := getClientIdentity(stub)
+	if err != nil {
+		return nil, err
+	}
+	config, err := getConfig(stub)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.IssuerMSPs) > 0 && !slices.Contains(config.IssuerMSPs, identity.MSPID) {
+		return nil, fmt.Errorf("MSP %s không được phép ghi văn bằng", identity.MSPID)
+	}
+	if universityCode == "" {
+		return nil, errors.New("thiếu university_code")
+	}
+	if identity.UniversityCode() != universityCode {
+		return nil, fmt.Errorf("định danh %s không được ghi dữ liệu của trường %s", identity.String(), universityCode)
+	}
+	return identity, nil
+}
+
+func getConfig(stub Stub) (*Config, error) {
+	raw, err := stub.GetState(configKey)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if raw == nil {
+		return config, nil
+	}
+	if err := json.Unmarshal(raw, config); err != nil {
+		return nil, fmt.Errorf("cấu hình chaincode không hợp lệ: %w", err)
+	}
+	return config, nil
+}
+
+func parseCertificate(certJSON string) (*models.CertificateOnChain, error) {
+	var cert models.CertificateOnChain
+	if err := json.Unmarshal([]byte(certJSON), &cert); err != nil {
+		return nil, fmt.Errorf("văn bằng không hợp lệ: %w", err)
+	}
+	if cert.CertID == "" || cert.CertHash == "" {
+		return nil, fmt.Errorf("thiếu cert_id hoặc cert_hash")
+	}
+	return &cert, nil
+}
+
+func getCertificate(stub Stub, certID string) (string, *models.CertificateOnChain, error) {
+	key, err := stub.CreateCompositeKey(certificateObjectType, []string{certID})
+	if err != nil {
+		return "", nil, err
+	}
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return "", nil, err
+	}
+	if raw == nil {
+		return "", nil, fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, certID)
+	}
+	var cert models.CertificateOnChain
+	if err := json.Unmarshal(raw, &cert); err != nil {
+		return "", nil, fmt.Errorf("bản ghi văn bằng %s không hợp lệ: %w", certID, err)
+	}
+	return key, &cert, nil
+}
+
+func putCertificate(stub Stub, key string, cert *models.CertificateOnChain) ([]byte, error) {
+	raw, err := json.Marshal(cert)
+	if err != nil {
+		return nil, err
+	}
+	if err := stub.PutState(key, raw); err != nil {
+		return nil, err
+	}
+	return []byte(stub.GetTxID()), nil
+}
+
+// putPrivateDetails ghi dữ liệu riêng trong transient map vào collection dưới cùng khóa với văn bằng
+// và đặt private_collection, private_data_hash của bản ghi công khai; bên gửi không tự đặt hai trường này.
+func putPrivateDetails(stub Stub, key string, cert *models.CertificateOnChain) error {
+	cert.PrivateCollection = ""
+	cert.PrivateDataHash = ""
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return err
+	}
+	raw, ok := transient[TransientPrivateDetails]
+	if !ok {
+		return nil
+	}
+	collection := string(transient[TransientPrivateCollection])
+	if collection == "" {
+		return fmt.Errorf("thiếu tên private data collection")
+	}
+	var details models.CertificatePrivateDetails
+	if err := json.Unmarshal(raw, &details); err != nil {
+		return fmt.Errorf("dữ liệu riêng không hợp lệ: %w", err)
+	}
+	if details.CertID != cert.CertID {
+		return fmt.Errorf("dữ liệu riêng không thuộc văn bằng %s", cert.CertID)
+	}
+	if err := stub.PutPrivateData(collection, key, raw); err != nil {
+		return err
+	}
+	cert.PrivateCollection = collection
+	cert.PrivateDataHash = privateDataHash(raw)
+	return nil
+}
+
+// privateDataHash trùng với mã băm peer lưu trên kênh công khai cho giá trị private data
+func privateDataHash(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// claimSerial giữ số hiệu của văn bằng trong phạm vi trường, số hiệu đã thuộc văn bằng khác thì từ chối.
+// Số hiệu cũ khi đổi không bị xóa khỏi chỉ mục để không cấp lại cho văn bằng khác.
+func claimSerial(stub Stub, cert *models.CertificateOnChain, previousSerial string) error {
+	if cert.SerialNumber == "" || cert.SerialNumber == previousSerial {
+		return nil
+	}
+	key, err := stub.CreateCompositeKey(serialObjectType, []string{cert.UniversityCode, cert.SerialNumber})
+	if err != nil {
+		return err
+	}
+	owner, err := stub.GetState(key)
+	if err != nil {
+		return err
+	}
+	if owner != nil && string(owner) != cert.CertID {
+		return fmt.Errorf("số hiệu %s của trường %s đã được cấp cho văn bằng %s", cert.SerialNumber, cert.UniversityCode, owner)
+	}
+	return stub.PutState(key, []byte(cert.CertID))
+}
@@ -0,0 +1,580 @@
+package certificate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+)
+
+const testCollection = "certificatePrivateDetails"
+
+func certificateJSON(t *testing.T, cert *models.CertificateOnChain) string {
+	t.Helper()
+	raw, err := json.Marshal(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+func newTestCertificate(certID, universityCode, serial string) *models.CertificateOnChain {
+	return &models.CertificateOnChain{
+		CertID:         certID,
+		UniversityCode: universityCode,
+		CertHash:       "hash-" + certID,
+		SerialNumber:   serial,
+		Version:        1,
+	}
+}
+
+func privateTransient(t *testing.T, certID string) (map[string][]byte, []byte) {
+	t.Helper()
+	raw, err := json.Marshal(&models.CertificatePrivateDetails{
+		CertID: certID,
+		Fields: map[string]string{models.DisclosureFieldStudentName: "Nguyễn Văn A"},
+		Salt:   "00ff",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string][]byte{
+		TransientPrivateCollection: []byte(testCollection),
+		TransientPrivateDetails:    raw,
+	}, raw
+}
+
+func readCertificate(t *testing.T, cc *Chaincode, stub *fakeStub, certID string) *models.CertificateOnChain {
+	t.Helper()
+	raw, err := cc.ReadCertificate(stub, certID)
+	if err != nil {
+		t.Fatalf("ReadCertificate: %v", err)
+	}
+	var cert models.CertificateOnChain
+	if err := json.Unmarshal(raw, &cert); err != nil {
+		t.Fatal(err)
+	}
+	return &cert
+}
+
+func TestIssueCertificateAuthorizesIssuer(t *testing.T) {
+	tests := []struct {
+		name           string
+		issuerMSPs     []string
+		mspID          string
+		universityCode string
+		wantErr        string
+	}{
+		{name: "đúng trường", mspID: "Org1MSP", universityCode: "KMA"},
+		{name: "định danh của trường khác", mspID: "Org1MSP", universityCode: "HUST", wantErr: "không được ghi dữ liệu của trường KMA"},
+		{name: "định danh không có university_code", mspID: "Org1MSP", wantErr: "không được ghi dữ liệu của trường KMA"},
+		{name: "MSP không được phép", issuerMSPs: []string{"Org2MSP"}, mspID: "Org1MSP", universityCode: "KMA", wantErr: "MSP Org1MSP không được phép"},
+		{name: "MSP được phép", issuerMSPs: []string{"Org1MSP"}, mspID: "Org1MSP", universityCode: "KMA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &Chaincode{}
+			stub := newFakeStub()
+			if len(tt.issuerMSPs) > 0 {
+				stub.args = tt.issuerMSPs
+				if res := cc.Init(stub); res.Status != StatusOK {
+					t.Fatalf("Init: %s", res.Message)
+				}
+			}
+			stub.nextTx(newCreator(t, tt.mspID, "admin-kma", tt.universityCode), nil)
+
+			txID, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001")))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.wantErr)
+				}
+				if _, err := cc.ReadCertificate(stub, "cert-1"); err == nil {
+					t.Fatal("văn bằng bị từ chối vẫn được ghi lên sổ cái")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IssueCertificate: %v", err)
+			}
+			if string(txID) != stub.txID {
+				t.Fatalf("tx_id = %s, muốn %s", txID, stub.txID)
+			}
+			if got := readCertificate(t, cc, stub, "cert-1").SubmittedBy; got != "admin-kma@"+tt.mspID {
+				t.Fatalf("submitted_by = %s", got)
+			}
+		})
+	}
+}
+
+func TestIssueCertificateRejectsDuplicate(t *testing.T) {
+	cc := &Chaincode{}
+	stub := newFakeStub()
+	creator := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+
+	stub.nextTx(creator, nil)
+	if _, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001"))); err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+	stub.nextTx(creator, nil)
+	_, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S002")))
+	if err == nil || !strings.Contains(err.Error(), "đã tồn tại") {
+		t.Fatalf("lỗi = %v, muốn từ chối văn bằng trùng", err)
+	}
+	if got := readCertificate(t, cc, stub, "cert-1").SerialNumber; got != "S001" {
+		t.Fatalf("bản ghi bị ghi đè, serial_number = %s", got)
+	}
+}
+
+func TestClaimSerial(t *testing.T) {
+	cc := &Chaincode{}
+	stub := newFakeStub()
+	kma := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+	hust := newCreator(t, "Org1MSP", "admin-hust", "HUST")
+
+	stub.nextTx(kma, nil)
+	if _, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001"))); err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		cert    *models.CertificateOnChain
+		wantErr bool
+	}{
+		{name: "số hiệu đã cấp cho văn bằng khác cùng trường", creator: kma, cert: newTestCertificate("cert-2", "KMA", "S001"), wantErr: true},
+		{name: "cùng số hiệu ở trường khác", creator: hust, cert: newTestCertificate("cert-3", "HUST", "S001")},
+		{name: "số hiệu mới", creator: kma, cert: newTestCertificate("cert-4", "KMA", "S002")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub.nextTx(tt.creator, nil)
+			_, err := cc.IssueCertificate(stub, certificateJSON(t, tt.cert))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "đã được cấp cho văn bằng cert-1") {
+					t.Fatalf("lỗi = %v, muốn từ chối số hiệu trùng", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IssueCertificate: %v", err)
+			}
+		})
+	}
+
+	// Văn bằng giữ nguyên số hiệu của chính nó khi cập nhật, số hiệu cũ không được cấp lại sau khi đổi
+	stub.nextTx(kma, nil)
+	renamed := newTestCertificate("cert-1", "KMA", "S009")
+	renamed.Version = 2
+	if _, err := cc.UpdateCertificate(stub, certificateJSON(t, renamed)); err != nil {
+		t.Fatalf("UpdateCertificate: %v", err)
+	}
+	stub.nextTx(kma, nil)
+	if _, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-5", "KMA", "S001"))); err == nil {
+		t.Fatal("số hiệu cũ của văn bằng đã đổi số hiệu bị cấp lại")
+	}
+}
+
+func TestUpdateCertificateRejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		revoke  bool
+		update  *models.CertificateOnChain
+		creator func(t *testing.T) []byte
+		wantErr string
+	}{
+		{
+			name:    "đổi trường cấp",
+			update:  newTestCertificate("cert-1", "HUST", "S001"),
+			creator: func(t *testing.T) []byte { return newCreator(t, "Org1MSP", "admin-hust", "HUST") },
+			wantErr: "không được đổi trường cấp",
+		},
+		{
+			name:    "văn bằng đã thu hồi",
+			revoke:  true,
+			update:  newTestCertificate("cert-1", "KMA", "S001"),
+			creator: func(t *testing.T) []byte { return newCreator(t, "Org1MSP", "admin-kma", "KMA") },
+			wantErr: "đã bị thu hồi",
+		},
+		{
+			name:    "định danh của trường khác",
+			update:  newTestCertificate("cert-1", "KMA", "S001"),
+			creator: func(t *testing.T) []byte { return newCreator(t, "Org1MSP", "admin-hust", "HUST") },
+			wantErr: "không được ghi dữ liệu của trường KMA",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &Chaincode{}
+			stub := newFakeStub()
+			kma := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+			stub.nextTx(kma, nil)
+			if _, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001"))); err != nil {
+				t.Fatalf("IssueCertificate: %v", err)
+			}
+			if tt.revoke {
+				stub.nextTx(kma, nil)
+				if _, err := cc.RevokeCertificate(stub, "cert-1", "01", "QD-01", "2025-01-01"); err != nil {
+					t.Fatalf("RevokeCertificate: %v", err)
+				}
+			}
+			keys := len(stub.state)
+
+			stub.nextTx(tt.creator(t), nil)
+			tt.update.Version = 2
+			_, err := cc.UpdateCertificate(stub, certificateJSON(t, tt.update))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.wantErr)
+			}
+			if got := readCertificate(t, cc, stub, "cert-1"); got.Version != 1 || got.UniversityCode != "KMA" {
+				t.Fatalf("bản ghi bị thay đổi: %+v", got)
+			}
+			if len(stub.state) != keys {
+				t.Fatal("giao dịch bị từ chối vẫn ghi thêm khóa")
+			}
+		})
+	}
+}
+
+func TestPrivateDetailsHashMatchesRead(t *testing.T) {
+	cc := &Chaincode{}
+	stub := newFakeStub()
+	kma := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+
+	transient, raw := privateTransient(t, "cert-1")
+	stub.nextTx(kma, transient)
+	if _, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001"))); err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+
+	cert := readCertificate(t, cc, stub, "cert-1")
+	if cert.PrivateCollection != testCollection {
+		t.Fatalf("private_collection = %q", cert.PrivateCollection)
+	}
+	if cert.PrivateDataHash != privateDataHash(raw) {
+		t.Fatalf("private_data_hash = %s, muốn %s", cert.PrivateDataHash, privateDataHash(raw))
+	}
+	if strings.Contains(string(stub.state[mustKey(t, stub, "cert-1")]), "Nguyễn Văn A") {
+		t.Fatal("dữ liệu riêng lọt vào bản ghi công khai")
+	}
+
+	got, err := cc.ReadCertificatePrivateDetails(stub, "cert-1")
+	if err != nil {
+		t.Fatalf("ReadCertificatePrivateDetails: %v", err)
+	}
+	if string(got) != string(raw) {
+		t.Fatalf("dữ liệu riêng đọc lại = %s, muốn %s", got, raw)
+	}
+
+	// Dữ liệu riêng bị sửa ngoài giao dịch thì không còn khớp mã băm công khai
+	stub.private[testCollection][mustKey(t, stub, "cert-1")] = []byte(`{"cert_id":"cert-1"}`)
+	if _, err := cc.ReadCertificatePrivateDetails(stub, "cert-1"); err == nil || !strings.Contains(err.Error(), "không khớp mã băm") {
+		t.Fatalf("lỗi = %v, muốn báo không khớp mã băm", err)
+	}
+
+	// Phiên bản không kèm dữ liệu riêng không còn trỏ tới dữ liệu riêng cũ
+	stub.nextTx(kma, nil)
+	update := newTestCertificate("cert-1", "KMA", "S001")
+	update.Version = 2
+	if _, err := cc.UpdateCertificate(stub, certificateJSON(t, update)); err != nil {
+		t.Fatalf("UpdateCertificate: %v", err)
+	}
+	if cert := readCertificate(t, cc, stub, "cert-1"); cert.PrivateCollection != "" || cert.PrivateDataHash != "" {
+		t.Fatalf("bản ghi vẫn trỏ tới dữ liệu riêng cũ: %+v", cert)
+	}
+	if _, err := cc.ReadCertificatePrivateDetails(stub, "cert-1"); err == nil || !strings.HasPrefix(err.Error(), ErrNotFound.Error()) {
+		t.Fatalf("lỗi = %v, muốn %v", err, ErrNotFound)
+	}
+}
+
+func TestPutPrivateDetailsRejectsOtherCertificate(t *testing.T) {
+	cc := &Chaincode{}
+	stub := newFakeStub()
+	transient, _ := privateTransient(t, "cert-2")
+	stub.nextTx(newCreator(t, "Org1MSP", "admin-kma", "KMA"), transient)
+
+	_, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001")))
+	if err == nil || !strings.Contains(err.Error(), "không thuộc văn bằng cert-1") {
+		t.Fatalf("lỗi = %v, muốn từ chối dữ liệu riêng của văn bằng khác", err)
+	}
+	if len(stub.private[testCollection]) != 0 {
+		t.Fatal("dữ liệu riêng của văn bằng khác vẫn được ghi")
+	}
+}
+
+func mustKey(t *testing.T, stub *fakeStub, certID string) string {
+	t.Helper()
+	key, err := stub.CreateCompositeKey(certificateObjectType, []string{certID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestInvokeDispatch(t *testing.T) {
+	cc := &Chaincode{}
+	stub := newFakeStub()
+	kma := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+
+	res := stub.invoke(cc, kma, "IssueCertificate", certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001")))
+	if res.Status != StatusOK || string(res.Payload) != stub.txID {
+		t.Fatalf("IssueCertificate: status = %d, payload = %s, lỗi = %s", res.Status, res.Payload, res.Message)
+	}
+
+	tests := []struct {
+		name    string
+		fn      string
+		args    []string
+		wantErr string
+		check   func(t *testing.T, payload []byte)
+	}{
+		{name: "hàm không tồn tại", fn: "DeleteCertificate", args: []string{"cert-1"}, wantErr: "hàm DeleteCertificate không tồn tại"},
+		{name: "thiếu tham số", fn: "ReadCertificate", wantErr: "ReadCertificate cần 1 tham số, nhận 0"},
+		{name: "thừa tham số", fn: "IssueCertificate", args: []string{"{}", "{}"}, wantErr: "IssueCertificate cần 1 tham số, nhận 2"},
+		{name: "thu hồi thiếu tham số", fn: "RevokeCertificate", args: []string{"cert-1", "01", "QD-01"}, wantErr: "RevokeCertificate cần 4 tham số, nhận 3"},
+		{name: "lô thiếu tham số", fn: "AnchorBatch", wantErr: "AnchorBatch cần 1 tham số, nhận 0"},
+		{name: "khóa chứa ký tự shim từ chối", fn: "ReadCertificate", args: []string{"cert\x00-1"}, wantErr: "not allowed"},
+		{name: "đọc văn bằng", fn: "ReadCertificate", args: []string{"cert-1"}, check: func(t *testing.T, payload []byte) {
+			var cert models.CertificateOnChain
+			if err := json.Unmarshal(payload, &cert); err != nil || cert.CertID != "cert-1" {
+				t.Fatalf("văn bằng = %s, lỗi = %v", payload, err)
+			}
+		}},
+		{name: "danh sách văn bằng", fn: "GetAllCertificates", check: func(t *testing.T, payload []byte) {
+			var certs []*models.CertificateOnChain
+			if err := json.Unmarshal(payload, &certs); err != nil || len(certs) != 1 {
+				t.Fatalf("danh sách = %s, lỗi = %v", payload, err)
+			}
+		}},
+		{name: "không có bản ghi", fn: "ReadBatch", args: []string{"batch-1"}, wantErr: ErrNotFound.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := stub.invoke(cc, kma, tt.fn, tt.args...)
+			if tt.wantErr != "" {
+				if res.Status != StatusError || !strings.Contains(res.Message, tt.wantErr) {
+					t.Fatalf("status = %d, lỗi = %q, muốn chứa %q", res.Status, res.Message, tt.wantErr)
+				}
+				return
+			}
+			if res.Status != StatusOK {
+				t.Fatalf("status = %d, lỗi = %s", res.Status, res.Message)
+			}
+			tt.check(t, res.Payload)
+		})
+	}
+}
+
+func TestRevokeCertificate(t *testing.T) {
+	tests := []struct {
+		name        string
+		certID      string
+		reasonCode  string
+		revokedAt   string
+		creator     func(t *testing.T) []byte
+		revokeTwice bool
+		wantErr     string
+	}{
+		{name: "thu hồi", certID: "cert-1", reasonCode: "fraud", revokedAt: "2025-01-01"},
+		{name: "đã thu hồi", certID: "cert-1", reasonCode: "fraud", revokedAt: "2025-01-01", revokeTwice: true, wantErr: "đã bị thu hồi"},
+		{name: "thiếu mã lý do", certID: "cert-1", revokedAt: "2025-01-01", wantErr: "thiếu mã lý do hoặc ngày thu hồi"},
+		{name: "thiếu ngày thu hồi", certID: "cert-1", reasonCode: "fraud", wantErr: "thiếu mã lý do hoặc ngày thu hồi"},
+		{
+			name: "định danh của trường khác", certID: "cert-1", reasonCode: "fraud", revokedAt: "2025-01-01",
+			creator: func(t *testing.T) []byte { return newCreator(t, "Org1MSP", "admin-hust", "HUST") },
+			wantErr: "không được ghi dữ liệu của trường KMA",
+		},
+		{name: "văn bằng không tồn tại", certID: "cert-9", reasonCode: "fraud", revokedAt: "2025-01-01", wantErr: ErrNotFound.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &Chaincode{}
+			stub := newFakeStub()
+			kma := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+			stub.nextTx(kma, nil)
+			if _, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001"))); err != nil {
+				t.Fatalf("IssueCertificate: %v", err)
+			}
+			if tt.revokeTwice {
+				stub.nextTx(kma, nil)
+				if _, err := cc.RevokeCertificate(stub, "cert-1", "other", "QD-00", "2024-12-31"); err != nil {
+					t.Fatalf("RevokeCertificate: %v", err)
+				}
+			}
+			before := readCertificate(t, cc, stub, "cert-1")
+
+			creator := kma
+			if tt.creator != nil {
+				creator = tt.creator(t)
+			}
+			stub.nextTx(creator, nil)
+			txID, err := cc.RevokeCertificate(stub, tt.certID, tt.reasonCode, "QD-01", tt.revokedAt)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.wantErr)
+				}
+				if after := readCertificate(t, cc, stub, "cert-1"); *after != *before {
+					t.Fatalf("bản ghi bị thay đổi: %+v", after)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RevokeCertificate: %v", err)
+			}
+			if string(txID) != stub.txID {
+				t.Fatalf("tx_id = %s, muốn %s", txID, stub.txID)
+			}
+			cert := readCertificate(t, cc, stub, "cert-1")
+			if !cert.Revoked || cert.RevokeReasonCode != tt.reasonCode || cert.RevokedDate != tt.revokedAt {
+				t.Fatalf("bản ghi sau thu hồi: %+v", cert)
+			}
+			if cert.CertHash != before.CertHash || cert.Version != before.Version {
+				t.Fatalf("thu hồi làm đổi nội dung văn bằng: %+v", cert)
+			}
+		})
+	}
+}
+
+func TestGetCertificateHistory(t *testing.T) {
+	cc := &Chaincode{}
+	stub := newFakeStub()
+	kma := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+
+	var txIDs []string
+	stub.nextTx(kma, nil)
+	if _, err := cc.IssueCertificate(stub, certificateJSON(t, newTestCertificate("cert-1", "KMA", "S001"))); err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+	txIDs = append(txIDs, stub.txID)
+	stub.nextTx(kma, nil)
+	update := newTestCertificate("cert-1", "KMA", "S001")
+	update.Version = 2
+	if _, err := cc.UpdateCertificate(stub, certificateJSON(t, update)); err != nil {
+		t.Fatalf("UpdateCertificate: %v", err)
+	}
+	txIDs = append(txIDs, stub.txID)
+	stub.nextTx(kma, nil)
+	if _, err := cc.RevokeCertificate(stub, "cert-1", "fraud", "QD-01", "2025-01-01"); err != nil {
+		t.Fatalf("RevokeCertificate: %v", err)
+	}
+	txIDs = append(txIDs, stub.txID)
+
+	raw, err := cc.GetCertificateHistory(stub, "cert-1")
+	if err != nil {
+		t.Fatalf("GetCertificateHistory: %v", err)
+	}
+	var history []*models.LedgerHistoryEntry
+	if err := json.Unmarshal(raw, &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != len(txIDs) {
+		t.Fatalf("số bản ghi lịch sử = %d, muốn %d", len(history), len(txIDs))
+	}
+	wantVersions := []int{1, 2, 2}
+	for i, entry := range history {
+		if entry.TxID != txIDs[i] || entry.Value == nil || entry.Value.Version != wantVersions[i] {
+			t.Fatalf("lịch sử[%d] = %+v, muốn tx_id %s, phiên bản %d", i, entry, txIDs[i], wantVersions[i])
+		}
+	}
+	if !history[2].Value.Revoked || history[1].Value.Revoked {
+		t.Fatal("trạng thái thu hồi trong lịch sử không đúng")
+	}
+
+	if _, err := cc.GetCertificateHistory(stub, "cert-9"); err == nil || !strings.HasPrefix(err.Error(), ErrNotFound.Error()) {
+		t.Fatalf("lỗi = %v, muốn %v", err, ErrNotFound)
+	}
+}
+
+func TestAnchorBatch(t *testing.T) {
+	batchJSON := func(t *testing.T, batch *models.CertificateBatchOnChain) string {
+		t.Helper()
+		raw, err := json.Marshal(batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+	newBatch := func() *models.CertificateBatchOnChain {
+		return &models.CertificateBatchOnChain{BatchID: "batch-1", UniversityCode: "KMA", MerkleRoot: "root-1"}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*models.CertificateBatchOnChain)
+		creator func(t *testing.T) []byte
+		anchor  bool // Neo trước một lô cùng batch_id
+		wantErr string
+	}{
+		{name: "neo lô"},
+		{name: "lô đã tồn tại", anchor: true, wantErr: "lô batch-1 đã tồn tại"},
+		{name: "thiếu merkle_root", mutate: func(b *models.CertificateBatchOnChain) { b.MerkleRoot = "" }, wantErr: "thiếu batch_id, merkle_root hoặc university_code"},
+		{name: "thiếu university_code", mutate: func(b *models.CertificateBatchOnChain) { b.UniversityCode = "" }, wantErr: "thiếu batch_id, merkle_root hoặc university_code"},
+		{
+			name:    "định danh của trường khác",
+			creator: func(t *testing.T) []byte { return newCreator(t, "Org1MSP", "admin-hust", "HUST") },
+			wantErr: "không được ghi dữ liệu của trường KMA",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := &Chaincode{}
+			stub := newFakeStub()
+			kma := newCreator(t, "Org1MSP", "admin-kma", "KMA")
+			if tt.anchor {
+				stub.nextTx(kma, nil)
+				if _, err := cc.AnchorBatch(stub, batchJSON(t, newBatch())); err != nil {
+					t.Fatalf("AnchorBatch: %v", err)
+				}
+			}
+
+			batch := newBatch()
+			batch.MerkleRoot = "root-2"
+			if tt.mutate != nil {
+				tt.mutate(batch)
+			}
+			creator := kma
+			if tt.creator != nil {
+				creator = tt.creator(t)
+			}
+			stub.nextTx(creator, nil)
+			txID, err := cc.AnchorBatch(stub, batchJSON(t, batch))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.wantErr)
+				}
+				if raw, err := cc.ReadBatch(stub, "batch-1"); err == nil && strings.Contains(string(raw), "root-2") {
+					t.Fatal("lô bị từ chối vẫn được ghi lên sổ cái")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AnchorBatch: %v", err)
+			}
+			if string(txID) != stub.txID {
+				t.Fatalf("tx_id = %s, muốn %s", txID, stub.txID)
+			}
+
+			raw, err := cc.ReadBatch(stub, "batch-1")
+			if err != nil {
+				t.Fatalf("ReadBatch: %v", err)
+			}
+			var got models.CertificateBatchOnChain
+			if err := json.Unmarshal(raw, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.MerkleRoot != "root-2" || got.SubmittedBy != "admin-kma@Org1MSP" {
+				t.Fatalf("lô đọc lại = %+v", got)
+			}
+			// Lô và văn bằng nằm ở hai loại khóa khác nhau nên cùng mã không đè nhau
+			if _, err := cc.ReadCertificate(stub, "batch-1"); err == nil {
+				t.Fatal("lô được đọc như văn bằng")
+			}
+		})
+	}
+
+	cc := &Chaincode{}
+	if _, err := cc.ReadBatch(newFakeStub(), "batch-9"); err == nil || !strings.HasPrefix(err.Error(), ErrNotFound.Error()) {
+		t.Fatalf("lỗi = %v, muốn %v", err, ErrNotFound)
+	}
+}
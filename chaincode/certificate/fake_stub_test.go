@@ -0,0 +1,206 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// fakeStub giả lập peer trong bộ nhớ theo interface Stub, hành vi theo shimtest.MockStub:
+// khóa tổng hợp mã hóa và kiểm tra giống shim, invoke gọi Chaincode.Invoke như MockInvoke.
+// Module backend không phụ thuộc fabric-chaincode-go nên không dùng trực tiếp MockStub.
+type fakeStub struct {
+	fn        string
+	args      []string
+	txID      string
+	txCount   int
+	creator   []byte
+	state     map[string][]byte
+	history   map[string][]*KeyModification
+	private   map[string]map[string][]byte
+	transient map[string][]byte
+}
+
+func newFakeStub() *fakeStub {
+	return &fakeStub{
+		state:   map[string][]byte{},
+		history: map[string][]*KeyModification{},
+		private: map[string]map[string][]byte{},
+	}
+}
+
+// nextTx bắt đầu giao dịch mới dưới định danh creator, transient của giao dịch trước bị xóa
+func (s *fakeStub) nextTx(creator []byte, transient map[string][]byte) {
+	s.txCount++
+	s.txID = fmt.Sprintf("tx-%d", s.txCount)
+	s.creator = creator
+	s.transient = transient
+}
+
+// invoke gửi giao dịch fn(args...) qua Chaincode.Invoke dưới định danh creator
+func (s *fakeStub) invoke(cc *Chaincode, creator []byte, fn string, args ...string) Response {
+	s.nextTx(creator, nil)
+	s.fn, s.args = fn, args
+	return cc.Invoke(s)
+}
+
+func (s *fakeStub) GetFunctionAndParameters() (string, []string) {
+	return s.fn, s.args
+}
+
+func (s *fakeStub) GetTxID() string {
+	return s.txID
+}
+
+func (s *fakeStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *fakeStub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *fakeStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	s.history[key] = append(s.history[key], &KeyModification{TxID: s.txID, Value: value, Timestamp: time.Now()})
+	return nil
+}
+
+func (s *fakeStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	if err := validateCompositeKeyAttribute(objectType); err != nil {
+		return "", err
+	}
+	key := "\x00" + objectType + "\x00"
+	for _, attr := range attributes {
+		if err := validateCompositeKeyAttribute(attr); err != nil {
+			return "", err
+		}
+		key += attr + "\x00"
+	}
+	return key, nil
+}
+
+// validateCompositeKeyAttribute từ chối thành phần khóa mà shim từ chối
+func validateCompositeKeyAttribute(str string) error {
+	if !utf8.ValidString(str) {
+		return fmt.Errorf("not a valid utf8 string: [%x]", str)
+	}
+	for index, runeValue := range str {
+		if runeValue == 0 || runeValue == utf8.MaxRune {
+			return fmt.Errorf("input contains unicode %#U starting at position [%d]. %#U and %#U are not allowed in the input attribute of a composite key",
+				runeValue, index, 0, utf8.MaxRune)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStub) GetStateByPartialCompositeKey(objectType string, keys []string) (StateIterator, error) {
+	prefix, _ := s.CreateCompositeKey(objectType, keys)
+	iter := &fakeStateIterator{}
+	for key, value := range s.state {
+		if strings.HasPrefix(key, prefix) {
+			iter.items = append(iter.items, &KV{Key: key, Value: value})
+		}
+	}
+	sort.Slice(iter.items, func(i, j int) bool { return iter.items[i].Key < iter.items[j].Key })
+	return iter, nil
+}
+
+func (s *fakeStub) GetHistoryForKey(key string) (HistoryIterator, error) {
+	return &fakeHistoryIterator{items: s.history[key]}, nil
+}
+
+func (s *fakeStub) GetTransient() (map[string][]byte, error) {
+	if s.transient == nil {
+		return map[string][]byte{}, nil
+	}
+	return s.transient, nil
+}
+
+func (s *fakeStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.private[collection][key], nil
+}
+
+func (s *fakeStub) PutPrivateData(collection, key string, value []byte) error {
+	if s.private[collection] == nil {
+		s.private[collection] = map[string][]byte{}
+	}
+	s.private[collection][key] = value
+	return nil
+}
+
+type fakeStateIterator struct {
+	items []*KV
+}
+
+func (it *fakeStateIterator) HasNext() bool { return len(it.items) > 0 }
+
+func (it *fakeStateIterator) Next() (*KV, error) {
+	kv := it.items[0]
+	it.items = it.items[1:]
+	return kv, nil
+}
+
+func (it *fakeStateIterator) Close() error { return nil }
+
+type fakeHistoryIterator struct {
+	items []*KeyModification
+}
+
+func (it *fakeHistoryIterator) HasNext() bool { return len(it.items) > 0 }
+
+func (it *fakeHistoryIterator) Next() (*KeyModification, error) {
+	mod := it.items[0]
+	it.items = it.items[1:]
+	return mod, nil
+}
+
+func (it *fakeHistoryIterator) Close() error { return nil }
+
+// newCreator tạo định danh gửi giao dịch như Fabric CA cấp: chứng thư tự ký mang thuộc tính university_code
+func newCreator(t *testing.T, mspID, commonName, universityCode string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if universityCode != "" {
+		attrs, err := json.Marshal(map[string]map[string]string{"attrs": {universityCodeAttribute: universityCode}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.ExtraExtensions = []pkix.Extension{{Id: fabricAttributesOID, Value: attrs}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creator
+}
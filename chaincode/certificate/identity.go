@@ -0,0 +1,70 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// universityCodeAttribute trùng với blockchain.UniversityCodeAttribute mà backend yêu cầu CA nhúng vào chứng thư của từng trường
+const universityCodeAttribute = "university_code"
+
+// fabricAttributesOID là extension Fabric CA dùng để nhúng thuộc tính vào chứng thư
+var fabricAttributesOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// clientIdentity là định danh gửi giao dịch đọc từ creator của proposal
+type clientIdentity struct {
+	MSPID string
+	ID    string // Common name của chứng thư
+	Attrs map[string]string
+}
+
+// String là giá trị ghi vào submitted_by
+func (id *clientIdentity) String() string {
+	return id.ID + "@" + id.MSPID
+}
+
+func (id *clientIdentity) UniversityCode() string {
+	return id.Attrs[universityCodeAttribute]
+}
+
+func getClientIdentity(stub Stub) (*clientIdentity, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return nil, fmt.Errorf("không đọc được định danh gửi giao dịch: %w", err)
+	}
+	var serialized msp.SerializedIdentity
+	if err := proto.Unmarshal(creator, &serialized); err != nil {
+		return nil, fmt.Errorf("định danh gửi giao dịch không hợp lệ: %w", err)
+	}
+	block, _ := pem.Decode(serialized.IdBytes)
+	if block == nil {
+		return nil, fmt.Errorf("định danh gửi giao dịch không có chứng thư X.509")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("chứng thư gửi giao dịch không hợp lệ: %w", err)
+	}
+
+	id := &clientIdentity{MSPID: serialized.Mspid, ID: cert.Subject.CommonName, Attrs: map[string]string{}}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(fabricAttributesOID) {
+			continue
+		}
+		var attrs struct {
+			Attrs map[string]string `json:"attrs"`
+		}
+		if err := json.Unmarshal(ext.Value, &attrs); err != nil {
+			return nil, fmt.Errorf("thuộc tính trong chứng thư không hợp lệ: %w", err)
+		}
+		if attrs.Attrs != nil {
+			id.Attrs = attrs.Attrs
+		}
+	}
+	return id, nil
+}
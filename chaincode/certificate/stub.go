@@ -0,0 +1,67 @@
+package certificate
+
+import (
+	"time"
+)
+
+// Stub là phần của shim.ChaincodeStubInterface mà chaincode văn bằng sử dụng.
+// Chaincode không phụ thuộc trực tiếp vào shim; chương trình chạy trên peer bọc stub thật theo interface này.
+type Stub interface {
+	GetFunctionAndParameters() (string, []string)
+	GetTxID() string
+	GetCreator() ([]byte, error)
+	GetState(key string) ([]byte, error)
+	PutState(key string, value []byte) error
+	CreateCompositeKey(objectType string, attributes []string) (string, error)
+	GetStateByPartialCompositeKey(objectType string, keys []string) (StateIterator, error)
+	GetHistoryForKey(key string) (HistoryIterator, error)
+	GetTransient() (map[string][]byte, error)
+	GetPrivateData(collection, key string) ([]byte, error)
+	PutPrivateData(collection, key string, value []byte) error
+}
+
+// KV là một cặp khóa, giá trị trong world state
+type KV struct {
+	Key   string
+	Value []byte
+}
+
+// KeyModification là một lần ghi lên khóa trong lịch sử sổ cái
+type KeyModification struct {
+	TxID      string
+	Value     []byte
+	Timestamp time.Time
+	IsDelete  bool
+}
+
+type StateIterator interface {
+	HasNext() bool
+	Next() (*KV, error)
+	Close() error
+}
+
+type HistoryIterator interface {
+	HasNext() bool
+	Next() (*KeyModification, error)
+	Close() error
+}
+
+// Response là kết quả trả cho peer: Status 200 kèm Payload khi thành công, 500 kèm Message khi lỗi
+type Response struct {
+	Status  int32
+	Message string
+	Payload []byte
+}
+
+const (
+	StatusOK    = 200
+	StatusError = 500
+)
+
+func success(payload []byte) Response {
+	return Response{Status: StatusOK, Payload: payload}
+}
+
+func failure(err error) Response {
+	return Response{Status: StatusError, Message: err.Error()}
+}
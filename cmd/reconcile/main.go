@@ -0,0 +1,80 @@
+// Lệnh reconcile đối soát văn bằng trong MongoDB với sổ cái một lần rồi thoát.
+// Mã thoát 0: không có sai lệch, 1: lỗi khi chạy, 2: có sai lệch.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/database"
+)
+
+func main() {
+	out := flag.String("out", "", "Ghi báo cáo JSON ra file (mặc định in ra màn hình)")
+	timeout := flag.Duration("timeout", 30*time.Minute, "Thời gian tối đa cho một lần đối soát")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Không tìm thấy file .env, đang dùng biến môi trường hệ thống")
+	}
+
+	if err := database.ConnectMongo(); err != nil {
+		log.Fatalf("Lỗi khi kết nối MongoDB: %v", err)
+	}
+	db := database.DB
+
+	ledger, err := blockchain.NewLedger(blockchain.NewLedgerConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Không thể khởi tạo sổ cái: %v", err)
+	}
+
+	reconciliationSvc := service.NewReconciliationService(
+		repository.NewCertificateRepository(db),
+		repository.NewReconciliationReportRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewFacultyRepository(db),
+		repository.NewUniversityRepository(db),
+		ledger,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report, runErr := reconciliationSvc.Run(ctx, service.ReconciliationTriggerCLI)
+	if report != nil {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Không thể xuất báo cáo: %v", err)
+		}
+		if *out != "" {
+			if err := os.WriteFile(*out, data, 0o644); err != nil {
+				log.Fatalf("Không thể ghi file báo cáo: %v", err)
+			}
+		} else {
+			fmt.Println(string(data))
+		}
+		log.Printf("Đã đối soát %d văn bằng, %d bản ghi trên sổ cái, %d sai lệch %v", report.Checked, report.OnChain, len(report.Drifts), report.Summary)
+	}
+
+	code := 0
+	switch {
+	case runErr != nil:
+		log.Printf("Đối soát thất bại: %v", runErr)
+		code = 1
+	case report.HasDrift():
+		code = 2
+	}
+	if err := database.CloseMongo(); err != nil {
+		log.Printf("Lỗi khi đóng kết nối MongoDB: %v", err)
+	}
+	os.Exit(code)
+}
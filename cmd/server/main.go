@@ -1,18 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/handlers"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
 	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/credential"
 	"github.com/vnkmasc/Kmasc/app/backend/pkg/database"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/diploma"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/signing"
 	"github.com/vnkmasc/Kmasc/app/backend/routes"
 	"github.com/vnkmasc/Kmasc/app/backend/utils"
 )
@@ -26,7 +31,7 @@ func main() {
 		log.Fatalf("Lỗi khi kết nối MongoDB: %v", err)
 	}
 	db := database.DB
-	fabricCfg := blockchain.NewFabricConfigFromEnv()
+	ledgerCfg := blockchain.NewLedgerConfigFromEnv()
 
 	InitValidator()
 	seedAdminAccount(db)
@@ -53,9 +58,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("Không thể khởi tạo MinIO client: %v", err)
 	}
-	fabricClient, err := blockchain.NewFabricClient(fabricCfg)
+	keyStore := signing.NewKeyStoreFromEnv()
+	diplomaRenderer := diploma.NewRendererFromEnv()
+	ledger, err := blockchain.NewLedger(ledgerCfg)
 	if err != nil {
-		log.Fatalf("khởi tạo FabricClient thất bại: %v", err)
+		log.Fatalf("Không thể khởi tạo sổ cái: %v", err)
 	}
 
 	// Repository
@@ -63,6 +70,14 @@ func main() {
 	authRepo := repository.NewAuthRepository(db)
 	universityRepo := repository.NewUniversityRepository(db)
 	certificateRepo := repository.NewCertificateRepository(db)
+	if err := certificateRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Không thể tạo index cho certificates: %v", err)
+	}
+	certificateVersionRepo := repository.NewCertificateVersionRepository(db)
+	certificateTypeRepo := repository.NewCertificateTypeRepository(db)
+	blockchainJobRepo := repository.NewBlockchainJobRepository(db)
+	certificateBatchRepo := repository.NewCertificateBatchRepository(db)
+	reconciliationReportRepo := repository.NewReconciliationReportRepository(db)
 	facultyRepo := repository.NewFacultyRepository(db)
 	verificationRepo := repository.NewVerificationRepository(db)
 	rewardDisciplineRepo := repository.NewRewardDisciplineRepository(db)
@@ -70,15 +85,41 @@ func main() {
 	// Services
 	userService := service.NewUserService(userRepo, universityRepo, facultyRepo)
 	authService := service.NewAuthService(authRepo, userRepo, emailSender)
-	universityService := service.NewUniversityService(universityRepo, authRepo, emailSender)
-	certificateService := service.NewCertificateService(certificateRepo, userRepo, facultyRepo, universityRepo, minioClient)
+	universityService := service.NewUniversityService(universityRepo, authRepo, emailSender, keyStore)
+	certificateService := service.NewCertificateService(certificateRepo, certificateVersionRepo, blockchainJobRepo, certificateTypeRepo, userRepo, facultyRepo, universityRepo, minioClient, ledger, keyStore, diplomaRenderer)
+	certificateTypeService := service.NewCertificateTypeService(certificateTypeRepo, certificateRepo)
 	facultyService := service.NewFacultyService(universityRepo, facultyRepo)
 	verificationService := service.NewVerificationService(verificationRepo, certificateService)
 	rewardDisciplineService := service.NewRewardDisciplineService(rewardDisciplineRepo, userRepo)
 	blockchainSvc := service.NewBlockchainService(
-		certificateRepo, userRepo, facultyRepo, universityRepo, fabricClient,
+		certificateRepo, certificateVersionRepo, blockchainJobRepo, certificateBatchRepo, userRepo, facultyRepo, universityRepo, minioClient, ledger,
+	)
+	reconciliationSvc := service.NewReconciliationService(
+		certificateRepo, reconciliationReportRepo, userRepo, facultyRepo, universityRepo, ledger,
+	)
+	credentialService := service.NewCredentialService(
+		certificateRepo, certificateTypeRepo, userRepo, facultyRepo, universityRepo, keyStore, ledger, credential.BaseURLFromEnv(),
+	)
+	badgeService := service.NewBadgeService(
+		certificateRepo, certificateTypeRepo, userRepo, universityRepo, keyStore, ledger, credential.BaseURLFromEnv(),
 	)
 
+	workerInterval, err := time.ParseDuration(os.Getenv("BLOCKCHAIN_WORKER_INTERVAL"))
+	if err != nil {
+		workerInterval = 5 * time.Second
+	}
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	go service.NewBlockchainWorker(blockchainSvc, workerInterval).Run(workerCtx)
+
+	// Đặt RECONCILIATION_INTERVAL=0s để tắt đối soát định kỳ
+	reconciliationInterval, err := time.ParseDuration(os.Getenv("RECONCILIATION_INTERVAL"))
+	if err != nil {
+		reconciliationInterval = 24 * time.Hour
+	}
+	if reconciliationInterval > 0 {
+		go service.NewReconciliationWorker(reconciliationSvc, reconciliationInterval).Run(workerCtx)
+	}
+
 	// Handlers
 	facultyHandler := handlers.NewFacultyHandler(facultyService)
 	userHandler := handlers.NewUserHandler(userService)
@@ -95,9 +136,13 @@ func main() {
 
 	fileHandler := handlers.NewFileHandler(minioClient)
 	blockchainHandler := handlers.NewBlockchainHandler(blockchainSvc)
+	certificateTypeHandler := handlers.NewCertificateTypeHandler(certificateTypeService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationSvc)
+	credentialHandler := handlers.NewCredentialHandler(credentialService)
+	badgeHandler := handlers.NewBadgeHandler(badgeService)
 
 	// Setup router
-	r := routes.SetupRouter(
+	r, err := routes.SetupRouter(
 		userHandler,
 		authHandler,
 		certificateHandler,
@@ -107,7 +152,14 @@ func main() {
 		verificationHandler,
 		rewardDisciplineHandler,
 		blockchainHandler,
+		certificateTypeHandler,
+		reconciliationHandler,
+		credentialHandler,
+		badgeHandler,
 	)
+	if err != nil {
+		log.Fatalf("Không thể khởi tạo router: %v", err)
+	}
 
 	// Xử lý tín hiệu dừng
 	go func() {
@@ -115,6 +167,7 @@ func main() {
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		<-quit
 		log.Println("Đang tắt server...")
+		stopWorker()
 		if err := database.CloseMongo(); err != nil {
 			log.Printf("Lỗi khi đóng kết nối MongoDB: %v", err)
 		}
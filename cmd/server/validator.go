@@ -2,12 +2,10 @@ package main
 
 import (
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
-	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 )
 
 func InitValidator() {
@@ -17,16 +15,6 @@ func InitValidator() {
 			return re.MatchString(fl.Field().String())
 		})
 
-		_ = v.RegisterValidation("certtype", func(fl validator.FieldLevel) bool {
-			val := strings.TrimSpace(fl.Field().String())
-			switch val {
-			case "Cử nhân", "Kỹ sư", "Thạc sĩ", "Tiến sĩ":
-				return true
-			default:
-				return false
-			}
-		})
-
 		// Add validator for date format dd/mm/yyyy
 		_ = v.RegisterValidation("dateformat", func(fl validator.FieldLevel) bool {
 			dateStr := fl.Field().String()
@@ -56,7 +44,5 @@ func InitValidator() {
 			level := int(fl.Field().Int())
 			return level >= 1 && level <= 4
 		})
-
-		v.RegisterStructValidation(models.ValidateCreateCertificateRequest, models.CreateCertificateRequest{})
 	}
 }
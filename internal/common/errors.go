@@ -15,11 +15,13 @@ var (
 	ErrUniversityNameExists           = errors.New("university_name_exists")
 	ErrUniversityEmailDomainExists    = errors.New("university_email_domain_exists")
 	ErrUniversityCodeExists           = errors.New("university_code_exists")
+	ErrInvalidUniversityCode          = errors.New("invalid_university_code")
 	ErrUniversityNotFound             = errors.New("university not found")
 	ErrAccountUniversityNotFound      = errors.New("university account not found")
 	ErrUniversityAlreadyApproved      = errors.New("university_already_approved")
 	ErrAccountUniversityAlreadyExists = errors.New("university_admin_account_already_exists")
 	ErrAccountNotFound                = errors.New("account_not_found")
+	ErrInvalidStaffRole               = errors.New("invalid_staff_role")
 	ErrInvalidOldPassword             = errors.New("invalid_old_password")
 	ErrPersonalAccountAlreadyExist    = errors.New("personal_account_already_exists")
 	ErrCheckingPersonalAccount        = errors.New("error_checking_personal_account")
@@ -29,10 +31,51 @@ var (
 	ErrFacultyCodeExists = errors.New("faculty_code_existed")
 
 	//Certificate
-	ErrCertificateNotFound      = errors.New("certificate_not_found")
-	ErrCertificateAlreadyExists = errors.New("certificate_already_exists")
-	ErrSerialNumberExists       = errors.New("serial_number_exists")
-	ErrRegNoExists              = errors.New("reg_no_exists")
+	ErrCertificateNotFound        = errors.New("certificate_not_found")
+	ErrCertificateAlreadyExists   = errors.New("certificate_already_exists")
+	ErrSerialNumberExists         = errors.New("serial_number_exists")
+	ErrRegNoExists                = errors.New("reg_no_exists")
+	ErrCertificateRevoked         = errors.New("certificate_revoked")
+	ErrCertificateAccessDenied    = errors.New("certificate_access_denied")
+	ErrCertificateAlreadySigned   = errors.New("certificate_already_signed")
+	ErrCertificateNotSigned       = errors.New("certificate_not_signed")
+	ErrSigningKeyNotFound         = errors.New("signing_key_not_found")
+	ErrSigningKeyExists           = errors.New("signing_key_exists")
+	ErrCertificateFileExists      = errors.New("certificate_file_exists")
+	ErrInvalidStatusTransition    = errors.New("invalid_status_transition")
+	ErrTransitionNotPermitted     = errors.New("transition_not_permitted")
+	ErrTransitionSameActor        = errors.New("transition_same_actor")
+	ErrCertificateNotApproved     = errors.New("certificate_not_approved")
+	ErrCertificateVersionConflict = errors.New("certificate_version_conflict")
+	ErrCertificateStatusConflict  = errors.New("certificate_status_conflict")
+	ErrCommentRequired            = errors.New("comment_required")
+
+	//Blockchain job
+	ErrBlockchainJobNotFound     = errors.New("blockchain_job_not_found")
+	ErrBlockchainJobNotRetryable = errors.New("blockchain_job_not_retryable")
+
+	//Certificate batch
+	ErrCertificateBatchNotFound        = errors.New("certificate_batch_not_found")
+	ErrCertificateBatchMixedUniversity = errors.New("certificate_batch_mixed_university")
+	ErrCertificateBatchStale           = errors.New("certificate_batch_stale")
+	ErrCertificateAlreadyAnchored      = errors.New("certificate_already_anchored")
+	ErrCertificateAlreadyQueued        = errors.New("certificate_already_queued")
+
+	//Selective disclosure
+	ErrDisclosureNotAvailable = errors.New("disclosure_not_available")
+	ErrInvalidDisclosureField = errors.New("invalid_disclosure_field")
+
+	//Verifiable credential
+	ErrInvalidCredential   = errors.New("invalid_credential")
+	ErrCertificateIsDegree = errors.New("certificate_is_degree")
+
+	//Reconciliation
+	ErrReconciliationReportNotFound = errors.New("reconciliation_report_not_found")
+
+	//Certificate type
+	ErrCertificateTypeNotFound = errors.New("certificate_type_not_found")
+	ErrCertificateTypeExists   = errors.New("certificate_type_exists")
+	ErrCertificateTypeInUse    = errors.New("certificate_type_in_use")
 
 	ErrMissingRequiredFieldsForDegree      = errors.New("missing_required_fields_for_degree")
 	ErrMissingRequiredFieldsForCertificate = errors.New("missing_required_fields_for_certificate")
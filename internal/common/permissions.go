@@ -0,0 +1,101 @@
+package common
+
+import "slices"
+
+// Permission là quyền trên một nhóm chức năng; route khai báo quyền cần có, vai trò nào có quyền do RolePermissions quyết định
+type Permission string
+
+const (
+	// PermPublic đánh dấu route không cần đăng nhập
+	PermPublic Permission = "public"
+	// PermProfile là các thao tác trên tài khoản của chính người dùng, mọi vai trò đều có
+	PermProfile Permission = "profile"
+
+	PermAccountManage      Permission = "account:manage"       // Xem, xóa tài khoản trên toàn hệ thống
+	PermStaffAccountManage Permission = "staff_account:manage" // Tạo tài khoản cán bộ khoa, ban giám hiệu của trường
+	PermUniversityManage   Permission = "university:manage"    // Duyệt trường đăng ký
+	PermSigningKeyManage   Permission = "signing_key:manage"
+
+	PermUserRead  Permission = "user:read"
+	PermUserWrite Permission = "user:write"
+
+	PermFacultyRead  Permission = "faculty:read"
+	PermFacultyWrite Permission = "faculty:write"
+
+	PermCertificateTypeRead  Permission = "certificate_type:read"
+	PermCertificateTypeWrite Permission = "certificate_type:write"
+
+	PermCertificateRead       Permission = "certificate:read" // Danh sách, tìm kiếm văn bằng của trường
+	PermCertificateView       Permission = "certificate:view" // Xem một văn bằng, service kiểm tra thêm quyền trên văn bằng đó
+	PermCertificateReadOwn    Permission = "certificate:read_own"
+	PermCertificateIssue      Permission = "certificate:issue" // Tạo, nhập, tải file văn bằng
+	PermCertificateDelete     Permission = "certificate:delete"
+	PermCertificateAmend      Permission = "certificate:amend"
+	PermCertificateRevoke     Permission = "certificate:revoke"
+	PermCertificateTransition Permission = "certificate:transition" // Service kiểm tra thêm vai trò theo từng bước duyệt
+	PermCertificateSign       Permission = "certificate:sign"
+	PermCertificateExport     Permission = "certificate:export" // Xuất VC, Open Badge, xem lịch sử sổ cái, phiên bản và trạng thái; service kiểm tra thêm quyền trên văn bằng
+
+	PermRewardDisciplineRead    Permission = "reward_discipline:read"
+	PermRewardDisciplineWrite   Permission = "reward_discipline:write"
+	PermRewardDisciplineReadOwn Permission = "reward_discipline:read_own"
+
+	PermVerificationCodeManage Permission = "verification_code:manage"
+
+	PermLedgerRead        Permission = "ledger:read" // Tác vụ ghi sổ cái, lô văn bằng
+	PermLedgerWrite       Permission = "ledger:write"
+	PermLedgerPrivateRead Permission = "ledger:private_read"
+	PermReconciliation    Permission = "ledger:reconciliation"
+)
+
+// AllRoles là mọi vai trò của tài khoản, thêm vai trò mới thì phải bổ sung vào đây và RolePermissions
+var AllRoles = []string{RoleAdmin, RoleUniversityAdmin, RoleStudent, RoleFacultyStaff, RoleRector}
+
+// universityStaffRoles là các vai trò cán bộ của một trường
+var universityStaffRoles = []string{RoleUniversityAdmin, RoleFacultyStaff, RoleRector}
+
+// RolePermissions là ma trận phân quyền: mỗi quyền liệt kê các vai trò được cấp
+var RolePermissions = map[Permission][]string{
+	PermProfile: AllRoles,
+
+	PermAccountManage:      {RoleAdmin},
+	PermStaffAccountManage: {RoleUniversityAdmin},
+	PermUniversityManage:   {RoleAdmin},
+	PermSigningKeyManage:   {RoleUniversityAdmin},
+
+	PermUserRead:  append([]string{RoleAdmin}, universityStaffRoles...),
+	PermUserWrite: {RoleUniversityAdmin},
+
+	PermFacultyRead:  universityStaffRoles,
+	PermFacultyWrite: {RoleUniversityAdmin},
+
+	PermCertificateTypeRead:  universityStaffRoles,
+	PermCertificateTypeWrite: {RoleUniversityAdmin},
+
+	PermCertificateRead:       append([]string{RoleAdmin}, universityStaffRoles...),
+	PermCertificateView:       AllRoles,
+	PermCertificateReadOwn:    {RoleStudent},
+	PermCertificateIssue:      {RoleUniversityAdmin, RoleFacultyStaff},
+	PermCertificateDelete:     {RoleUniversityAdmin},
+	PermCertificateAmend:      {RoleUniversityAdmin},
+	PermCertificateRevoke:     {RoleAdmin, RoleUniversityAdmin},
+	PermCertificateTransition: universityStaffRoles,
+	PermCertificateSign:       {RoleRector},
+	PermCertificateExport:     AllRoles,
+
+	PermRewardDisciplineRead:    universityStaffRoles,
+	PermRewardDisciplineWrite:   {RoleUniversityAdmin},
+	PermRewardDisciplineReadOwn: {RoleStudent},
+
+	PermVerificationCodeManage: {RoleStudent},
+
+	PermLedgerRead:        {RoleAdmin, RoleUniversityAdmin},
+	PermLedgerWrite:       {RoleAdmin, RoleUniversityAdmin},
+	PermLedgerPrivateRead: {RoleAdmin, RoleUniversityAdmin},
+	PermReconciliation:    {RoleAdmin},
+}
+
+// HasPermission cho biết vai trò có được cấp quyền hay không, quyền không có trong ma trận thì không vai trò nào có
+func HasPermission(role string, perm Permission) bool {
+	return slices.Contains(RolePermissions[perm], role)
+}
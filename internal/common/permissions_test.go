@@ -0,0 +1,58 @@
+package common
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name string
+		role string
+		perm Permission
+		want bool
+	}{
+		{"sinh viên không được cấp văn bằng", RoleStudent, PermCertificateIssue, false},
+		{"cán bộ khoa được cấp văn bằng", RoleFacultyStaff, PermCertificateIssue, true},
+		{"ban giám hiệu được ký văn bằng", RoleRector, PermCertificateSign, true},
+		{"quản trị trường không được ký văn bằng", RoleUniversityAdmin, PermCertificateSign, false},
+		{"cán bộ khoa không được ký văn bằng", RoleFacultyStaff, PermCertificateSign, false},
+		{"quản trị trường tạo tài khoản cán bộ", RoleUniversityAdmin, PermStaffAccountManage, true},
+		{"quản trị hệ thống không tạo tài khoản cán bộ", RoleAdmin, PermStaffAccountManage, false},
+		{"sinh viên xem văn bằng của mình", RoleStudent, PermCertificateReadOwn, true},
+		{"sinh viên không xem danh sách văn bằng", RoleStudent, PermCertificateRead, false},
+		{"sinh viên xem phiên bản và trạng thái văn bằng của mình", RoleStudent, PermCertificateExport, true},
+		{"chỉ quản trị hệ thống đối soát", RoleUniversityAdmin, PermReconciliation, false},
+		{"quyền không có trong ma trận", RoleAdmin, Permission("unknown:perm"), false},
+		{"route công khai không cấp cho vai trò nào", RoleAdmin, PermPublic, false},
+		{"vai trò không tồn tại", "guest", PermProfile, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.role, tt.perm); got != tt.want {
+				t.Fatalf("HasPermission(%q, %q) = %v, muốn %v", tt.role, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEveryRoleHasProfile(t *testing.T) {
+	for _, role := range AllRoles {
+		if !HasPermission(role, PermProfile) {
+			t.Fatalf("vai trò %s thiếu quyền %s", role, PermProfile)
+		}
+	}
+}
+
+func TestRolePermissionsUseKnownRoles(t *testing.T) {
+	for perm, roles := range RolePermissions {
+		if len(roles) == 0 {
+			t.Fatalf("quyền %s không được cấp cho vai trò nào", perm)
+		}
+		for _, role := range roles {
+			if !slices.Contains(AllRoles, role) {
+				t.Fatalf("quyền %s cấp cho vai trò không tồn tại %q", perm, role)
+			}
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package common
+
+const (
+	RoleAdmin           = "admin"
+	RoleUniversityAdmin = "university_admin"
+	RoleStudent         = "student"
+	RoleFacultyStaff    = "faculty_staff" // Cán bộ khoa, duyệt văn bằng cấp khoa
+	RoleRector          = "rector"        // Ban giám hiệu, phê duyệt và ký văn bằng
+)
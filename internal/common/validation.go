@@ -43,7 +43,6 @@ func TranslateError(field, tag string) string {
 		},
 		"CertificateType": {
 			"required": "Loại văn bằng không được để trống",
-			"certtype": "Loại văn bằng phải là Cử nhân, Kỹ Sư, Thạc sĩ hoặc Tiến sĩ",
 		},
 		"Name": {
 			"required": "Tên văn bằng không được để trống",
@@ -99,6 +98,13 @@ func TranslateError(field, tag string) string {
 		"DecisionNumber": {
 			"required": "Số quyết định không được để trống",
 		},
+		"ReasonCode": {
+			"required": "Mã lý do thu hồi không được để trống",
+			"oneof":    "Mã lý do thu hồi phải là issued_in_error, fraud, superseded hoặc other",
+		},
+		"Reason": {
+			"required": "Lý do thu hồi không được để trống",
+		},
 		"DisciplineLevel": {
 			"disciplinelevel": "Mức độ kỷ luật phải từ 1 đến 4",
 		},
@@ -193,6 +193,50 @@ func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Xóa tài khoản thành công"})
 }
+func (h *AuthHandler) CreateStaffAccount(c *gin.Context) {
+	var req models.CreateStaffAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		return
+	}
+
+	account, err := h.authService.CreateStaffAccount(c.Request.Context(), universityID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrEmailExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email đã được sử dụng"})
+		case errors.Is(err, common.ErrInvalidStaffRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vai trò chỉ được là faculty_staff hoặc rector"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Tạo tài khoản thất bại", "detail": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": models.AccountResponse{
+		ID:            account.ID,
+		UniversityID:  &account.UniversityID,
+		PersonalEmail: account.PersonalEmail,
+		CreatedAt:     account.CreatedAt.Format(time.RFC3339),
+		Role:          account.Role,
+	}})
+}
+
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req models.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/credential"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BadgeHandler struct {
+	badgeService service.BadgeService
+}
+
+func NewBadgeHandler(badgeService service.BadgeService) *BadgeHandler {
+	return &BadgeHandler{badgeService: badgeService}
+}
+
+func (h *BadgeHandler) ExportCertificateBadge(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	badge, err := h.badgeService.ExportCertificateBadge(c.Request.Context(), claims, id)
+	if err != nil {
+		respondBadgeError(c, "ExportCertificateBadge", err)
+		return
+	}
+	respondBadge(c, badge)
+}
+
+// GetHostedBadge là địa chỉ công khai ghi trong id của huy hiệu
+func (h *BadgeHandler) GetHostedBadge(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	status, err := h.badgeService.GetHostedBadge(c.Request.Context(), id)
+	if err != nil {
+		respondBadgeError(c, "GetHostedBadge", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+func (h *BadgeHandler) GetIssuerProfile(c *gin.Context) {
+	profile, err := h.badgeService.GetIssuerProfile(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondBadgeError(c, "GetIssuerProfile", err)
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *BadgeHandler) GetAchievement(c *gin.Context) {
+	achievement, err := h.badgeService.GetAchievement(c.Request.Context(), c.Param("code"), c.Param("type"))
+	if err != nil {
+		respondBadgeError(c, "GetAchievement", err)
+		return
+	}
+	c.JSON(http.StatusOK, achievement)
+}
+
+func (h *BadgeHandler) VerifyBadge(c *gin.Context) {
+	var req models.VerifyCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu huy hiệu cần xác minh"})
+		return
+	}
+
+	result, err := h.badgeService.VerifyBadge(c.Request.Context(), strings.TrimSpace(req.JWT))
+	if err != nil {
+		respondBadgeError(c, "VerifyBadge", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// respondBadge trả JWT nếu client yêu cầu application/vc+jwt, ngược lại trả JSON gồm credential và JWT
+func respondBadge(c *gin.Context, badge *models.CertificateBadge) {
+	if strings.Contains(c.GetHeader("Accept"), credential.MediaTypeJWT) {
+		c.Data(http.StatusOK, credential.MediaTypeJWT, []byte(badge.JWT))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": badge})
+}
+
+func respondBadgeError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, common.ErrCertificateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chứng chỉ"})
+	case errors.Is(err, common.ErrUniversityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy trường"})
+	case errors.Is(err, common.ErrCertificateTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy loại chứng chỉ"})
+	case errors.Is(err, common.ErrCertificateIsDegree):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Văn bằng không được xuất dưới dạng huy hiệu, hãy dùng Verifiable Credential"})
+	case errors.Is(err, common.ErrCertificateAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xuất chứng chỉ này"})
+	case errors.Is(err, common.ErrCertificateRevoked):
+		c.JSON(http.StatusGone, gin.H{"error": "Chứng chỉ đã bị thu hồi", "revoked": true})
+	case errors.Is(err, common.ErrCertificateNotSigned):
+		c.JSON(http.StatusConflict, gin.H{"error": "Chứng chỉ chưa được ký số"})
+	case errors.Is(err, common.ErrSigningKeyNotFound):
+		c.JSON(http.StatusConflict, gin.H{"error": "Trường chưa có khóa ký số"})
+	case errors.Is(err, common.ErrInvalidCredential):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Huy hiệu không đúng định dạng hoặc không do hệ thống phát hành"})
+	case errors.Is(err, common.ErrUserNotExisted):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy người nhận chứng chỉ"})
+	default:
+		log.Printf("[BadgeHandler] %s error: %v", action, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi xử lý huy hiệu"})
+	}
+}
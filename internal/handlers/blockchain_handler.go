@@ -1,11 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"log"
+	"math"
 	"net/http"
+	"path/filepath"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -24,14 +33,28 @@ func (h *BlockchainHandler) PushCertificateToChain(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
 		return
 	}
-	txID, err := h.BlockchainSvc.PushCertificateToChain(c.Request.Context(), certID)
+	job, err := h.BlockchainSvc.PushCertificateToChain(c.Request.Context(), certID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể đưa lên blockchain", "detail": err.Error()})
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không được phép ghi văn bằng của trường khác lên blockchain"})
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi"})
+		case errors.Is(err, common.ErrCertificateNotApproved):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng chưa được duyệt và ký số, không thể ghi lên blockchain"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể đưa lên blockchain", "detail": err.Error()})
+		}
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message":        "Ghi văn bằng lên blockchain thành công",
-		"transaction_id": txID,
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":        "Đã đưa văn bằng vào hàng đợi ghi blockchain",
+		"job_id":         job.ID.Hex(),
+		"status":         job.Status,
 		"certificate_id": certID.Hex(),
 	})
 }
@@ -40,11 +63,82 @@ func (h *BlockchainHandler) GetCertificateByID(c *gin.Context) {
 	id := c.Param("id")
 	result, err := h.BlockchainSvc.GetCertificateFromChain(c.Request.Context(), id)
 	if err != nil {
+		if isLedgerError(err) {
+			respondLedgerError(c, err)
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, result)
 }
+
+// GetCertificateHistory trả mọi phiên bản của văn bằng trên sổ cái kèm tx_id, thời điểm, định danh gửi giao dịch và nhật ký MongoDB tương ứng
+func (h *BlockchainHandler) GetCertificateHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	history, err := h.BlockchainSvc.GetCertificateHistory(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xem lịch sử văn bằng này"})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
+		default:
+			log.Printf("[BlockchainHandler] GetCertificateHistory error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể truy vấn lịch sử văn bằng"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+// GetCertificatePrivateDetails trả dữ liệu riêng của văn bằng trong private data collection kèm kết quả đối chiếu mã băm
+func (h *BlockchainHandler) GetCertificatePrivateDetails(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	result, err := h.BlockchainSvc.GetCertificatePrivateDetails(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xem dữ liệu riêng của văn bằng này"})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
+		default:
+			log.Printf("[BlockchainHandler] GetCertificatePrivateDetails error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể truy vấn dữ liệu riêng của văn bằng"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
 func (h *BlockchainHandler) VerifyCertificateIntegrity(c *gin.Context) {
 	certID := c.Param("id")
 	if certID == "" {
@@ -52,11 +146,13 @@ func (h *BlockchainHandler) VerifyCertificateIntegrity(c *gin.Context) {
 		return
 	}
 
-	ok, msg, onChainCert, err := h.BlockchainSvc.VerifyCertificateIntegrity(c.Request.Context(), certID)
+	report, err := h.BlockchainSvc.VerifyCertificateIntegrity(c.Request.Context(), certID)
 	if err != nil {
 		switch {
 		case strings.Contains(err.Error(), "certID không hợp lệ"):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
 		case strings.Contains(err.Error(), "không tìm thấy"):
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		default:
@@ -68,18 +164,324 @@ func (h *BlockchainHandler) VerifyCertificateIntegrity(c *gin.Context) {
 		return
 	}
 
-	if !ok {
+	if !report.Valid {
 		c.JSON(http.StatusConflict, gin.H{
 			"valid":    false,
-			"message":  msg,
-			"on_chain": onChainCert,
+			"revoked":  report.Revoked,
+			"message":  report.Message,
+			"checks":   report.Checks,
+			"on_chain": report.OnChain,
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"valid":    true,
-		"message":  msg,
-		"on_chain": onChainCert,
+		"message":  report.Message,
+		"checks":   report.Checks,
+		"on_chain": report.OnChain,
 	})
 }
+
+func (h *BlockchainHandler) PublicVerify(c *gin.Context) {
+	token := strings.TrimSpace(c.Query("token"))
+	universityCode := strings.TrimSpace(c.Query("university_code"))
+	serialNumber := strings.TrimSpace(c.Query("serial_number"))
+	if token == "" && (universityCode == "" || serialNumber == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cần cung cấp token hoặc mã trường và số hiệu văn bằng"})
+		return
+	}
+
+	result, err := h.BlockchainSvc.PublicVerify(c.Request.Context(), universityCode, serialNumber, token)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Mã xác minh không hợp lệ"})
+		case errors.Is(err, common.ErrUniversityNotFound), errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
+		default:
+			log.Printf("[BlockchainHandler] PublicVerify error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi xác minh văn bằng"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// Endpoint xác minh file là công khai nên giới hạn dung lượng giống mỗi file trong ZIP văn bằng
+const maxVerifyFileSize = maxZipEntrySize
+
+func (h *BlockchainHandler) VerifyCertificateFile(c *gin.Context) {
+	// Chừa thêm 1MB cho phần đầu multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVerifyFileSize+1<<20)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File vượt quá dung lượng cho phép (20MB)"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng chọn file văn bằng để xác minh"})
+		return
+	}
+	if file.Size > maxVerifyFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File vượt quá dung lượng cho phép (20MB)"})
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".pdf" && ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chỉ hỗ trợ file PDF, JPG, JPEG, PNG"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể mở file"})
+		return
+	}
+	defer src.Close()
+
+	fileData, err := io.ReadAll(io.LimitReader(src, maxVerifyFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể đọc file"})
+		return
+	}
+	if len(fileData) > maxVerifyFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File vượt quá dung lượng cho phép (20MB)"})
+		return
+	}
+
+	result, err := h.BlockchainSvc.VerifyCertificateFile(c.Request.Context(), fileData)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"valid": false,
+				"error": "File không khớp với bất kỳ văn bằng nào trong hệ thống",
+			})
+		case errors.Is(err, common.ErrUniversityNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy trường"})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
+		default:
+			log.Printf("[BlockchainHandler] VerifyCertificateFile error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi xác minh văn bằng"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// VerifyDisclosure cho bên xác minh kiểm tra các trường sinh viên công bố với gốc các trường trên sổ cái
+func (h *BlockchainHandler) VerifyDisclosure(c *gin.Context) {
+	var req models.VerifyDisclosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	result, err := h.BlockchainSvc.VerifyDisclosure(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, common.ErrCertificateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng trên blockchain"})
+			return
+		}
+		if isLedgerError(err) {
+			respondLedgerError(c, err)
+			return
+		}
+		log.Printf("[BlockchainHandler] VerifyDisclosure error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi xác minh dữ liệu công bố"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *BlockchainHandler) SearchJobs(c *gin.Context) {
+	var params models.SearchBlockchainJobParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tham số không hợp lệ"})
+		return
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.PageSize <= 0 {
+		params.PageSize = 10
+	}
+
+	jobs, total, err := h.BlockchainSvc.SearchJobs(c.Request.Context(), params)
+	if err != nil {
+		if errors.Is(err, common.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       jobs,
+		"page":       params.Page,
+		"page_size":  params.PageSize,
+		"total":      total,
+		"total_page": int(math.Ceil(float64(total) / float64(params.PageSize))),
+	})
+}
+
+func (h *BlockchainHandler) GetJob(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	job, err := h.BlockchainSvc.GetJob(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, common.ErrBlockchainJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy tác vụ"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+func (h *BlockchainHandler) RetryJob(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	job, err := h.BlockchainSvc.RetryJob(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrBlockchainJobNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy tác vụ"})
+		case errors.Is(err, common.ErrBlockchainJobNotRetryable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Chỉ có thể thử lại tác vụ đã thất bại"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Đã đưa tác vụ trở lại hàng đợi",
+		"data":    job,
+	})
+}
+
+func (h *BlockchainHandler) AnchorCertificateBatch(c *gin.Context) {
+	var req models.CreateCertificateBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	certIDs := make([]primitive.ObjectID, 0, len(req.CertificateIDs))
+	for _, idStr := range req.CertificateIDs {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID văn bằng không hợp lệ", "certificate_id": idStr})
+			return
+		}
+		certIDs = append(certIDs, id)
+	}
+
+	batch, job, err := h.BlockchainSvc.AnchorCertificateBatch(c.Request.Context(), certIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng", "detail": err.Error()})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Không có quyền ghi văn bằng của trường khác"})
+		case errors.Is(err, common.ErrCertificateBatchMixedUniversity):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Các văn bằng trong một lô phải cùng một trường"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi", "detail": err.Error()})
+		case errors.Is(err, common.ErrCertificateNotApproved):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng chưa được duyệt và ký số, không thể ghi lên blockchain", "detail": err.Error()})
+		case errors.Is(err, common.ErrCertificateAlreadyAnchored):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã được ghi lên blockchain", "detail": err.Error()})
+		case errors.Is(err, common.ErrCertificateAlreadyQueued):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đang chờ ghi lên blockchain", "detail": err.Error()})
+		default:
+			log.Printf("[BlockchainHandler] AnchorCertificateBatch error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể tạo lô ghi blockchain"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":     "Đã đưa lô văn bằng vào hàng đợi ghi blockchain",
+		"batch_id":    batch.ID.Hex(),
+		"merkle_root": batch.MerkleRoot,
+		"size":        batch.Size,
+		"job_id":      job.ID.Hex(),
+		"status":      job.Status,
+	})
+}
+
+func (h *BlockchainHandler) GetBatch(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	batch, err := h.BlockchainSvc.GetBatch(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, common.ErrCertificateBatchNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy lô văn bằng"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": batch})
+}
+
+// isLedgerError cho biết err có phải lỗi sổ cái đã được phân loại hay không
+func isLedgerError(err error) bool {
+	return errors.Is(err, blockchain.ErrNotFound) ||
+		errors.Is(err, blockchain.ErrEndorsementFailed) ||
+		errors.Is(err, blockchain.ErrTimeout) ||
+		errors.Is(err, blockchain.ErrUnavailable) ||
+		errors.Is(err, blockchain.ErrPrivateDataDenied)
+}
+
+// respondLedgerError trả mã HTTP theo loại lỗi sổ cái
+func respondLedgerError(c *gin.Context, err error) {
+	log.Printf("[Ledger] %s %s error: %v", c.Request.Method, c.FullPath(), err)
+	switch {
+	case errors.Is(err, blockchain.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy dữ liệu trên blockchain"})
+	case errors.Is(err, blockchain.ErrEndorsementFailed):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Blockchain từ chối giao dịch"})
+	case errors.Is(err, blockchain.ErrTimeout):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Blockchain phản hồi quá thời gian chờ"})
+	case errors.Is(err, blockchain.ErrPrivateDataDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tổ chức không thuộc private data collection của văn bằng"})
+	default:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Blockchain tạm thời không khả dụng"})
+	}
+}
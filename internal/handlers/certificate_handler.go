@@ -1,20 +1,26 @@
 package handlers
 
 import (
+	"archive/zip"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/minio/minio-go/v7"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
 	"github.com/vnkmasc/Kmasc/app/backend/pkg/database"
 	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"github.com/xuri/excelize/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -97,6 +103,12 @@ func (h *CertificateHandler) CreateCertificate(c *gin.Context) {
 		case errors.Is(err, common.ErrMissingRequiredFieldsForDegree):
 			c.JSON(http.StatusBadRequest, gin.H{"message": "Thiếu thông tin bắt buộc cho văn bằng"})
 
+		case errors.Is(err, common.ErrMissingRequiredFieldsForCertificate):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Thiếu thông tin bắt buộc cho chứng chỉ"})
+
+		case errors.Is(err, common.ErrCertificateTypeNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Loại văn bằng không có trong danh mục của trường"})
+
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Lỗi hệ thống"})
 		}
@@ -109,6 +121,193 @@ func (h *CertificateHandler) CreateCertificate(c *gin.Context) {
 	})
 }
 
+func (h *CertificateHandler) ImportCertificatesFromExcel(c *gin.Context) {
+	val, exists := c.Get(string(utils.ClaimsContextKey))
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Bạn chưa đăng nhập hoặc token không hợp lệ"})
+		return
+	}
+	claims, ok := val.(*utils.CustomClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng upload file Excel"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Không thể mở file"})
+		return
+	}
+	defer src.Close()
+
+	f, err := excelize.OpenReader(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File không đúng định dạng Excel"})
+		return
+	}
+
+	rows, err := f.GetRows("Sheet1")
+	if err != nil || len(rows) == 0 {
+		rows, err = f.GetRows("Sheet")
+		if err != nil || len(rows) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Không đọc được sheet dữ liệu (Sheet1 hoặc Sheet)"})
+			return
+		}
+	}
+
+	var (
+		successResults []map[string]interface{}
+		errorResults   []map[string]interface{}
+	)
+
+	for i, row := range rows {
+		if i == 0 {
+			continue
+		}
+
+		result := map[string]interface{}{"row": i + 1}
+
+		// Cột: mã SV, loại văn bằng, tên, số hiệu, số vào sổ, ngày cấp, ngành, khóa.
+		// Chỉ 3 cột đầu luôn bắt buộc, các cột còn lại bắt buộc hay không do loại văn bằng quyết định như khi tạo từng văn bằng
+		if len(row) < 3 {
+			result["error"] = "Thiếu dữ liệu"
+			errorResults = append(errorResults, result)
+			continue
+		}
+		// Excel bỏ các ô trống ở cuối dòng nên bổ sung cho đủ cột
+		for len(row) < 8 {
+			row = append(row, "")
+		}
+
+		var issueDate time.Time
+		if strings.TrimSpace(row[5]) != "" {
+			issueDate, err = parseExcelDate(row[5])
+			if err != nil {
+				result["error"] = "Ngày cấp không hợp lệ (định dạng dd/mm/yyyy)"
+				errorResults = append(errorResults, result)
+				continue
+			}
+		}
+
+		req := &models.CreateCertificateRequest{
+			StudentCode:     strings.TrimSpace(row[0]),
+			CertificateType: strings.TrimSpace(row[1]),
+			Name:            strings.TrimSpace(row[2]),
+			SerialNumber:    strings.TrimSpace(row[3]),
+			RegNo:           strings.TrimSpace(row[4]),
+			IssueDate:       issueDate,
+			Major:           strings.TrimSpace(row[6]),
+			Course:          strings.TrimSpace(row[7]),
+		}
+		result["student_code"] = req.StudentCode
+		result["serial_number"] = req.SerialNumber
+
+		if len(row) > 8 && strings.TrimSpace(row[8]) != "" {
+			gpa, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(row[8]), ",", "."), 64)
+			if err != nil {
+				result["error"] = "GPA không hợp lệ"
+				errorResults = append(errorResults, result)
+				continue
+			}
+			req.GPA = gpa
+		}
+
+		if len(row) > 9 {
+			req.GraduationRank = strings.TrimSpace(row[9])
+		}
+
+		if len(row) > 10 {
+			req.EducationType = strings.TrimSpace(row[10])
+		}
+
+		if len(row) > 11 {
+			req.Description = strings.TrimSpace(row[11])
+		}
+
+		// Dùng validator của gin để áp dụng các rule đã đăng ký
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			if errs, ok := common.ParseValidationError(err); ok {
+				var errorMsgs []string
+				for _, msg := range errs {
+					errorMsgs = append(errorMsgs, msg)
+				}
+				result["error"] = strings.Join(errorMsgs, "; ")
+			} else {
+				result["error"] = "Dữ liệu không hợp lệ"
+			}
+			errorResults = append(errorResults, result)
+			continue
+		}
+
+		if err := h.certificateService.CreateCertificate(c.Request.Context(), claims, req); err != nil {
+			switch {
+			case errors.Is(err, common.ErrInvalidToken):
+				result["error"] = "Token không hợp lệ"
+			case errors.Is(err, common.ErrUserNotExisted):
+				result["error"] = "Sinh viên không tồn tại"
+			case errors.Is(err, common.ErrCertificateAlreadyExists):
+				result["error"] = "Văn bằng đã tồn tại"
+			case errors.Is(err, common.ErrFacultyNotFound):
+				result["error"] = "Không tìm thấy khoa"
+			case errors.Is(err, common.ErrUniversityNotFound):
+				result["error"] = "Không tìm thấy trường"
+			case errors.Is(err, common.ErrSerialNumberExists):
+				result["error"] = "Số hiệu văn bằng đã tồn tại"
+			case errors.Is(err, common.ErrRegNoExists):
+				result["error"] = "Số vào sổ gốc đã tồn tại"
+			case errors.Is(err, common.ErrMissingRequiredFieldsForDegree):
+				result["error"] = "Thiếu thông tin bắt buộc cho văn bằng"
+			case errors.Is(err, common.ErrMissingRequiredFieldsForCertificate):
+				result["error"] = "Thiếu thông tin bắt buộc cho chứng chỉ"
+			case errors.Is(err, common.ErrCertificateTypeNotFound):
+				result["error"] = "Loại văn bằng không có trong danh mục của trường"
+			default:
+				result["error"] = err.Error()
+			}
+			errorResults = append(errorResults, result)
+		} else {
+			result["status"] = "Thêm thành công"
+			successResults = append(successResults, result)
+		}
+	}
+
+	if len(errorResults) == 0 {
+		c.JSON(http.StatusCreated, gin.H{
+			"success_count": len(successResults),
+			"data": gin.H{
+				"success": successResults,
+				"error":   []map[string]interface{}{},
+			},
+		})
+	} else {
+		c.JSON(http.StatusMultiStatus, gin.H{
+			"success_count": len(successResults),
+			"error_count":   len(errorResults),
+			"data": gin.H{
+				"success": successResults,
+				"error":   errorResults,
+			},
+		})
+	}
+}
+
+// parseExcelDate đọc ngày cấp dạng dd/mm/yyyy, chấp nhận thêm định dạng ngày mặc định của Excel
+func parseExcelDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"02/01/2006", "2/1/2006", "2006-01-02", "01-02-06"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("ngày không hợp lệ: %s", value)
+}
+
 func (h *CertificateHandler) GetAllCertificates(c *gin.Context) {
 	certs, err := h.certificateService.GetAllCertificates(c.Request.Context())
 	if err != nil {
@@ -133,11 +332,20 @@ func (h *CertificateHandler) GetCertificateByID(c *gin.Context) {
 		return
 	}
 
-	cert, err := h.certificateService.GetCertificateByID(c.Request.Context(), id)
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	cert, err := h.certificateService.GetCertificateByID(c.Request.Context(), claims, id)
 	if err != nil {
-		if errors.Is(err, common.ErrCertificateNotFound) {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy certificate"})
-		} else {
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xem văn bằng này"})
+		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống", "chi_tiet": err.Error()})
 		}
 		return
@@ -241,6 +449,172 @@ func (h *CertificateHandler) UploadCertificateFile(c *gin.Context) {
 	})
 }
 
+// Giới hạn dung lượng mỗi file trong ZIP để tránh giải nén quá lớn
+const maxZipEntrySize = 20 << 20
+
+func (h *CertificateHandler) UploadCertificateFilesZip(c *gin.Context) {
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng chọn file ZIP để tải lên"})
+		return
+	}
+	if strings.ToLower(filepath.Ext(file.Filename)) != ".zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chỉ hỗ trợ file ZIP"})
+		return
+	}
+
+	isDegree := c.Query("is_degree") == "true"
+	certificateName := c.Query("name")
+	if !isDegree && certificateName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu tên chứng chỉ (query param 'name')"})
+		return
+	}
+
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ (UniversityID không đúng định dạng)"})
+		return
+	}
+	university, err := h.universityService.GetUniversityByID(c.Request.Context(), universityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không lấy được thông tin trường đại học"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể mở file"})
+		return
+	}
+	defer src.Close()
+
+	archive, err := zip.NewReader(src, file.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File ZIP không hợp lệ"})
+		return
+	}
+
+	var (
+		matched        []map[string]interface{}
+		unmatched      []map[string]interface{}
+		duplicates     []map[string]interface{}
+		alreadyHasFile []map[string]interface{}
+		failed         []map[string]interface{}
+	)
+
+	// Đếm số lần xuất hiện của mỗi tên để phát hiện file trùng trong cùng một ZIP
+	entries := make([]*zip.File, 0, len(archive.File))
+	nameCount := make(map[string]int)
+	for _, entry := range archive.File {
+		base := filepath.Base(entry.Name)
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		entries = append(entries, entry)
+		nameCount[strings.TrimSuffix(base, filepath.Ext(base))]++
+	}
+
+	for _, entry := range entries {
+		filename := filepath.Base(entry.Name)
+		ext := strings.ToLower(filepath.Ext(filename))
+		key := strings.TrimSuffix(filename, filepath.Ext(filename))
+		result := map[string]interface{}{"file": entry.Name}
+
+		if ext != ".pdf" && ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+			result["error"] = "Chỉ hỗ trợ file PDF, JPG, JPEG, PNG"
+			failed = append(failed, result)
+			continue
+		}
+
+		if nameCount[key] > 1 {
+			result["error"] = "Trùng tên file trong ZIP"
+			duplicates = append(duplicates, result)
+			continue
+		}
+
+		var certificate *models.Certificate
+		if isDegree {
+			certificate, err = h.certificateService.GetCertificateBySerialAndUniversity(
+				c.Request.Context(), key, university.ID)
+		} else {
+			certificate, err = h.certificateService.GetCertificateByStudentCodeAndNameAndUniversity(
+				c.Request.Context(), key, certificateName, university.ID)
+		}
+		if err != nil || certificate == nil || certificate.ID.IsZero() || certificate.UniversityID != university.ID {
+			result["error"] = "Không tìm thấy văn bằng/chứng chỉ phù hợp"
+			unmatched = append(unmatched, result)
+			continue
+		}
+		result["certificate_id"] = certificate.ID.Hex()
+
+		if certificate.Path != "" {
+			result["error"] = "Văn bằng/chứng chỉ đã có file, không thể ghi đè"
+			alreadyHasFile = append(alreadyHasFile, result)
+			continue
+		}
+
+		if entry.UncompressedSize64 > maxZipEntrySize {
+			result["error"] = "File vượt quá dung lượng cho phép"
+			failed = append(failed, result)
+			continue
+		}
+
+		fileData, err := readZipEntry(entry)
+		if err != nil {
+			result["error"] = "Không thể đọc file"
+			failed = append(failed, result)
+			continue
+		}
+
+		filePath, err := h.certificateService.UploadCertificateFile(
+			c.Request.Context(), certificate.ID, fileData, filename, isDegree, certificateName)
+		if err != nil {
+			result["error"] = "Tải lên thất bại: " + err.Error()
+			failed = append(failed, result)
+			continue
+		}
+
+		result["path"] = filePath
+		matched = append(matched, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Đã xử lý file ZIP",
+		"total":         len(entries),
+		"matched_count": len(matched),
+		"data": gin.H{
+			"matched":          matched,
+			"unmatched":        unmatched,
+			"duplicate":        duplicates,
+			"already_has_file": alreadyHasFile,
+			"error":            failed,
+		},
+	})
+}
+
+func readZipEntry(entry *zip.File) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipEntrySize {
+		return nil, fmt.Errorf("file vượt quá dung lượng cho phép")
+	}
+	return data, nil
+}
+
 func (h *CertificateHandler) GetCertificateFile(c *gin.Context) {
 	ctx := c.Request.Context()
 	idParam := c.Param("id")
@@ -251,8 +625,18 @@ func (h *CertificateHandler) GetCertificateFile(c *gin.Context) {
 		return
 	}
 
-	certificate, err := h.certificateService.GetCertificateByID(ctx, certificateID)
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	certificate, err := h.certificateService.GetCertificateByID(ctx, claims, certificateID)
 	if err != nil {
+		if errors.Is(err, common.ErrCertificateAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xem file văn bằng này"})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
 		return
 	}
@@ -408,6 +792,215 @@ func (h *CertificateHandler) DeleteCertificate(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Xóa văn bằng thành công"})
 }
+func (h *CertificateHandler) RevokeCertificate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	var req models.RevokeCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	err = h.certificateService.RevokeCertificate(c.Request.Context(), claims, id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không được phép thu hồi văn bằng này"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi trước đó"})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Thu hồi văn bằng thất bại", "detail": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Thu hồi văn bằng thành công"})
+}
+
+func (h *CertificateHandler) AmendCertificate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	var req models.AmendCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	cert, err := h.certificateService.AmendCertificate(c.Request.Context(), claims, id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không được phép đính chính văn bằng này"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi, không thể đính chính"})
+		case errors.Is(err, common.ErrNoFieldsToUpdate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Không có trường nào thay đổi"})
+		case errors.Is(err, common.ErrCertificateVersionConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng vừa được cập nhật bởi yêu cầu khác, vui lòng tải lại và thử lại"})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Đính chính văn bằng thất bại", "detail": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Đính chính văn bằng thành công",
+		"data":    cert,
+	})
+}
+
+func (h *CertificateHandler) GetCertificateVersions(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	versions, err := h.certificateService.GetCertificateVersions(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xem lịch sử phiên bản của văn bằng này"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống", "chi_tiet": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+func (h *CertificateHandler) SignCertificate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	cert, err := h.certificateService.SignCertificate(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không được phép ký văn bằng này"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi"})
+		case errors.Is(err, common.ErrCertificateAlreadySigned):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã được ký"})
+		case errors.Is(err, common.ErrCertificateNotApproved):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng chưa được ban giám hiệu phê duyệt"})
+		case errors.Is(err, common.ErrTransitionNotPermitted):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền ký văn bằng"})
+		case errors.Is(err, common.ErrSigningKeyNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Trường chưa có khóa ký số"})
+		case isLedgerError(err):
+			respondLedgerError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ký văn bằng thất bại", "detail": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ký số văn bằng thành công",
+		"data":    cert,
+	})
+}
+
+func (h *CertificateHandler) VerifyCertificateSignature(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	valid, err := h.certificateService.VerifyCertificateSignature(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xác minh chữ ký văn bằng này"})
+		case errors.Is(err, common.ErrCertificateNotSigned):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Văn bằng chưa được ký"})
+		case errors.Is(err, common.ErrSigningKeyNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Trường chưa đăng ký khóa công khai"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi xác minh chữ ký", "detail": err.Error()})
+		}
+		return
+	}
+
+	if !valid {
+		c.JSON(http.StatusConflict, gin.H{"valid": false, "message": "Chữ ký số không hợp lệ"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true, "message": "Chữ ký số hợp lệ"})
+}
+
 func (h *CertificateHandler) GetMyCertificateNames(c *gin.Context) {
 	val, exists := c.Get(string(utils.ClaimsContextKey))
 	if !exists {
@@ -434,3 +1027,126 @@ func (h *CertificateHandler) GetMyCertificateNames(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": certificates})
 }
+
+func (h *CertificateHandler) GenerateCertificateFile(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	path, err := h.certificateService.GenerateCertificateFile(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không được phép cập nhật văn bằng này"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi"})
+		case errors.Is(err, common.ErrCertificateFileExists):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Văn bằng/chứng chỉ đã có file, không thể ghi đè"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Tạo file văn bằng thất bại", "detail": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tạo file văn bằng thành công",
+		"path":    path,
+	})
+}
+
+func (h *CertificateHandler) TransitionCertificate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	var req models.CertificateTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	resp, err := h.certificateService.TransitionCertificate(c.Request.Context(), claims, id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không được phép cập nhật văn bằng này"})
+		case errors.Is(err, common.ErrTransitionNotPermitted):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Vai trò của bạn không được phép thực hiện thao tác này"})
+		case errors.Is(err, common.ErrTransitionSameActor):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Người trình văn bằng không được tự duyệt văn bằng đó"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi"})
+		case errors.Is(err, common.ErrInvalidStatusTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": "Không thể thực hiện thao tác ở trạng thái hiện tại của văn bằng"})
+		case errors.Is(err, common.ErrCommentRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vui lòng nhập lý do từ chối"})
+		case errors.Is(err, common.ErrCertificateStatusConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": "Trạng thái văn bằng vừa được thay đổi bởi yêu cầu khác, vui lòng tải lại và thử lại"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Cập nhật trạng thái thất bại", "detail": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cập nhật trạng thái văn bằng thành công",
+		"data":    resp,
+	})
+}
+
+func (h *CertificateHandler) GetCertificateStatusHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	resp, err := h.certificateService.GetCertificateStatusHistory(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không được phép xem văn bằng này"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
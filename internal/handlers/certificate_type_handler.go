@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CertificateTypeHandler struct {
+	certificateTypeService service.CertificateTypeService
+}
+
+func NewCertificateTypeHandler(s service.CertificateTypeService) *CertificateTypeHandler {
+	return &CertificateTypeHandler{certificateTypeService: s}
+}
+
+func (h *CertificateTypeHandler) GetCertificateTypes(c *gin.Context) {
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "university_id không hợp lệ trong token"})
+		return
+	}
+
+	resp, err := h.certificateTypeService.GetCertificateTypes(c.Request.Context(), universityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể lấy danh mục loại văn bằng"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func (h *CertificateTypeHandler) CreateCertificateType(c *gin.Context) {
+	var req models.CreateCertificateTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	resp, err := h.certificateTypeService.CreateCertificateType(c.Request.Context(), claims, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateTypeExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "Mã hoặc tên loại văn bằng đã tồn tại"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": resp})
+}
+
+func (h *CertificateTypeHandler) UpdateCertificateType(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	var req models.UpdateCertificateTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if errs, ok := common.ParseValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	resp, err := h.certificateTypeService.UpdateCertificateType(c.Request.Context(), claims, id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateTypeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy loại văn bằng"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+func (h *CertificateTypeHandler) DeleteCertificateType(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	if err := h.certificateTypeService.DeleteCertificateType(c.Request.Context(), claims, id); err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		case errors.Is(err, common.ErrCertificateTypeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy loại văn bằng"})
+		case errors.Is(err, common.ErrCertificateTypeInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": "Loại văn bằng đã được sử dụng, không thể xóa"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Xóa loại văn bằng thành công"})
+}
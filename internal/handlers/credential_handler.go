@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/credential"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CredentialHandler struct {
+	credentialService service.CredentialService
+}
+
+func NewCredentialHandler(credentialService service.CredentialService) *CredentialHandler {
+	return &CredentialHandler{credentialService: credentialService}
+}
+
+// ExportCertificateCredential trả về văn bằng dạng W3C Verifiable Credential; Accept: application/vc+jwt chỉ trả JWT
+func (h *CredentialHandler) ExportCertificateCredential(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+
+	vc, err := h.credentialService.ExportCertificateCredential(c.Request.Context(), claims, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xuất văn bằng này"})
+		case errors.Is(err, common.ErrCertificateRevoked):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng đã bị thu hồi"})
+		case errors.Is(err, common.ErrCertificateNotSigned):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng chưa được ký số"})
+		case errors.Is(err, common.ErrSigningKeyNotFound):
+			c.JSON(http.StatusConflict, gin.H{"error": "Trường chưa có khóa ký số"})
+		case errors.Is(err, common.ErrUserNotExisted), errors.Is(err, common.ErrFacultyNotFound), errors.Is(err, common.ErrUniversityNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thiếu dữ liệu sinh viên, khoa hoặc trường của văn bằng"})
+		default:
+			log.Printf("[CredentialHandler] ExportCertificateCredential error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể xuất credential"})
+		}
+		return
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), credential.MediaTypeJWT) {
+		c.Data(http.StatusOK, credential.MediaTypeJWT, []byte(vc.JWT))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": vc})
+}
+
+// VerifyCredential kiểm tra JWT-VC do hệ thống phát hành: chữ ký, bên phát hành và trạng thái thu hồi
+func (h *CredentialHandler) VerifyCredential(c *gin.Context) {
+	var req models.VerifyCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu credential cần xác minh"})
+		return
+	}
+
+	result, err := h.credentialService.VerifyCredential(c.Request.Context(), strings.TrimSpace(req.JWT))
+	if err != nil {
+		if errors.Is(err, common.ErrInvalidCredential) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Credential không đúng định dạng hoặc không do hệ thống phát hành"})
+			return
+		}
+		log.Printf("[CredentialHandler] VerifyCredential error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi xác minh credential"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
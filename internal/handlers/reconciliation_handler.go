@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReconciliationHandler struct {
+	reconciliationService service.ReconciliationService
+}
+
+func NewReconciliationHandler(reconciliationService service.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{reconciliationService: reconciliationService}
+}
+
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	report, err := h.reconciliationService.Run(c.Request.Context(), service.ReconciliationTriggerAPI)
+	if err != nil {
+		log.Printf("[ReconciliationHandler] Run error: %v", err)
+		resp := gin.H{"error": "Không thể hoàn tất đối soát", "detail": err.Error()}
+		if report != nil {
+			resp["report_id"] = report.ID.Hex()
+		}
+		c.JSON(http.StatusBadGateway, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Đã hoàn tất đối soát",
+		"data":    report,
+	})
+}
+
+func (h *ReconciliationHandler) SearchReports(c *gin.Context) {
+	var params models.SearchReconciliationReportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tham số không hợp lệ"})
+		return
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.PageSize <= 0 {
+		params.PageSize = 10
+	}
+
+	reports, total, err := h.reconciliationService.SearchReports(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       reports,
+		"page":       params.Page,
+		"page_size":  params.PageSize,
+		"total":      total,
+		"total_page": int(math.Ceil(float64(total) / float64(params.PageSize))),
+	})
+}
+
+func (h *ReconciliationHandler) GetReport(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		return
+	}
+
+	report, err := h.reconciliationService.GetReport(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, common.ErrReconciliationReportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy báo cáo đối soát"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
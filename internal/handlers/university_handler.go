@@ -9,6 +9,8 @@ import (
 	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UniversityHandler struct {
@@ -128,3 +130,51 @@ func (h *UniversityHandler) GetUniversities(c *gin.Context) {
 
 	c.JSON(200, gin.H{"data": resp})
 }
+
+func (h *UniversityHandler) GenerateSigningKey(c *gin.Context) {
+	claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác thực được người dùng"})
+		return
+	}
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		return
+	}
+
+	resp, err := h.universityService.GenerateSigningKey(c.Request.Context(), universityID)
+	if err != nil {
+		switch err {
+		case common.ErrUniversityNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy trường"})
+		case common.ErrSigningKeyExists:
+			c.JSON(http.StatusConflict, gin.H{"error": "Trường đã có khóa ký số"})
+		case common.ErrInvalidUniversityCode:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Mã trường chỉ được gồm chữ, số, dấu gạch ngang và gạch dưới"})
+		default:
+			log.Printf("[UniversityHandler] GenerateSigningKey error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể tạo khóa ký số"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": resp})
+}
+
+func (h *UniversityHandler) GetSigningCertificate(c *gin.Context) {
+	resp, err := h.universityService.GetSigningCertificate(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		switch err {
+		case common.ErrUniversityNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy trường"})
+		case common.ErrSigningKeyNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Trường chưa đăng ký khóa ký số"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi hệ thống"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/minio/minio-go/v7"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/service"
 	"github.com/vnkmasc/Kmasc/app/backend/pkg/database"
@@ -60,16 +62,36 @@ func (h *VerificationHandler) CreateVerificationCode(c *gin.Context) {
 	expiredAt := time.Now().Add(time.Duration(req.DurationMinutes) * time.Minute)
 
 	code := &models.VerificationCode{
-		UserID:       userID,
-		CanViewScore: req.CanViewScore,
-		CanViewData:  req.CanViewData,
-		CanViewFile:  req.CanViewFile,
-		ExpiredAt:    expiredAt,
+		UserID:          userID,
+		CanViewScore:    req.CanViewScore,
+		CanViewData:     req.CanViewData,
+		CanViewFile:     req.CanViewFile,
+		DisclosedFields: req.DisclosedFields,
+		ExpiredAt:       expiredAt,
+	}
+	if len(req.DisclosedFields) > 0 {
+		certificateID, err := primitive.ObjectIDFromHex(req.CertificateID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cần chọn văn bằng hợp lệ để công bố các trường"})
+			return
+		}
+		code.CertificateID = certificateID
 	}
 
 	err = h.verificationService.CreateVerificationCode(c.Request.Context(), code)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể tạo mã xác minh"})
+		switch {
+		case errors.Is(err, common.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy văn bằng"})
+		case errors.Is(err, common.ErrCertificateAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không sở hữu văn bằng này"})
+		case errors.Is(err, common.ErrDisclosureNotAvailable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Văn bằng chưa ghi cam kết các trường lên blockchain"})
+		case errors.Is(err, common.ErrInvalidDisclosureField):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Trường công bố không hợp lệ"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể tạo mã xác minh"})
+		}
 		return
 	}
 
@@ -81,6 +103,7 @@ func (h *VerificationHandler) CreateVerificationCode(c *gin.Context) {
 			"data":  code.CanViewData,
 			"file":  code.CanViewFile,
 		},
+		"disclosed_fields": code.DisclosedFields,
 	})
 }
 func (h *VerificationHandler) GetMyCodes(c *gin.Context) {
@@ -122,6 +145,16 @@ func (h *VerificationHandler) VerifyCode(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
+	if req.ViewType == "disclosure" {
+		disclosure, err := h.verificationService.GetDisclosure(ctx, req.Code)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": disclosure})
+		return
+	}
+
 	_, certResp, err := h.verificationService.VerifyCode(ctx, req.Code, req.ViewType)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -130,6 +163,14 @@ func (h *VerificationHandler) VerifyCode(c *gin.Context) {
 
 	switch req.ViewType {
 	case "data", "score":
+		if certResp != nil && certResp.Revoked {
+			c.JSON(http.StatusOK, gin.H{
+				"data":    certResp,
+				"revoked": true,
+				"message": "Văn bằng đã bị thu hồi",
+			})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"data": certResp,
 		})
@@ -139,6 +180,14 @@ func (h *VerificationHandler) VerifyCode(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy dữ liệu văn bằng"})
 			return
 		}
+		if certResp.Revoked {
+			c.JSON(http.StatusGone, gin.H{
+				"error":      "Văn bằng đã bị thu hồi",
+				"revoked":    true,
+				"revocation": certResp.Revocation,
+			})
+			return
+		}
 		if certResp.Path == "" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Văn bằng chưa có file"})
 			return
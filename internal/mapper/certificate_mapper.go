@@ -6,7 +6,7 @@ import (
 )
 
 func MapCertificateToResponse(cert *models.Certificate, user *models.User, faculty *models.Faculty, university *models.University) *models.CertificateResponse {
-	return &models.CertificateResponse{
+	resp := &models.CertificateResponse{
 		ID:              cert.ID.Hex(),
 		UserID:          cert.UserID.Hex(),
 		StudentCode:     cert.StudentCode,
@@ -28,11 +28,20 @@ func MapCertificateToResponse(cert *models.Certificate, user *models.User, facul
 		GraduationRank:  cert.GraduationRank,
 		EducationType:   cert.EducationType,
 		Signed:          cert.Signed,
+		Signature:       cert.Signature,
+		Version:         cert.CurrentVersion(),
+		Status:          cert.CurrentStatus(),
 		IssueDate:       cert.IssueDate.Format("02/01/2006"),
 		CreatedAt:       cert.CreatedAt,
 		UpdatedAt:       cert.UpdatedAt,
 		Description:     cert.Description,
+		Revoked:         cert.Revoked,
+		Revocation:      cert.Revocation,
 	}
+	if !cert.BatchID.IsZero() {
+		resp.BatchID = cert.BatchID.Hex()
+	}
+	return resp
 }
 func MapCertificatesToResponses(certs []*models.Certificate, userMap map[primitive.ObjectID]*models.User, facultyMap map[primitive.ObjectID]*models.Faculty, universityMap map[primitive.ObjectID]*models.University) []*models.CertificateResponse {
 	var responses []*models.CertificateResponse
@@ -9,24 +9,23 @@ import (
 	"github.com/vnkmasc/Kmasc/app/backend/utils"
 )
 
-func JWTAuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Thiếu hoặc sai định dạng token"})
-			return
-		}
-
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		claims, err := utils.ParseToken(tokenStr)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
-			return
-		}
+// authenticate đọc token và gắn claims vào request, token sai thì dừng request với 401
+func authenticate(c *gin.Context) bool {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Thiếu hoặc sai định dạng token"})
+		return false
+	}
 
-		ctx := context.WithValue(c.Request.Context(), utils.ClaimsContextKey, claims)
-		c.Request = c.Request.WithContext(ctx)
-		c.Set("claims", claims)
-		c.Next()
+	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+	claims, err := utils.ParseToken(tokenStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		return false
 	}
+
+	ctx := context.WithValue(c.Request.Context(), utils.ClaimsContextKey, claims)
+	c.Request = c.Request.WithContext(ctx)
+	c.Set("claims", claims)
+	return true
 }
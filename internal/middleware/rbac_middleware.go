@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+)
+
+// RouteKey là khóa của route trong bảng phân quyền, dạng "METHOD /đường/dẫn" theo mẫu route của gin
+func RouteKey(method, path string) string {
+	return method + " " + path
+}
+
+// Authorize kiểm tra quyền theo bảng policies (RouteKey -> quyền cần có) cho mọi route của nhóm.
+// Route công khai đi qua không cần token; route không có trong bảng bị từ chối để không route nào vô tình bị bỏ ngỏ.
+func Authorize(policies map[string]common.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perm, ok := policies[RouteKey(c.Request.Method, c.FullPath())]
+		if !ok {
+			log.Printf("[Authorize] Route %s %s chưa được khai báo quyền", c.Request.Method, c.FullPath())
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền thực hiện thao tác này"})
+			return
+		}
+		if perm == common.PermPublic {
+			c.Next()
+			return
+		}
+		if !authenticate(c) {
+			return
+		}
+
+		claims, ok := c.MustGet("claims").(*utils.CustomClaims)
+		if !ok || claims == nil || !common.HasPermission(claims.Role, perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền thực hiện thao tác này"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -46,6 +46,12 @@ type VerifyOTPResponse struct {
 	UserID string `json:"user_id"`
 }
 
+// CreateStaffAccountRequest là yêu cầu quản trị trường tạo tài khoản cán bộ khoa hoặc ban giám hiệu
+type CreateStaffAccountRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=faculty_staff rector"`
+}
+
 type RegisterRequest struct {
 	UserID        string `json:"user_id" binding:"required"`
 	PersonalEmail string `json:"personal_email" binding:"required,email"`
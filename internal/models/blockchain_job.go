@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Trạng thái của một tác vụ ghi blockchain trong outbox
+const (
+	BlockchainJobPending    = "pending"
+	BlockchainJobProcessing = "processing"
+	BlockchainJobSucceeded  = "succeeded"
+	BlockchainJobFailed     = "failed"
+)
+
+const (
+	BlockchainJobTypeAnchor      = "anchor"       // Ghi riêng một văn bằng
+	BlockchainJobTypeAnchorBatch = "anchor_batch" // Ghi gốc Merkle của một lô văn bằng
+	BlockchainJobTypeSync        = "sync"         // Cập nhật phiên bản mới của văn bằng đã ghi lên sổ cái
+)
+
+type BlockchainJob struct {
+	ID             primitive.ObjectID      `bson:"_id" json:"id"`
+	Type           string                  `bson:"type" json:"type"`
+	CertificateID  primitive.ObjectID      `bson:"certificate_id" json:"certificate_id"`
+	BatchID        primitive.ObjectID      `bson:"batch_id,omitempty" json:"batch_id,omitempty"`
+	CertificateIDs []primitive.ObjectID    `bson:"certificate_ids,omitempty" json:"certificate_ids,omitempty"` // Các văn bằng trong lô
+	Version        int                     `bson:"version,omitempty" json:"version,omitempty"`                 // Phiên bản văn bằng cần cập nhật lên sổ cái
+	UniversityID   primitive.ObjectID      `bson:"university_id" json:"university_id"`
+	Status         string                  `bson:"status" json:"status"`
+	Attempts       int                     `bson:"attempts" json:"attempts"`
+	MaxAttempts    int                     `bson:"max_attempts" json:"max_attempts"`
+	NextAttemptAt  time.Time               `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedAt       time.Time               `bson:"locked_at,omitempty" json:"-"`
+	TxID           string                  `bson:"tx_id,omitempty" json:"tx_id,omitempty"` // Giao dịch đã ghi thành công lên sổ cái
+	LastError      string                  `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	History        []*BlockchainJobAttempt `bson:"history,omitempty" json:"history,omitempty"`
+	CreatedBy      primitive.ObjectID      `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt      time.Time               `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time               `bson:"updated_at" json:"updated_at"`
+}
+
+// BlockchainJobAttempt ghi lại kết quả từng lần thử
+type BlockchainJobAttempt struct {
+	Attempt    int       `bson:"attempt" json:"attempt"`
+	StartedAt  time.Time `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time `bson:"finished_at" json:"finished_at"`
+	TxID       string    `bson:"tx_id,omitempty" json:"tx_id,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+type SearchBlockchainJobParams struct {
+	Status   string `form:"status"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=10"`
+}
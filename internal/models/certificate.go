@@ -3,7 +3,6 @@ package models
 import (
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -21,7 +20,9 @@ type Certificate struct {
 	RegNo           string    `bson:"registration_number" json:"registration_number"` // Số vào sổ gốc
 	Path            string    `bson:"path" json:"path"`
 	CertHash        string    `bson:"cert_hash" json:"cert_hash"`
-	IssueDate       time.Time `bson:"issue_date" json:"issue_date"` // Ngày cấp
+	HashVersion     int       `bson:"hash_version,omitempty" json:"hash_version,omitempty"` // Phiên bản lược đồ tính cert_hash
+	HashSalt        string    `bson:"hash_salt,omitempty" json:"-"`                         // Muối bí mật của cert_hash, không được trả ra ngoài
+	IssueDate       time.Time `bson:"issue_date" json:"issue_date"`                         // Ngày cấp
 	HashFile        string    `bson:"hash_file,omitempty" json:"hash_file,omitempty"`
 	Major           string    `bson:"major" json:"major"`                     // Ngành đào tạo
 	Course          string    `bson:"course" json:"course"`                   //  Khóa học (VD: AT18)
@@ -31,31 +32,118 @@ type Certificate struct {
 
 	Signed      bool      `bson:"signed" json:"signed"`
 	SignedAt    time.Time `bson:"signed_at,omitempty" json:"signed_at,omitempty"`
+	Signature   string    `bson:"signature,omitempty" json:"signature,omitempty"`     // Chữ ký số của trường trên cert_hash (base64)
 	Description string    `bson:"description,omitempty" json:"description,omitempty"` // Mô tả thêm
+	Version     int       `bson:"version,omitempty" json:"version,omitempty"`         // Phiên bản hiện tại (tăng khi đính chính)
+
+	Status        string                     `bson:"status,omitempty" json:"status,omitempty"`                 // Trạng thái trong quy trình duyệt
+	StatusHistory []*CertificateStatusChange `bson:"status_history,omitempty" json:"status_history,omitempty"` // Lịch sử chuyển trạng thái
+
+	Revoked    bool                   `bson:"revoked" json:"revoked"`
+	Revocation *CertificateRevocation `bson:"revocation,omitempty" json:"revocation,omitempty"` // Thông tin thu hồi
+
+	BatchID     primitive.ObjectID `bson:"batch_id,omitempty" json:"batch_id,omitempty"`         // Lô Merkle đã neo văn bằng
+	MerkleProof []*MerkleProofStep `bson:"merkle_proof,omitempty" json:"merkle_proof,omitempty"` // Đường chứng minh cert_hash thuộc gốc của lô
+
+	FieldCommitment *CertificateFieldCommitment `bson:"field_commitment,omitempty" json:"-"` // Cam kết từng trường để công bố chọn lọc, chứa muối bí mật
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
+// Mã lý do thu hồi văn bằng
+const (
+	RevokeReasonIssuedInError = "issued_in_error" // Cấp nhầm
+	RevokeReasonFraud         = "fraud"           // Gian lận
+	RevokeReasonSuperseded    = "superseded"      // Đã cấp văn bằng thay thế
+	RevokeReasonOther         = "other"           // Lý do khác
+)
+
+type CertificateRevocation struct {
+	ReasonCode     string             `bson:"reason_code" json:"reason_code"`
+	Reason         string             `bson:"reason" json:"reason"`                   // Lý do chi tiết
+	DecisionNumber string             `bson:"decision_number" json:"decision_number"` // Số quyết định thu hồi
+	RevokedBy      primitive.ObjectID `bson:"revoked_by" json:"revoked_by"`           // Tài khoản thực hiện thu hồi
+	RevokedAt      time.Time          `bson:"revoked_at" json:"revoked_at"`
+	TxID           string             `bson:"tx_id,omitempty" json:"tx_id,omitempty"` // Giao dịch thu hồi trên blockchain
+}
+
+type RevokeCertificateRequest struct {
+	ReasonCode     string `json:"reason_code" binding:"required,oneof=issued_in_error fraud superseded other"`
+	Reason         string `json:"reason" binding:"required"`
+	DecisionNumber string `json:"decision_number" binding:"required"`
+}
+
 type CertificateOnChain struct {
-	CertID              string `json:"cert_id" bson:"cert_id"`                           // ID của VBCC
-	CertHash            string `json:"cert_hash" bson:"cert_hash"`                       // Mã băm các thông tin chính
-	HashFile            string `json:"hash_file" bson:"hash_file"`                       // Mã băm file
-	UniversitySignature string `json:"university_signature" bson:"university_signature"` // Chữ ký số của trường
-	DateOfIssuing       string `json:"date_of_issuing" bson:"date_of_issuing"`           // Ngày cấp
-	SerialNumber        string `bson:"serial_number" json:"serial_number"`               // Số hiệu
-	RegNo               string `bson:"registration_number" json:"registration_number"`   // Số vào sổ gốc
-	Version             int    `json:"version" bson:"version"`                           // Phiên bản VBCC
-	UpdatedDate         string `json:"updated_date" bson:"updated_date"`                 // Ngày sửa đổi
+	CertID              string `json:"cert_id" bson:"cert_id"`                                     // ID của VBCC
+	UniversityCode      string `json:"university_code,omitempty" bson:"university_code,omitempty"` // Trường cấp, chaincode đối chiếu với định danh gửi giao dịch
+	CertHash            string `json:"cert_hash" bson:"cert_hash"`                                 // Mã băm các thông tin chính
+	HashFile            string `json:"hash_file" bson:"hash_file"`                                 // Mã băm file
+	UniversitySignature string `json:"university_signature" bson:"university_signature"`           // Chữ ký số của trường
+	DateOfIssuing       string `json:"date_of_issuing" bson:"date_of_issuing"`                     // Ngày cấp
+	SerialNumber        string `bson:"serial_number" json:"serial_number"`                         // Số hiệu
+	RegNo               string `bson:"registration_number" json:"registration_number"`             // Số vào sổ gốc
+	Version             int    `json:"version" bson:"version"`                                     // Phiên bản VBCC
+	HashVersion         int    `json:"hash_version,omitempty" bson:"hash_version,omitempty"`       // Phiên bản lược đồ tính cert_hash
+	UpdatedDate         string `json:"updated_date" bson:"updated_date"`                           // Ngày sửa đổi
+	Revoked             bool   `json:"revoked" bson:"revoked"`                                     // Đã thu hồi
+	RevokeReasonCode    string `json:"revoke_reason_code,omitempty" bson:"revoke_reason_code,omitempty"`
+	RevokedDate         string `json:"revoked_date,omitempty" bson:"revoked_date,omitempty"`             // Ngày thu hồi
+	BatchID             string `json:"batch_id,omitempty" bson:"batch_id,omitempty"`                     // Lô Merkle, chỉ có khi văn bằng được neo theo lô
+	MerkleRoot          string `json:"merkle_root,omitempty" bson:"merkle_root,omitempty"`               // Gốc Merkle đã ghi trên sổ cái
+	FieldsRoot          string `json:"fields_root,omitempty" bson:"fields_root,omitempty"`               // Gốc Merkle các trường đã cam kết
+	SubmittedBy         string `json:"submitted_by,omitempty" bson:"submitted_by,omitempty"`             // Danh tính gửi giao dịch, do sổ cái ghi, bên gửi không tự đặt
+	PrivateCollection   string `json:"private_collection,omitempty" bson:"private_collection,omitempty"` // Private data collection chứa dữ liệu riêng của phiên bản này
+	PrivateDataHash     string `json:"private_data_hash,omitempty" bson:"private_data_hash,omitempty"`   // SHA-256 của dữ liệu riêng, do sổ cái tính
+}
+
+// PrivateDetailFields là các trường của văn bằng và sinh viên ghi vào private data collection, kênh công khai chỉ giữ mã băm
+var PrivateDetailFields = []string{
+	DisclosureFieldStudentName,
+	DisclosureFieldDateOfBirth,
+	DisclosureFieldStudentCode,
+	DisclosureFieldFacultyCode,
+	DisclosureFieldName,
+	DisclosureFieldMajor,
+	DisclosureFieldCourse,
+	DisclosureFieldGPA,
+	DisclosureFieldGraduationRank,
+	DisclosureFieldEducationType,
+}
+
+// CertificatePrivateDetails là dữ liệu riêng của văn bằng trong private data collection.
+// Salt ngẫu nhiên để không dò được mã băm công khai từ các giá trị dễ đoán như họ tên, ngày sinh.
+type CertificatePrivateDetails struct {
+	CertID string            `json:"cert_id"`
+	Fields map[string]string `json:"fields"`
+	Salt   string            `json:"salt"`
+}
+
+// CertificatePrivateDetailsResult là dữ liệu riêng đọc từ sổ cái kèm kết quả đối chiếu với mã băm trên kênh công khai
+type CertificatePrivateDetailsResult struct {
+	CertificateID   string                     `json:"certificate_id"`
+	Collection      string                     `json:"collection"`
+	PrivateDataHash string                     `json:"private_data_hash"` // Mã băm ghi trên kênh công khai
+	ComputedHash    string                     `json:"computed_hash"`     // Mã băm tính lại từ dữ liệu riêng đọc được
+	HashMatches     bool                       `json:"hash_matches"`
+	Details         *CertificatePrivateDetails `json:"details"`
+}
+
+// LedgerHistoryEntry là một phiên bản của bản ghi văn bằng trên sổ cái
+type LedgerHistoryEntry struct {
+	TxID      string              `json:"tx_id"`
+	Timestamp time.Time           `json:"timestamp"`
+	IsDelete  bool                `json:"is_delete"`
+	Value     *CertificateOnChain `json:"value,omitempty"`
 }
 
 type CreateCertificateRequest struct {
 	StudentCode     string    `json:"student_code" binding:"required"`
-	CertificateType string    `json:"certificate_type" binding:"certtype"`
-	Course          string    `json:"course" binding:"required"` // Khóa học (VD: AT18)
-	GraduationRank  string    `json:"graduation_rank"`           // Hạng tốt nghiệp: Xuất sắc, Giỏi, Khá...
-	EducationType   string    `json:"education_type"`            // Hệ đào tạo: Chính quy, Tại chức...
-	Description     string    `json:"description"`               // Mô tả thêm
+	CertificateType string    `json:"certificate_type" binding:"required"` // Mã hoặc tên loại trong danh mục của trường
+	Course          string    `json:"course" binding:"required"`           // Khóa học (VD: AT18)
+	GraduationRank  string    `json:"graduation_rank"`                     // Hạng tốt nghiệp: Xuất sắc, Giỏi, Khá...
+	EducationType   string    `json:"education_type"`                      // Hệ đào tạo: Chính quy, Tại chức...
+	Description     string    `json:"description"`                         // Mô tả thêm
 	Name            string    `json:"name"`
 	SerialNumber    string    `json:"serial_number"`
 	RegNo           string    `json:"reg_no"`
@@ -86,9 +174,16 @@ type CertificateResponse struct {
 	GraduationRank  string  `bson:"graduation_rank" json:"graduation_rank"` //  Hạng tốt nghiệp: Xuất sắc, Giỏi, Khá...
 	EducationType   string  `bson:"education_type" json:"education_type"`
 	Signed          bool    `json:"signed"`
+	Signature       string  `json:"signature,omitempty"`
+	Version         int     `json:"version,omitempty"`
+	Status          string  `json:"status,omitempty"`
 	IssueDate       string  `json:"issue_date,omitempty"`
 	Description     string  `bson:"description,omitempty" json:"description,omitempty"` // Mô tả thêm
 
+	Revoked    bool                   `json:"revoked"`
+	Revocation *CertificateRevocation `json:"revocation,omitempty"`
+	BatchID    string                 `json:"batch_id,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -103,23 +198,6 @@ type SearchCertificateParams struct {
 	PageSize        int    `form:"page_size,default=10"`
 }
 
-func ValidateCreateCertificateRequest(sl validator.StructLevel) {
-	req := sl.Current().Interface().(CreateCertificateRequest)
-
-	if req.CertificateType == "" {
-		sl.ReportError(req.CertificateType, "certificate_type", "CertificateType", "required_if_degree", "")
-	}
-	if req.SerialNumber == "" {
-		sl.ReportError(req.SerialNumber, "serial_number", "SerialNumber", "required_if_degree", "")
-	}
-	if req.RegNo == "" {
-		sl.ReportError(req.RegNo, "reg_no", "RegNo", "required_if_degree", "")
-	}
-	if req.IssueDate.IsZero() {
-		sl.ReportError(req.IssueDate, "issue_date", "IssueDate", "required_if_degree", "")
-	}
-}
-
 type CertificateSimpleResponse struct {
 	ID   string `json:"id"`
 	Name string `json:"certificate_name"`
@@ -145,7 +223,64 @@ func NewCertificate(req *CreateCertificateRequest, user *User, universityID prim
 		EducationType:   req.EducationType,
 		Signed:          false,
 		Description:     req.Description,
+		Version:         1,
+		Status:          CertificateStatusDraft,
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	}
 }
+
+// Phiên bản lược đồ tính cert_hash
+const (
+	CertificateHashV1             = 1 // Các trường nối bằng dấu |, không có muối
+	CertificateHashV2             = 2 // JSON chuẩn hóa, HMAC-SHA256 với muối riêng từng văn bằng
+	CurrentCertificateHashVersion = CertificateHashV2
+)
+
+// Văn bằng cũ chưa có hash_version được tính theo lược đồ v1
+func (c *Certificate) CurrentHashVersion() int {
+	if c.HashVersion < 1 {
+		return CertificateHashV1
+	}
+	return c.HashVersion
+}
+
+// Phiên bản hiện tại của văn bằng, các bản ghi cũ chưa có trường version được coi là phiên bản 1
+func (c *Certificate) CurrentVersion() int {
+	if c.Version < 1 {
+		return 1
+	}
+	return c.Version
+}
+
+// Gốc Merkle các trường đã cam kết, rỗng với văn bằng cũ chưa được cam kết
+func (c *Certificate) FieldsRoot() string {
+	if c.FieldCommitment == nil {
+		return ""
+	}
+	return c.FieldCommitment.Root
+}
+
+// Kết quả xác minh công khai, không chứa thông tin cá nhân của sinh viên
+const (
+	PublicVerifyValid       = "valid"
+	PublicVerifyRevoked     = "revoked"
+	PublicVerifyTampered    = "tampered"
+	PublicVerifyNotAnchored = "not_anchored"
+)
+
+type PublicVerifyResult struct {
+	Status          string `json:"status"`
+	Valid           bool   `json:"valid"`
+	Message         string `json:"message"`
+	CertificateType string `json:"certificate_type,omitempty"`
+	CertificateName string `json:"certificate_name,omitempty"`
+	SerialNumber    string `json:"serial_number,omitempty"`
+	IssueDate       string `json:"issue_date,omitempty"`
+	UniversityCode  string `json:"university_code"`
+	UniversityName  string `json:"university_name"`
+	FileHash        string `json:"file_hash,omitempty"` // SHA-256 của file được tải lên để xác minh
+	// FileLedgerBacked chỉ có khi xác minh bằng file: true nếu mã băm file được đối chiếu với sổ cái,
+	// false với văn bằng neo theo lô vì file chỉ được đối chiếu với MongoDB
+	FileLedgerBacked *bool `json:"file_ledger_backed,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Trạng thái của lô văn bằng ghi lên blockchain bằng gốc Merkle
+const (
+	CertificateBatchPending  = "pending"
+	CertificateBatchAnchored = "anchored"
+)
+
+// CertificateBatch gom nhiều văn bằng vào một cây Merkle, chỉ gốc cây được ghi lên sổ cái
+type CertificateBatch struct {
+	ID           primitive.ObjectID      `bson:"_id" json:"id"`
+	UniversityID primitive.ObjectID      `bson:"university_id" json:"university_id"`
+	MerkleRoot   string                  `bson:"merkle_root" json:"merkle_root"`
+	Size         int                     `bson:"size" json:"size"`
+	Leaves       []*CertificateBatchLeaf `bson:"leaves" json:"leaves"` // Thứ tự lá quyết định gốc cây
+	Status       string                  `bson:"status" json:"status"`
+	TxID         string                  `bson:"tx_id,omitempty" json:"tx_id,omitempty"`
+	AnchoredAt   time.Time               `bson:"anchored_at,omitempty" json:"anchored_at,omitempty"`
+	CreatedBy    primitive.ObjectID      `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt    time.Time               `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time               `bson:"updated_at" json:"updated_at"`
+}
+
+type CertificateBatchLeaf struct {
+	CertificateID primitive.ObjectID `bson:"certificate_id" json:"certificate_id"`
+	CertHash      string             `bson:"cert_hash" json:"cert_hash"`
+}
+
+// MerkleProofStep là nút anh em trên đường chứng minh từ cert_hash lên gốc của lô
+type MerkleProofStep struct {
+	Hash string `bson:"hash" json:"hash"`
+	Left bool   `bson:"left" json:"left"` // Nút anh em nằm bên trái
+}
+
+// CertificateBatchOnChain là bản ghi gốc Merkle trên sổ cái
+type CertificateBatchOnChain struct {
+	BatchID        string `json:"batch_id"`
+	UniversityCode string `json:"university_code,omitempty"` // Trường sở hữu lô, chaincode đối chiếu với định danh gửi giao dịch
+	MerkleRoot     string `json:"merkle_root"`
+	Size           int    `json:"size"`
+	AnchoredDate   string `json:"anchored_date"`
+	SubmittedBy    string `json:"submitted_by,omitempty"` // Danh tính gửi giao dịch, do sổ cái ghi
+}
+
+type CreateCertificateBatchRequest struct {
+	CertificateIDs []string `json:"certificate_ids" binding:"required,min=1,max=1000,dive,required"`
+}
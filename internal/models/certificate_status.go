@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Trạng thái trong quy trình cấp văn bằng
+const (
+	CertificateStatusDraft           = "draft"            // Mới tạo
+	CertificateStatusSubmitted       = "submitted"        // Đã trình duyệt
+	CertificateStatusFacultyApproved = "faculty_approved" // Khoa đã duyệt
+	CertificateStatusRectorApproved  = "rector_approved"  // Ban giám hiệu đã duyệt
+	CertificateStatusSigned          = "signed"           // Đã ký số
+	CertificateStatusAnchored        = "anchored"         // Đã ghi lên blockchain
+)
+
+// Các thao tác chuyển trạng thái
+const (
+	CertificateActionSubmit         = "submit"
+	CertificateActionFacultyApprove = "faculty_approve"
+	CertificateActionRectorApprove  = "rector_approve"
+	CertificateActionReject         = "reject"
+	CertificateActionSign           = "sign"
+	CertificateActionAnchor         = "anchor"
+	CertificateActionAmend          = "amend"
+)
+
+type CertificateStatusChange struct {
+	Action    string             `bson:"action" json:"action"`
+	From      string             `bson:"from" json:"from"`
+	To        string             `bson:"to" json:"to"`
+	Comment   string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	TxID      string             `bson:"tx_id,omitempty" json:"tx_id,omitempty"` // Giao dịch trên sổ cái của lần chuyển trạng thái, chỉ có khi neo
+	ChangedBy primitive.ObjectID `bson:"changed_by,omitempty" json:"changed_by,omitempty"`
+	Role      string             `bson:"role,omitempty" json:"role,omitempty"`
+	ChangedAt time.Time          `bson:"changed_at" json:"changed_at"`
+}
+
+type CertificateTransitionRequest struct {
+	Action  string `json:"action" binding:"required,oneof=submit faculty_approve rector_approve reject"`
+	Comment string `json:"comment"`
+}
+
+type CertificateStatusResponse struct {
+	CertificateID string                     `json:"certificate_id"`
+	Status        string                     `json:"status"`
+	History       []*CertificateStatusChange `json:"history"`
+}
+
+// Trạng thái hiện tại, văn bằng tạo trước khi có quy trình duyệt được suy ra từ dữ liệu sẵn có
+func (c *Certificate) CurrentStatus() string {
+	if c.Status != "" {
+		return c.Status
+	}
+	switch {
+	case c.BlockchainTxID != "":
+		return CertificateStatusAnchored
+	case c.Signed:
+		return CertificateStatusSigned
+	default:
+		return CertificateStatusDraft
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Các trường của CreateCertificateRequest có thể cấu hình là bắt buộc theo loại văn bằng
+const (
+	CertificateFieldSerialNumber   = "serial_number"
+	CertificateFieldRegNo          = "reg_no"
+	CertificateFieldIssueDate      = "issue_date"
+	CertificateFieldName           = "name"
+	CertificateFieldGPA            = "gpa"
+	CertificateFieldGraduationRank = "graduation_rank"
+	CertificateFieldEducationType  = "education_type"
+)
+
+// CertificateType là một loại văn bằng/chứng chỉ trong danh mục của trường
+type CertificateType struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UniversityID   primitive.ObjectID `bson:"university_id" json:"university_id"`
+	Code           string             `bson:"code" json:"code"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`                         // Tên hiển thị, được lưu vào certificate_type của văn bằng
+	IsDegree       bool               `bson:"is_degree" json:"is_degree"`                               // Văn bằng mỗi sinh viên chỉ được cấp một lần
+	RequiredFields []string           `bson:"required_fields" json:"required_fields"`                   // Các trường bắt buộc khi tạo văn bằng
+	StudentStatus  int                `bson:"student_status,omitempty" json:"student_status,omitempty"` // Trạng thái sinh viên sau khi được cấp, 0 là không đổi
+	Description    string             `bson:"description,omitempty" json:"description,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type CreateCertificateTypeRequest struct {
+	Code           string   `json:"code" binding:"required"`
+	DisplayName    string   `json:"display_name" binding:"required"`
+	IsDegree       bool     `json:"is_degree"`
+	RequiredFields []string `json:"required_fields" binding:"dive,oneof=serial_number reg_no issue_date name gpa graduation_rank education_type"`
+	StudentStatus  int      `json:"student_status" binding:"min=0"`
+	Description    string   `json:"description"`
+}
+
+// Mã và tên hiển thị không được sửa vì văn bằng đã cấp tham chiếu theo tên hiển thị
+type UpdateCertificateTypeRequest struct {
+	IsDegree       bool     `json:"is_degree"`
+	RequiredFields []string `json:"required_fields" binding:"dive,oneof=serial_number reg_no issue_date name gpa graduation_rank education_type"`
+	StudentStatus  int      `json:"student_status" binding:"min=0"`
+	Description    string   `json:"description"`
+}
+
+type CertificateTypeResponse struct {
+	ID             string   `json:"id,omitempty"`
+	Code           string   `json:"code"`
+	DisplayName    string   `json:"display_name"`
+	IsDegree       bool     `json:"is_degree"`
+	RequiredFields []string `json:"required_fields"`
+	StudentStatus  int      `json:"student_status,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	IsDefault      bool     `json:"is_default"` // Loại mặc định của hệ thống, chưa được trường ghi đè
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bản ghi lịch sử của từng phiên bản văn bằng (đính chính)
+type CertificateVersion struct {
+	ID             primitive.ObjectID  `bson:"_id" json:"id"`
+	CertificateID  primitive.ObjectID  `bson:"certificate_id" json:"certificate_id"`
+	Version        int                 `bson:"version" json:"version"`
+	Snapshot       CertificateSnapshot `bson:"snapshot" json:"snapshot"`
+	Reason         string              `bson:"reason,omitempty" json:"reason,omitempty"`                   // Lý do đính chính
+	DecisionNumber string              `bson:"decision_number,omitempty" json:"decision_number,omitempty"` // Số quyết định đính chính
+	AmendedBy      primitive.ObjectID  `bson:"amended_by,omitempty" json:"amended_by,omitempty"`
+	TxID           string              `bson:"tx_id,omitempty" json:"tx_id,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// Các trường của văn bằng được phép đính chính
+type CertificateSnapshot struct {
+	Name           string    `bson:"name" json:"name"`
+	Major          string    `bson:"major" json:"major"`
+	Course         string    `bson:"course" json:"course"`
+	GPA            float64   `bson:"gpa" json:"gpa"`
+	GraduationRank string    `bson:"graduation_rank" json:"graduation_rank"`
+	EducationType  string    `bson:"education_type" json:"education_type"`
+	Description    string    `bson:"description" json:"description"`
+	IssueDate      time.Time `bson:"issue_date" json:"issue_date"`
+	CertHash       string    `bson:"cert_hash" json:"cert_hash"`
+	HashFile       string    `bson:"hash_file,omitempty" json:"hash_file,omitempty"`
+}
+
+type AmendCertificateRequest struct {
+	Name           *string    `json:"name" binding:"omitempty"`
+	Major          *string    `json:"major" binding:"omitempty"`
+	Course         *string    `json:"course" binding:"omitempty"`
+	GPA            *float64   `json:"gpa" binding:"omitempty,min=0"`
+	GraduationRank *string    `json:"graduation_rank" binding:"omitempty"`
+	EducationType  *string    `json:"education_type" binding:"omitempty"`
+	Description    *string    `json:"description" binding:"omitempty"`
+	IssueDate      *time.Time `json:"issue_date" binding:"omitempty"`
+	Reason         string     `json:"reason" binding:"required"`
+	DecisionNumber string     `json:"decision_number"`
+}
+
+type FieldDiff struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+type CertificateVersionResponse struct {
+	Version        int                 `json:"version"`
+	Snapshot       CertificateSnapshot `json:"snapshot"`
+	Reason         string              `json:"reason,omitempty"`
+	DecisionNumber string              `json:"decision_number,omitempty"`
+	AmendedBy      string              `json:"amended_by,omitempty"`
+	TxID           string              `json:"tx_id,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Changes        []FieldDiff         `json:"changes"` // Khác biệt so với phiên bản trước
+}
+
+func NewCertificateSnapshot(cert *Certificate) CertificateSnapshot {
+	return CertificateSnapshot{
+		Name:           cert.Name,
+		Major:          cert.Major,
+		Course:         cert.Course,
+		GPA:            cert.GPA,
+		GraduationRank: cert.GraduationRank,
+		EducationType:  cert.EducationType,
+		Description:    cert.Description,
+		IssueDate:      cert.IssueDate,
+		CertHash:       cert.CertHash,
+		HashFile:       cert.HashFile,
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+// Các trường văn bằng được cam kết riêng lẻ để sinh viên có thể công bố từng phần
+const (
+	DisclosureFieldStudentName     = "student_name"
+	DisclosureFieldDateOfBirth     = "date_of_birth"
+	DisclosureFieldStudentCode     = "student_code"
+	DisclosureFieldUniversityCode  = "university_code"
+	DisclosureFieldUniversityName  = "university_name"
+	DisclosureFieldFacultyCode     = "faculty_code"
+	DisclosureFieldCertificateType = "certificate_type"
+	DisclosureFieldName            = "name"
+	DisclosureFieldMajor           = "major"
+	DisclosureFieldCourse          = "course"
+	DisclosureFieldGPA             = "gpa"
+	DisclosureFieldGraduationRank  = "graduation_rank"
+	DisclosureFieldEducationType   = "education_type"
+	DisclosureFieldIssueDate       = "issue_date"
+	DisclosureFieldGraduationYear  = "graduation_year"
+	DisclosureFieldSerialNumber    = "serial_number"
+	DisclosureFieldRegNo           = "registration_number"
+)
+
+// DisclosureFields là thứ tự lá trong cây Merkle các trường, không được đổi khi đã có văn bằng được cam kết
+var DisclosureFields = []string{
+	DisclosureFieldStudentName,
+	DisclosureFieldDateOfBirth,
+	DisclosureFieldStudentCode,
+	DisclosureFieldUniversityCode,
+	DisclosureFieldUniversityName,
+	DisclosureFieldFacultyCode,
+	DisclosureFieldCertificateType,
+	DisclosureFieldName,
+	DisclosureFieldMajor,
+	DisclosureFieldCourse,
+	DisclosureFieldGPA,
+	DisclosureFieldGraduationRank,
+	DisclosureFieldEducationType,
+	DisclosureFieldIssueDate,
+	DisclosureFieldGraduationYear,
+	DisclosureFieldSerialNumber,
+	DisclosureFieldRegNo,
+}
+
+func IsDisclosureField(name string) bool {
+	for _, field := range DisclosureFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CertificateFieldCommitment lưu giá trị và muối của từng trường đã cam kết, chỉ gốc Merkle được ghi lên sổ cái
+type CertificateFieldCommitment struct {
+	Root   string              `bson:"root" json:"root"`
+	Fields []*CertificateField `bson:"fields" json:"fields"`
+}
+
+type CertificateField struct {
+	Name  string `bson:"name" json:"name"`
+	Value string `bson:"value" json:"value"`
+	Salt  string `bson:"salt" json:"salt"`
+}
+
+// SelectiveDisclosure là gói dữ liệu sinh viên công bố cho bên xác minh
+type SelectiveDisclosure struct {
+	CertificateID string            `json:"certificate_id"`
+	FieldsRoot    string            `json:"fields_root"`
+	Fields        []*DisclosedField `json:"fields"`
+}
+
+type DisclosedField struct {
+	Name  string             `json:"name" binding:"required"`
+	Value string             `json:"value"`
+	Salt  string             `json:"salt" binding:"required"`
+	Proof []*MerkleProofStep `json:"proof"`
+}
+
+type VerifyDisclosureRequest struct {
+	CertificateID string            `json:"certificate_id" binding:"required"`
+	FieldsRoot    string            `json:"fields_root"`
+	Fields        []*DisclosedField `json:"fields" binding:"required,min=1,dive,required"`
+}
+
+type DisclosureVerifyResult struct {
+	CertificateID string                  `json:"certificate_id"`
+	Valid         bool                    `json:"valid"`
+	Revoked       bool                    `json:"revoked"`
+	Message       string                  `json:"message"`
+	FieldsRoot    string                  `json:"fields_root,omitempty"` // Gốc đọc từ sổ cái
+	Fields        []*DisclosedFieldResult `json:"fields"`
+}
+
+type DisclosedFieldResult struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Valid bool   `json:"valid"`
+}
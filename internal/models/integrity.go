@@ -0,0 +1,64 @@
+package models
+
+// Các bước kiểm tra khi xác minh tính toàn vẹn của văn bằng
+const (
+	IntegrityCheckCertHash     = "cert_hash"     // Mã băm tính lại từ MongoDB so với sổ cái
+	IntegrityCheckMerkleProof  = "merkle_proof"  // Đường chứng minh tới gốc Merkle của lô
+	IntegrityCheckFileHash     = "file_hash"     // Mã băm file trên MinIO so với sổ cái
+	IntegrityCheckSerialNumber = "serial_number" // Số hiệu
+	IntegrityCheckRegNo        = "reg_no"        // Số vào sổ gốc
+	IntegrityCheckIssueDate    = "issue_date"    // Ngày cấp
+	IntegrityCheckRevocation   = "revocation"    // Trạng thái thu hồi
+	IntegrityCheckSignature    = "signature"     // Chữ ký số của trường
+)
+
+type IntegrityCheck struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Skipped  bool   `json:"skipped,omitempty"`  // Không đủ dữ liệu để kiểm tra, không tính vào kết quả
+	Expected string `json:"expected,omitempty"` // Giá trị theo MongoDB hoặc tính lại
+	Actual   string `json:"actual,omitempty"`   // Giá trị trên sổ cái
+	Message  string `json:"message,omitempty"`
+}
+
+// IntegrityReport là kết quả xác minh chi tiết từng bước của một văn bằng
+type IntegrityReport struct {
+	CertificateID string              `json:"certificate_id"`
+	Valid         bool                `json:"valid"`
+	Revoked       bool                `json:"revoked"`
+	Message       string              `json:"message"`
+	Checks        []*IntegrityCheck   `json:"checks"`
+	OnChain       *CertificateOnChain `json:"on_chain,omitempty"`
+}
+
+// Add thêm một bước kiểm tra; bước đầu tiên thất bại quyết định thông báo chung của báo cáo
+func (r *IntegrityReport) Add(check *IntegrityCheck) {
+	r.Checks = append(r.Checks, check)
+	if !check.Passed && !check.Skipped && r.Message == "" {
+		r.Message = check.Message
+	}
+}
+
+// Failed cho biết bước kiểm tra có tên name có thất bại không
+func (r *IntegrityReport) Failed(name string) bool {
+	for _, check := range r.Checks {
+		if check.Name == name && !check.Passed && !check.Skipped {
+			return true
+		}
+	}
+	return false
+}
+
+// Finish tính kết quả chung sau khi đã chạy hết các bước
+func (r *IntegrityReport) Finish(successMessage string) {
+	r.Valid = true
+	for _, check := range r.Checks {
+		if !check.Passed && !check.Skipped {
+			r.Valid = false
+		}
+	}
+	r.Revoked = r.Failed(IntegrityCheckRevocation)
+	if r.Valid {
+		r.Message = successMessage
+	}
+}
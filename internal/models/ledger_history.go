@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Sự kiện của một giao dịch trên sổ cái, suy ra từ thay đổi so với phiên bản liền trước
+const (
+	LedgerEventIssue       = "issue"
+	LedgerEventUpdate      = "update"
+	LedgerEventRevoke      = "revoke"
+	LedgerEventDelete      = "delete"
+	LedgerEventAnchorBatch = "anchor_batch"
+)
+
+// Nguồn của bản ghi kiểm toán trong MongoDB được ghép với giao dịch
+const (
+	AuditSourceStatusHistory = "status_history"
+	AuditSourceVersion       = "versions"
+	AuditSourceRevocation    = "revocation"
+)
+
+// CertificateLedgerHistory là toàn bộ vòng đời của văn bằng trên sổ cái, ghép với nhật ký trong MongoDB
+type CertificateLedgerHistory struct {
+	CertificateID string                     `json:"certificate_id"`
+	InDatabase    bool                       `json:"in_database"`
+	Transactions  []*LedgerTransaction       `json:"transactions"`
+	StatusHistory []*CertificateStatusChange `json:"status_history"`
+	Versions      []*CertificateVersion      `json:"versions"`
+	LedgerError   string                     `json:"ledger_error,omitempty"` // Không đọc được sổ cái, chỉ có dữ liệu MongoDB
+}
+
+type LedgerTransaction struct {
+	TxID        string             `json:"tx_id"`
+	Timestamp   time.Time          `json:"timestamp"`
+	Event       string             `json:"event"`
+	Version     int                `json:"version,omitempty"`
+	SubmittedBy string             `json:"submitted_by,omitempty"` // Danh tính đã gửi giao dịch theo sổ cái
+	CertHash    string             `json:"cert_hash,omitempty"`
+	Revoked     bool               `json:"revoked"`
+	BatchID     string             `json:"batch_id,omitempty"`
+	MerkleRoot  string             `json:"merkle_root,omitempty"`
+	Audit       *LedgerAuditRecord `json:"audit,omitempty"` // Bản ghi trong MongoDB có cùng tx_id
+}
+
+type LedgerAuditRecord struct {
+	Source    string    `json:"source"`
+	Action    string    `json:"action,omitempty"`
+	ActorID   string    `json:"actor_id,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Version   int       `json:"version,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
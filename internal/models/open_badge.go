@@ -0,0 +1,73 @@
+package models
+
+// OpenBadgeCredential là chứng chỉ không phải văn bằng biểu diễn theo Open Badges 3.0
+type OpenBadgeCredential struct {
+	Context           []string                 `json:"@context"`
+	ID                string                   `json:"id"`
+	Type              []string                 `json:"type"`
+	Issuer            *BadgeProfile            `json:"issuer"`
+	Name              string                   `json:"name"`
+	ValidFrom         string                   `json:"validFrom"`
+	CredentialSubject *BadgeAchievementSubject `json:"credentialSubject"`
+	CredentialStatus  *CredentialStatus        `json:"credentialStatus,omitempty"`
+}
+
+// BadgeProfile là hồ sơ bên cấp huy hiệu (trường)
+type BadgeProfile struct {
+	ID   string   `json:"id"`
+	Type []string `json:"type"`
+	Name string   `json:"name"`
+	URL  string   `json:"url,omitempty"`
+}
+
+// BadgeAchievement là định nghĩa huy hiệu (badge class) cho một loại chứng chỉ của một trường
+type BadgeAchievement struct {
+	Context         []string       `json:"@context,omitempty"`
+	ID              string         `json:"id"`
+	Type            []string       `json:"type"`
+	AchievementType string         `json:"achievementType"`
+	Name            string         `json:"name"`
+	Description     string         `json:"description"`
+	Criteria        *BadgeCriteria `json:"criteria"`
+	Creator         *BadgeProfile  `json:"creator"`
+}
+
+type BadgeCriteria struct {
+	Narrative string `json:"narrative"`
+}
+
+// BadgeAchievementSubject định danh người nhận bằng mã băm email có muối, không công khai thông tin cá nhân
+type BadgeAchievementSubject struct {
+	Type        []string               `json:"type"`
+	Identifier  []*BadgeIdentityObject `json:"identifier"`
+	Achievement *BadgeAchievement      `json:"achievement"`
+}
+
+type BadgeIdentityObject struct {
+	Type         string `json:"type"`
+	Hashed       bool   `json:"hashed"`
+	IdentityHash string `json:"identityHash"`
+	IdentityType string `json:"identityType"`
+	Salt         string `json:"salt,omitempty"`
+}
+
+// CertificateBadge là kết quả xuất huy hiệu: credential và bản JWT đã ký
+type CertificateBadge struct {
+	Credential *OpenBadgeCredential `json:"credential"`
+	JWT        string               `json:"jwt"`
+}
+
+// BadgeStatus là nội dung công khai tại id của huy hiệu: loại chứng chỉ, bên cấp và trạng thái,
+// không có định danh người nhận, token xác minh hay chữ ký để người đoán được id không lấy được huy hiệu
+type BadgeStatus struct {
+	ID          string            `json:"id"`
+	Issuer      *BadgeProfile     `json:"issuer"`
+	Achievement *BadgeAchievement `json:"achievement"`
+	ValidFrom   string            `json:"validFrom"`
+	Revoked     bool              `json:"revoked"`
+}
+
+type BadgeVerifyResult struct {
+	IntegrityReport
+	Credential *OpenBadgeCredential `json:"credential,omitempty"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Loại sai lệch giữa MongoDB và sổ cái
+const (
+	DriftMissingOnChain = "missing_on_chain" // Có blockchain_tx_id nhưng sổ cái không có bản ghi
+	DriftMissingInDB    = "missing_in_db"    // Sổ cái có bản ghi nhưng MongoDB không có hoặc chưa ghi nhận giao dịch
+	DriftHashMismatch   = "hash_mismatch"    // Mã băm tính lại từ MongoDB khác cert_hash trên sổ cái
+	DriftFileMismatch   = "file_mismatch"    // hash_file trong MongoDB khác hash_file trên sổ cái
+	DriftCheckFailed    = "check_failed"     // Không đối chiếu được do lỗi dữ liệu hoặc lỗi kết nối
+)
+
+type ReconciliationReport struct {
+	ID         primitive.ObjectID     `bson:"_id" json:"id"`
+	Trigger    string                 `bson:"trigger" json:"trigger"` // cli, schedule hoặc api
+	StartedAt  time.Time              `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time              `bson:"finished_at" json:"finished_at"`
+	Checked    int                    `bson:"checked" json:"checked"`   // Số văn bằng trong MongoDB đã đối chiếu
+	OnChain    int                    `bson:"on_chain" json:"on_chain"` // Số bản ghi văn bằng trên sổ cái
+	Summary    map[string]int         `bson:"summary" json:"summary"`   // Số sai lệch theo loại
+	Drifts     []*ReconciliationDrift `bson:"drifts" json:"drifts"`
+	Error      string                 `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+type ReconciliationDrift struct {
+	Type          string `bson:"type" json:"type"`
+	CertificateID string `bson:"certificate_id" json:"certificate_id"`
+	SerialNumber  string `bson:"serial_number,omitempty" json:"serial_number,omitempty"`
+	UniversityID  string `bson:"university_id,omitempty" json:"university_id,omitempty"`
+	Expected      string `bson:"expected,omitempty" json:"expected,omitempty"` // Giá trị theo MongoDB
+	Actual        string `bson:"actual,omitempty" json:"actual,omitempty"`     // Giá trị trên sổ cái
+	Detail        string `bson:"detail,omitempty" json:"detail,omitempty"`
+}
+
+// HasDrift cho biết báo cáo có sai lệch nào không
+func (r *ReconciliationReport) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+type SearchReconciliationReportParams struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=10"`
+}
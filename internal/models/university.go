@@ -16,6 +16,9 @@ type University struct {
 	Description    string             `bson:"description"`
 	CreatedAt      time.Time          `bson:"created_at"`
 	UpdatedAt      time.Time          `bson:"updated_at"`
+
+	SigningCertificate  string    `bson:"signing_certificate,omitempty"`    // Chứng thư X.509 (PEM) chứa khóa công khai ký văn bằng
+	SigningKeyCreatedAt time.Time `bson:"signing_key_created_at,omitempty"` // Thời điểm tạo khóa ký
 }
 type CreateUniversityRequest struct {
 	UniversityName string `json:"university_name" binding:"required"`
@@ -36,6 +39,12 @@ type UniversityResponse struct {
 	UpdatedAt      string `json:"updated_at"`
 }
 
+type SigningKeyResponse struct {
+	UniversityCode     string `json:"university_code"`
+	SigningCertificate string `json:"signing_certificate"`
+	CreatedAt          string `json:"created_at"`
+}
+
 type ApproveOrRejectUniversityRequest struct {
 	UniversityID string `json:"university_id" binding:"required"`
 	Action       string `json:"action" binding:"required,oneof=approve reject"`
@@ -0,0 +1,81 @@
+package models
+
+// VerifiableCredential là văn bằng biểu diễn theo W3C VC Data Model 1.1 (JSON-LD)
+type VerifiableCredential struct {
+	Context           []any                    `json:"@context"`
+	ID                string                   `json:"id"`
+	Type              []string                 `json:"type"`
+	Issuer            *CredentialIssuer        `json:"issuer"`
+	IssuanceDate      string                   `json:"issuanceDate"`
+	CredentialSubject *DegreeCredentialSubject `json:"credentialSubject"`
+	CredentialStatus  *CredentialStatus        `json:"credentialStatus,omitempty"`
+}
+
+// Loại credential theo loại văn bằng
+const (
+	CredentialTypeDegree      = "UniversityDegreeCredential"
+	CredentialTypeCertificate = "EducationalCertificateCredential"
+)
+
+// CredentialIssuer là trường cấp văn bằng, id trỏ tới chứng thư khóa công khai của trường
+type CredentialIssuer struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	UniversityCode string `json:"universityCode"`
+}
+
+type DegreeCredentialSubject struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	StudentCode string            `json:"studentCode"`
+	BirthDate   string            `json:"birthDate,omitempty"`
+	Degree      *CredentialDegree `json:"degree"`
+}
+
+type CredentialDegree struct {
+	Type               string  `json:"type"`
+	Name               string  `json:"name"`
+	Major              string  `json:"major,omitempty"`
+	Course             string  `json:"course,omitempty"`
+	Faculty            string  `json:"faculty,omitempty"`
+	GPA                float64 `json:"gpa,omitempty"`
+	GraduationRank     string  `json:"graduationRank,omitempty"`
+	EducationType      string  `json:"educationType,omitempty"`
+	SerialNumber       string  `json:"serialNumber"`
+	RegistrationNumber string  `json:"registrationNumber"`
+	IssueDate          string  `json:"issueDate"`
+	Version            int     `json:"version"`
+	CertHash           string  `json:"certHash"`
+	BlockchainTxID     string  `json:"blockchainTxId,omitempty"`
+}
+
+// CredentialStatus trỏ tới trang xác minh công khai để tra trạng thái thu hồi
+type CredentialStatus struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// CertificateCredential là kết quả xuất văn bằng: credential JSON-LD và bản JWT-VC đã ký
+type CertificateCredential struct {
+	Credential *VerifiableCredential `json:"credential"`
+	JWT        string                `json:"jwt"`
+}
+
+type VerifyCredentialRequest struct {
+	JWT string `json:"jwt" binding:"required"`
+}
+
+// Các bước kiểm tra thêm khi xác minh credential
+const (
+	IntegrityCheckProof   = "proof"   // Chữ ký JWT của trường
+	IntegrityCheckIssuer  = "issuer"  // Bên phát hành là trường có trong hệ thống và khớp với văn bằng
+	IntegrityCheckVersion = "version" // Credential được xuất từ phiên bản hiện tại của văn bằng
+
+	IntegrityCheckCertificate = "certificate" // Văn bằng của credential còn tồn tại trong hệ thống
+)
+
+// CredentialVerifyResult là kết quả từng bước kiểm tra credential, dùng chung cấu trúc với báo cáo toàn vẹn
+type CredentialVerifyResult struct {
+	IntegrityReport
+	Credential *VerifiableCredential `json:"credential,omitempty"`
+}
@@ -14,6 +14,10 @@ type VerificationCode struct {
 	CanViewData  bool               `bson:"can_view_data" json:"can_view_data"`
 	CanViewFile  bool               `bson:"can_view_file" json:"can_view_file"`
 
+	// Công bố chọn lọc: chỉ các trường này của văn bằng được trả cho bên xác minh kèm đường chứng minh
+	CertificateID   primitive.ObjectID `bson:"certificate_id,omitempty" json:"certificate_id,omitempty"`
+	DisclosedFields []string           `bson:"disclosed_fields,omitempty" json:"disclosed_fields,omitempty"`
+
 	ExpiredAt time.Time `bson:"expired_at" json:"expired_at"`
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 }
@@ -23,6 +27,9 @@ type CreateVerificationCodeRequest struct {
 	CanViewScore    bool `json:"can_view_score"`
 	CanViewData     bool `json:"can_view_data"`
 	CanViewFile     bool `json:"can_view_file"`
+
+	CertificateID   string   `json:"certificate_id"`
+	DisclosedFields []string `json:"disclosed_fields" binding:"omitempty,dive,oneof=student_name date_of_birth student_code university_code university_name faculty_code certificate_type name major course gpa graduation_rank education_type issue_date graduation_year serial_number registration_number"`
 }
 
 type VerificationCodeResponse struct {
@@ -31,6 +38,8 @@ type VerificationCodeResponse struct {
 	CanViewScore     bool               `json:"can_view_score"`
 	CanViewData      bool               `json:"can_view_data"`
 	CanViewFile      bool               `json:"can_view_file"`
+	CertificateID    string             `json:"certificate_id,omitempty"`
+	DisclosedFields  []string           `json:"disclosed_fields,omitempty"`
 	ViewedScore      bool               `json:"viewed_score"`
 	ViewedData       bool               `json:"viewed_data"`
 	ViewedFile       bool               `json:"viewed_file"`
@@ -40,5 +49,5 @@ type VerificationCodeResponse struct {
 
 type VerifyCodeRequest struct {
 	Code     string `json:"code" binding:"required"`
-	ViewType string `json:"view_type" binding:"required,oneof=score data file"`
+	ViewType string `json:"view_type" binding:"required,oneof=score data file disclosure"`
 }
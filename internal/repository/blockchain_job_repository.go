@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BlockchainJobRepository interface {
+	Create(ctx context.Context, job *models.BlockchainJob) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.BlockchainJob, error)
+	FindActiveByCertificateID(ctx context.Context, certificateID primitive.ObjectID) (*models.BlockchainJob, error)
+	ClaimNext(ctx context.Context, now time.Time) (*models.BlockchainJob, error)
+	ReleaseStale(ctx context.Context, lockedBefore time.Time) (int64, error)
+	UpdateByID(ctx context.Context, id primitive.ObjectID, update bson.M) error
+	Find(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.BlockchainJob, int64, error)
+}
+
+type blockchainJobRepository struct {
+	col *mongo.Collection
+}
+
+func NewBlockchainJobRepository(db *mongo.Database) BlockchainJobRepository {
+	return &blockchainJobRepository{
+		col: db.Collection("blockchain_jobs"),
+	}
+}
+
+func (r *blockchainJobRepository) Create(ctx context.Context, job *models.BlockchainJob) error {
+	_, err := r.col.InsertOne(ctx, job)
+	return err
+}
+
+func (r *blockchainJobRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.BlockchainJob, error) {
+	var job models.BlockchainJob
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *blockchainJobRepository) FindActiveByCertificateID(ctx context.Context, certificateID primitive.ObjectID) (*models.BlockchainJob, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"certificate_id": certificateID},
+			{"certificate_ids": certificateID},
+		},
+		"status": bson.M{"$in": []string{models.BlockchainJobPending, models.BlockchainJobProcessing}},
+	}
+	var job models.BlockchainJob
+	err := r.col.FindOne(ctx, filter).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimNext lấy tác vụ đến hạn và chuyển sang processing trong một thao tác để nhiều worker không xử lý trùng
+func (r *blockchainJobRepository) ClaimNext(ctx context.Context, now time.Time) (*models.BlockchainJob, error) {
+	filter := bson.M{
+		"status":          models.BlockchainJobPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.BlockchainJobProcessing,
+			"locked_at":  now,
+			"updated_at": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.BlockchainJob
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ReleaseStale trả lại hàng đợi các tác vụ bị kẹt ở processing (worker dừng giữa chừng)
+func (r *blockchainJobRepository) ReleaseStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	filter := bson.M{
+		"status":    models.BlockchainJobProcessing,
+		"locked_at": bson.M{"$lt": lockedBefore},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.BlockchainJobPending,
+			"updated_at": time.Now(),
+		},
+	}
+	res, err := r.col.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r *blockchainJobRepository) UpdateByID(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	_, err := r.col.UpdateByID(ctx, id, update)
+	return err
+}
+
+func (r *blockchainJobRepository) Find(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.BlockchainJob, int64, error) {
+	total, err := r.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []*models.BlockchainJob
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CertificateBatchRepository interface {
+	Create(ctx context.Context, batch *models.CertificateBatch) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.CertificateBatch, error)
+	UpdateByID(ctx context.Context, id primitive.ObjectID, update bson.M) error
+}
+
+type certificateBatchRepository struct {
+	col *mongo.Collection
+}
+
+func NewCertificateBatchRepository(db *mongo.Database) CertificateBatchRepository {
+	return &certificateBatchRepository{
+		col: db.Collection("certificate_batches"),
+	}
+}
+
+func (r *certificateBatchRepository) Create(ctx context.Context, batch *models.CertificateBatch) error {
+	_, err := r.col.InsertOne(ctx, batch)
+	return err
+}
+
+func (r *certificateBatchRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CertificateBatch, error) {
+	var batch models.CertificateBatch
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&batch)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *certificateBatchRepository) UpdateByID(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	_, err := r.col.UpdateByID(ctx, id, update)
+	return err
+}
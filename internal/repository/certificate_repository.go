@@ -13,7 +13,9 @@ import (
 
 type CertificateRepository interface {
 	GetAllCertificates(ctx context.Context) ([]*models.Certificate, error)
+	FindAnchored(ctx context.Context) ([]*models.Certificate, error)
 	UpdateCertificateByID(ctx context.Context, id primitive.ObjectID, update bson.M) error
+	UpdateCertificateIfMatch(ctx context.Context, filter bson.M, update bson.M) (bool, error)
 	FindOne(ctx context.Context, filter interface{}) (*models.Certificate, error)
 	GetCertificateByID(ctx context.Context, id primitive.ObjectID) (*models.Certificate, error)
 	FindCertificateByStudentCodeAndName(ctx context.Context, studentCode, name string, universityID primitive.ObjectID) (*models.Certificate, error)
@@ -30,6 +32,8 @@ type CertificateRepository interface {
 	UpdateCertificatePath(ctx context.Context, certificateID primitive.ObjectID, path string) error
 	ExistsDegreeByStudentCodeAndType(ctx context.Context, studentCode string, universityID primitive.ObjectID, certType string) (bool, error)
 	FindBySerialAndUniversity(ctx context.Context, serial string, universityID primitive.ObjectID) (*models.Certificate, error)
+	FindByHashFile(ctx context.Context, hashFile string) (*models.Certificate, error)
+	EnsureIndexes(ctx context.Context) error
 }
 type certificateRepository struct {
 	col *mongo.Collection
@@ -49,6 +53,15 @@ func (r *certificateRepository) UpdateCertificateByID(ctx context.Context, id pr
 	return err
 }
 
+// UpdateCertificateIfMatch chỉ cập nhật khi văn bằng còn khớp filter, trả false nếu không có văn bằng nào khớp
+func (r *certificateRepository) UpdateCertificateIfMatch(ctx context.Context, filter bson.M, update bson.M) (bool, error) {
+	res, err := r.col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
 func (r *certificateRepository) GetAllCertificates(ctx context.Context) ([]*models.Certificate, error) {
 	cursor, err := r.col.Find(ctx, bson.M{})
 	if err != nil {
@@ -62,6 +75,22 @@ func (r *certificateRepository) GetAllCertificates(ctx context.Context) ([]*mode
 	}
 	return certs, nil
 }
+
+// FindAnchored trả về các văn bằng đã có blockchain_tx_id
+func (r *certificateRepository) FindAnchored(ctx context.Context) ([]*models.Certificate, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"blockchain_tx_id": bson.M{"$exists": true, "$ne": ""}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var certs []*models.Certificate
+	if err := cursor.All(ctx, &certs); err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
 func (r *certificateRepository) GetCertificateByID(ctx context.Context, id primitive.ObjectID) (*models.Certificate, error) {
 	var cert models.Certificate
 	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&cert)
@@ -101,6 +130,27 @@ func (r *certificateRepository) FindBySerialNumber(ctx context.Context, serial s
 	}
 	return &cert, nil
 }
+
+// EnsureIndexes tạo index cho các truy vấn công khai, xác minh bằng file tra cứu theo hash_file
+func (r *certificateRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash_file", Value: 1}},
+		Options: options.Index().SetName("hash_file_1").SetSparse(true),
+	})
+	return err
+}
+
+func (r *certificateRepository) FindByHashFile(ctx context.Context, hashFile string) (*models.Certificate, error) {
+	var cert models.Certificate
+	err := r.col.FindOne(ctx, bson.M{"hash_file": hashFile}).Decode(&cert)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cert, nil
+}
 func (r *certificateRepository) FindLatestCertificateByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Certificate, error) {
 	filter := bson.M{"user_id": userID}
 	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}) // sắp xếp giảm dần theo created_at để lấy mới nhất
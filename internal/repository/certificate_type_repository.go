@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CertificateTypeRepository interface {
+	Create(ctx context.Context, certType *models.CertificateType) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.CertificateType, error)
+	FindByCodeOrName(ctx context.Context, universityID primitive.ObjectID, value string) (*models.CertificateType, error)
+	FindAllByUniversityID(ctx context.Context, universityID primitive.ObjectID) ([]*models.CertificateType, error)
+	Update(ctx context.Context, id primitive.ObjectID, update bson.M) error
+	DeleteByID(ctx context.Context, id primitive.ObjectID) error
+}
+
+type certificateTypeRepository struct {
+	col *mongo.Collection
+}
+
+func NewCertificateTypeRepository(db *mongo.Database) CertificateTypeRepository {
+	return &certificateTypeRepository{
+		col: db.Collection("certificate_types"),
+	}
+}
+
+func (r *certificateTypeRepository) Create(ctx context.Context, certType *models.CertificateType) error {
+	_, err := r.col.InsertOne(ctx, certType)
+	return err
+}
+
+func (r *certificateTypeRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CertificateType, error) {
+	var certType models.CertificateType
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&certType)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &certType, nil
+}
+
+// FindByCodeOrName tìm theo mã hoặc tên hiển thị, vì văn bằng đã cấp lưu tên hiển thị của loại
+func (r *certificateTypeRepository) FindByCodeOrName(ctx context.Context, universityID primitive.ObjectID, value string) (*models.CertificateType, error) {
+	filter := bson.M{
+		"university_id": universityID,
+		"$or": []bson.M{
+			{"code": value},
+			{"display_name": value},
+		},
+	}
+	var certType models.CertificateType
+	err := r.col.FindOne(ctx, filter).Decode(&certType)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &certType, nil
+}
+
+func (r *certificateTypeRepository) FindAllByUniversityID(ctx context.Context, universityID primitive.ObjectID) ([]*models.CertificateType, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "code", Value: 1}})
+	cursor, err := r.col.Find(ctx, bson.M{"university_id": universityID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var types []*models.CertificateType
+	if err := cursor.All(ctx, &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (r *certificateTypeRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	_, err := r.col.UpdateByID(ctx, id, bson.M{"$set": update})
+	return err
+}
+
+func (r *certificateTypeRepository) DeleteByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
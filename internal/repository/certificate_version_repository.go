@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CertificateVersionRepository interface {
+	Create(ctx context.Context, version *models.CertificateVersion) error
+	FindByCertificateID(ctx context.Context, certificateID primitive.ObjectID) ([]*models.CertificateVersion, error)
+	CountByCertificateID(ctx context.Context, certificateID primitive.ObjectID) (int64, error)
+	SetTxID(ctx context.Context, certificateID primitive.ObjectID, version int, txID string) error
+}
+
+type certificateVersionRepository struct {
+	col *mongo.Collection
+}
+
+func NewCertificateVersionRepository(db *mongo.Database) CertificateVersionRepository {
+	return &certificateVersionRepository{
+		col: db.Collection("certificate_versions"),
+	}
+}
+
+func (r *certificateVersionRepository) Create(ctx context.Context, version *models.CertificateVersion) error {
+	_, err := r.col.InsertOne(ctx, version)
+	return err
+}
+
+func (r *certificateVersionRepository) FindByCertificateID(ctx context.Context, certificateID primitive.ObjectID) ([]*models.CertificateVersion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+	cursor, err := r.col.Find(ctx, bson.M{"certificate_id": certificateID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var versions []*models.CertificateVersion
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *certificateVersionRepository) CountByCertificateID(ctx context.Context, certificateID primitive.ObjectID) (int64, error) {
+	return r.col.CountDocuments(ctx, bson.M{"certificate_id": certificateID})
+}
+
+func (r *certificateVersionRepository) SetTxID(ctx context.Context, certificateID primitive.ObjectID, version int, txID string) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"certificate_id": certificateID, "version": version},
+		bson.M{"$set": bson.M{"tx_id": txID}},
+	)
+	return err
+}
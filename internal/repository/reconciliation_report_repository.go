@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReconciliationReportRepository interface {
+	Create(ctx context.Context, report *models.ReconciliationReport) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ReconciliationReport, error)
+	Find(ctx context.Context, page, pageSize int) ([]*models.ReconciliationReport, int64, error)
+}
+
+type reconciliationReportRepository struct {
+	col *mongo.Collection
+}
+
+func NewReconciliationReportRepository(db *mongo.Database) ReconciliationReportRepository {
+	return &reconciliationReportRepository{
+		col: db.Collection("reconciliation_reports"),
+	}
+}
+
+func (r *reconciliationReportRepository) Create(ctx context.Context, report *models.ReconciliationReport) error {
+	_, err := r.col.InsertOne(ctx, report)
+	return err
+}
+
+func (r *reconciliationReportRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ReconciliationReport, error) {
+	var report models.ReconciliationReport
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&report)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Find trả về danh sách báo cáo mới nhất trước, không kèm chi tiết sai lệch
+func (r *reconciliationReportRepository) Find(ctx context.Context, page, pageSize int) ([]*models.ReconciliationReport, int64, error) {
+	total, err := r.col.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetProjection(bson.M{"drifts": 0})
+	cursor, err := r.col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.ReconciliationReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, 0, err
+	}
+	return reports, total, nil
+}
@@ -20,6 +20,7 @@ type UniversityRepository interface {
 	GetAllUniversities(ctx context.Context) ([]*models.University, error)
 	GetUniversitiesByStatus(ctx context.Context, status string) ([]*models.University, error)
 	GetUniversityByCode(ctx context.Context, code string) (*models.University, error)
+	UpdateSigningCertificate(ctx context.Context, id primitive.ObjectID, certPEM string) error
 }
 
 type universityRepository struct {
@@ -146,3 +147,15 @@ func (r *universityRepository) FindByCode(ctx context.Context, code string) (*mo
 	}
 	return &university, nil
 }
+
+func (r *universityRepository) UpdateSigningCertificate(ctx context.Context, id primitive.ObjectID, certPEM string) error {
+	now := time.Now()
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"signing_certificate":    certPEM,
+			"signing_key_created_at": now,
+			"updated_at":             now,
+		},
+	})
+	return err
+}
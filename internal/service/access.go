@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// checkCertificateViewer cho phép sinh viên sở hữu văn bằng, cán bộ của trường cấp và quản trị hệ thống
+func checkCertificateViewer(claims *utils.CustomClaims, cert *models.Certificate) error {
+	switch claims.Role {
+	case common.RoleAdmin:
+		return nil
+	case common.RoleStudent:
+		if cert.UserID.Hex() == claims.UserID {
+			return nil
+		}
+	default:
+		if cert.UniversityID.Hex() == claims.UniversityID {
+			return nil
+		}
+	}
+	return common.ErrCertificateAccessDenied
+}
+
+// callerUniversityScope trả trường mà người gọi bị giới hạn trong đó, NilObjectID nếu là quản trị hệ thống hoặc worker không có claims
+func callerUniversityScope(ctx context.Context) (primitive.ObjectID, error) {
+	claims, ok := ctx.Value(utils.ClaimsContextKey).(*utils.CustomClaims)
+	if !ok || claims == nil || claims.Role == common.RoleAdmin {
+		return primitive.NilObjectID, nil
+	}
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return primitive.NilObjectID, common.ErrInvalidToken
+	}
+	return universityID, nil
+}
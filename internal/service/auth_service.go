@@ -24,6 +24,7 @@ type AuthService interface {
 	GetAllAccounts(ctx context.Context, page, pageSize int) ([]*models.Account, int64, error)
 	GetAccountByID(ctx context.Context, id primitive.ObjectID) (*models.Account, error)
 	GetAccountsByRole(ctx context.Context, role string) ([]models.Account, error)
+	CreateStaffAccount(ctx context.Context, universityID primitive.ObjectID, req *models.CreateStaffAccountRequest) (*models.Account, error)
 }
 
 type authService struct {
@@ -136,6 +137,51 @@ func (s *authService) Register(ctx context.Context, req models.RegisterRequest)
 	return nil
 }
 
+// CreateStaffAccount tạo tài khoản cán bộ khoa hoặc ban giám hiệu thuộc trường, mật khẩu ngẫu nhiên được gửi qua email
+func (s *authService) CreateStaffAccount(ctx context.Context, universityID primitive.ObjectID, req *models.CreateStaffAccountRequest) (*models.Account, error) {
+	if req.Role != common.RoleFacultyStaff && req.Role != common.RoleRector {
+		return nil, common.ErrInvalidStaffRole
+	}
+	exists, err := s.authRepo.IsPersonalEmailExist(ctx, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("Lỗi kiểm tra email: %w", err)
+	}
+	if exists {
+		return nil, common.ErrEmailExists
+	}
+
+	rawPassword := utils.GenerateRandomPassword(10)
+	hashed, err := utils.HashPassword(rawPassword)
+	if err != nil {
+		return nil, err
+	}
+	account := &models.Account{
+		ID:            primitive.NewObjectID(),
+		UniversityID:  universityID,
+		PersonalEmail: req.Email,
+		PasswordHash:  hashed,
+		CreatedAt:     time.Now(),
+		Role:          req.Role,
+	}
+	if err := s.authRepo.CreateAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("không tạo được tài khoản: %w", err)
+	}
+
+	emailBody := fmt.Sprintf(`Xin chào,
+
+Bạn đã được cấp tài khoản trên hệ thống quản lý văn bằng.
+
+Thông tin tài khoản:
+- Email đăng nhập: %s
+- Mật khẩu: %s
+
+Vui lòng đăng nhập và thay đổi mật khẩu ngay sau lần đầu sử dụng.
+
+Trân trọng.`, account.PersonalEmail, rawPassword)
+	_ = s.emailSender.SendEmail(account.PersonalEmail, "Tài khoản cán bộ trường", emailBody)
+	return account, nil
+}
+
 func (s *authService) Login(ctx context.Context, email, password string) (*models.Account, error) {
 	account, err := s.authRepo.FindByPersonalEmail(ctx, email)
 	if err != nil {
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/credential"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/signing"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	openBadgeContext          = "https://purl.imsglobal.org/spec/ob/v3p0/context-3.0.3.json"
+	credentialContextV2       = "https://www.w3.org/ns/credentials/v2"
+	openBadgeCredentialType   = "OpenBadgeCredential"
+	openBadgeAchievementType  = "Certificate"
+	badgeRecipientSaltSize    = 8
+	badgeIdentityTypeEmail    = "emailAddress"
+	badgeIdentityTypeStudent  = "studentId"
+	badgeHostedPathPrefix     = "/api/v1/public/badges"
+	badgeCredentialPathPrefix = badgeHostedPathPrefix + "/credentials/"
+)
+
+// BadgeService xuất chứng chỉ không phải văn bằng theo Open Badges 3.0 và phục vụ các endpoint công khai của huy hiệu
+type BadgeService interface {
+	ExportCertificateBadge(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateBadge, error)
+	GetHostedBadge(ctx context.Context, id primitive.ObjectID) (*models.BadgeStatus, error)
+	GetIssuerProfile(ctx context.Context, universityCode string) (*models.BadgeProfile, error)
+	GetAchievement(ctx context.Context, universityCode, typeCode string) (*models.BadgeAchievement, error)
+	VerifyBadge(ctx context.Context, token string) (*models.BadgeVerifyResult, error)
+}
+
+type badgeService struct {
+	certRepo       repository.CertificateRepository
+	certTypeRepo   repository.CertificateTypeRepository
+	userRepo       repository.UserRepository
+	universityRepo repository.UniversityRepository
+	keyStore       *signing.KeyStore
+	ledger         blockchain.Ledger
+	baseURL        string
+}
+
+func NewBadgeService(
+	certRepo repository.CertificateRepository,
+	certTypeRepo repository.CertificateTypeRepository,
+	userRepo repository.UserRepository,
+	universityRepo repository.UniversityRepository,
+	keyStore *signing.KeyStore,
+	ledger blockchain.Ledger,
+	baseURL string,
+) BadgeService {
+	return &badgeService{
+		certRepo:       certRepo,
+		certTypeRepo:   certTypeRepo,
+		userRepo:       userRepo,
+		universityRepo: universityRepo,
+		keyStore:       keyStore,
+		ledger:         ledger,
+		baseURL:        baseURL,
+	}
+}
+
+func (s *badgeService) ExportCertificateBadge(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateBadge, error) {
+	cert, err := s.certRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if err := checkCertificateViewer(claims, cert); err != nil {
+		return nil, err
+	}
+	return s.issueBadge(ctx, cert)
+}
+
+// GetHostedBadge là endpoint công khai mà id của huy hiệu trỏ tới, chỉ trả trạng thái hiện tại.
+// Huy hiệu đã ký và token xác minh chỉ được phát qua ExportCertificateBadge cho người đã đăng nhập.
+func (s *badgeService) GetHostedBadge(ctx context.Context, id primitive.ObjectID) (*models.BadgeStatus, error) {
+	cert, err := s.certRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil || !cert.Signed || cert.Signature == "" {
+		return nil, common.ErrCertificateNotFound
+	}
+	certType, err := resolveCertificateType(ctx, s.certTypeRepo, cert.UniversityID, cert.CertificateType)
+	if err != nil {
+		return nil, err
+	}
+	// Văn bằng không có huy hiệu, trả như không tồn tại để endpoint công khai không cho dò văn bằng theo id
+	if certType.IsDegree {
+		return nil, common.ErrCertificateNotFound
+	}
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+
+	achievement := s.buildAchievement(university, certType)
+	achievement.Context = nil
+	return &models.BadgeStatus{
+		ID:          s.baseURL + badgeCredentialPathPrefix + cert.ID.Hex(),
+		Issuer:      achievement.Creator,
+		Achievement: achievement,
+		ValidFrom:   badgeValidFrom(cert).UTC().Format(time.RFC3339),
+		Revoked:     cert.Revoked,
+	}, nil
+}
+
+// issueBadge dựng OpenBadgeCredential cho chứng chỉ đã ký và ký JWT bằng khóa của trường
+func (s *badgeService) issueBadge(ctx context.Context, cert *models.Certificate) (*models.CertificateBadge, error) {
+	if cert.Revoked {
+		return nil, common.ErrCertificateRevoked
+	}
+	if !cert.Signed || cert.Signature == "" {
+		return nil, common.ErrCertificateNotSigned
+	}
+	certType, err := resolveCertificateType(ctx, s.certTypeRepo, cert.UniversityID, cert.CertificateType)
+	if err != nil {
+		return nil, err
+	}
+	if certType.IsDegree {
+		return nil, common.ErrCertificateIsDegree
+	}
+	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return nil, common.ErrUserNotExisted
+	}
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+	key, err := loadUniversityKey(s.keyStore, university.UniversityCode)
+	if err != nil {
+		return nil, err
+	}
+
+	identifiers, err := badgeRecipientIdentifiers(user, cert, university)
+	if err != nil {
+		return nil, err
+	}
+	verifyToken, err := utils.GenerateVerifyToken(cert.ID)
+	if err != nil {
+		return nil, err
+	}
+	validFrom := badgeValidFrom(cert)
+
+	achievement := s.buildAchievement(university, certType)
+	achievement.Context = nil
+	badge := &models.OpenBadgeCredential{
+		Context:   []string{credentialContextV2, openBadgeContext},
+		ID:        s.baseURL + badgeCredentialPathPrefix + cert.ID.Hex(),
+		Type:      []string{credential.TypeVerifiableCredential, openBadgeCredentialType},
+		Issuer:    achievement.Creator,
+		Name:      cert.Name,
+		ValidFrom: validFrom.UTC().Format(time.RFC3339),
+		CredentialSubject: &models.BadgeAchievementSubject{
+			Type:        []string{"AchievementSubject"},
+			Identifier:  identifiers,
+			Achievement: achievement,
+		},
+		CredentialStatus: &models.CredentialStatus{
+			ID:   s.baseURL + "/api/v1/public/verify?" + url.Values{"token": {verifyToken}}.Encode(),
+			Type: credentialStatusType,
+		},
+	}
+	if badge.Name == "" {
+		badge.Name = certType.DisplayName
+	}
+
+	raw, err := json.Marshal(badge)
+	if err != nil {
+		return nil, fmt.Errorf("marshal lỗi: %v", err)
+	}
+	token, err := credential.Sign(key, s.issuerProfileID(university.UniversityCode), &credential.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    badge.Issuer.ID,
+			ID:        badge.ID,
+			NotBefore: jwt.NewNumericDate(validFrom),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		VC: raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.CertificateBadge{Credential: badge, JWT: token}, nil
+}
+
+func badgeValidFrom(cert *models.Certificate) time.Time {
+	if cert.SignedAt.IsZero() {
+		return cert.IssueDate
+	}
+	return cert.SignedAt
+}
+
+func (s *badgeService) GetIssuerProfile(ctx context.Context, universityCode string) (*models.BadgeProfile, error) {
+	university, err := s.universityRepo.FindByCode(ctx, universityCode)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+	return s.buildProfile(university), nil
+}
+
+// GetAchievement trả định nghĩa huy hiệu của một loại chứng chỉ trong danh mục của trường
+func (s *badgeService) GetAchievement(ctx context.Context, universityCode, typeCode string) (*models.BadgeAchievement, error) {
+	university, err := s.universityRepo.FindByCode(ctx, universityCode)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+	certType, err := resolveCertificateType(ctx, s.certTypeRepo, university.ID, typeCode)
+	if err != nil {
+		return nil, err
+	}
+	if certType.IsDegree {
+		return nil, common.ErrCertificateIsDegree
+	}
+	return s.buildAchievement(university, certType), nil
+}
+
+// VerifyBadge kiểm tra chữ ký JWT của huy hiệu, bên cấp và trạng thái thu hồi của chứng chỉ
+func (s *badgeService) VerifyBadge(ctx context.Context, token string) (*models.BadgeVerifyResult, error) {
+	claims, err := credential.ParseUnverified(token)
+	if err != nil {
+		return nil, common.ErrInvalidCredential
+	}
+	var badge models.OpenBadgeCredential
+	if err := json.Unmarshal(claims.VC, &badge); err != nil || badge.Issuer == nil || badge.CredentialSubject == nil {
+		return nil, common.ErrInvalidCredential
+	}
+	certID, err := objectIDFromURL(badge.ID, s.baseURL+badgeCredentialPathPrefix, "")
+	if err != nil {
+		return nil, common.ErrInvalidCredential
+	}
+	result := &models.BadgeVerifyResult{Credential: &badge}
+	result.CertificateID = certID.Hex()
+
+	issuerCheck := &models.IntegrityCheck{Name: models.IntegrityCheckIssuer, Actual: badge.Issuer.ID}
+	prefix := s.baseURL + badgeHostedPathPrefix + "/issuers/"
+	universityCode, _ := url.PathUnescape(strings.TrimPrefix(badge.Issuer.ID, prefix))
+	university, err := s.universityRepo.FindByCode(ctx, universityCode)
+	switch {
+	case !strings.HasPrefix(badge.Issuer.ID, prefix) || err != nil || university == nil:
+		issuerCheck.Message = "Bên cấp không phải trường trong hệ thống"
+	case claims.Issuer != badge.Issuer.ID:
+		issuerCheck.Message = "Bên cấp không khớp với claim iss của JWT"
+	case university.SigningCertificate == "":
+		issuerCheck.Message = "Trường chưa đăng ký khóa ký số"
+	default:
+		issuerCheck.Expected = s.issuerProfileID(university.UniversityCode)
+		issuerCheck.Passed = true
+	}
+	result.Add(issuerCheck)
+	if !issuerCheck.Passed {
+		result.Add(&models.IntegrityCheck{Name: models.IntegrityCheckProof, Skipped: true, Message: "Không xác định được khóa của bên cấp"})
+		result.Finish("")
+		return result, nil
+	}
+	result.Add(credentialProofCheck(university, token, badge.ID, ""))
+
+	cert, err := s.certRepo.GetCertificateByID(ctx, certID)
+	if err != nil || cert == nil || cert.UniversityID != university.ID {
+		result.Add(&models.IntegrityCheck{Name: models.IntegrityCheckCertificate, Message: "Không tìm thấy chứng chỉ của huy hiệu"})
+		result.Finish("")
+		return result, nil
+	}
+	result.Add(credentialRevocationCheck(ctx, s.ledger, cert, &result.IntegrityReport))
+
+	result.Finish("Huy hiệu hợp lệ")
+	return result, nil
+}
+
+func (s *badgeService) issuerProfileID(universityCode string) string {
+	return s.baseURL + badgeHostedPathPrefix + "/issuers/" + url.PathEscape(universityCode)
+}
+
+func (s *badgeService) buildProfile(university *models.University) *models.BadgeProfile {
+	return &models.BadgeProfile{
+		ID:   s.issuerProfileID(university.UniversityCode),
+		Type: []string{"Profile"},
+		Name: university.UniversityName,
+		URL:  s.baseURL,
+	}
+}
+
+func (s *badgeService) buildAchievement(university *models.University, certType *models.CertificateType) *models.BadgeAchievement {
+	description := certType.Description
+	if description == "" {
+		description = fmt.Sprintf("%s do %s cấp", certType.DisplayName, university.UniversityName)
+	}
+	return &models.BadgeAchievement{
+		Context:         []string{credentialContextV2, openBadgeContext},
+		ID:              s.baseURL + badgeHostedPathPrefix + "/achievements/" + url.PathEscape(university.UniversityCode) + "/" + url.PathEscape(certType.Code),
+		Type:            []string{"Achievement"},
+		AchievementType: openBadgeAchievementType,
+		Name:            certType.DisplayName,
+		Description:     description,
+		Criteria:        &models.BadgeCriteria{Narrative: fmt.Sprintf("Hoàn thành chương trình %s tại %s", certType.DisplayName, university.UniversityName)},
+		Creator:         s.buildProfile(university),
+	}
+}
+
+// badgeRecipientIdentifiers định danh người nhận bằng sha256(giá trị + muối) theo IdentityObject của Open Badges
+func badgeRecipientIdentifiers(user *models.User, cert *models.Certificate, university *models.University) ([]*models.BadgeIdentityObject, error) {
+	saltBytes := make([]byte, badgeRecipientSaltSize)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return nil, fmt.Errorf("không thể sinh muối cho người nhận: %w", err)
+	}
+	salt := hex.EncodeToString(saltBytes)
+	identity := func(identityType, value string) *models.BadgeIdentityObject {
+		sum := sha256.Sum256([]byte(value + salt))
+		return &models.BadgeIdentityObject{
+			Type:         "IdentityObject",
+			Hashed:       true,
+			IdentityHash: "sha256$" + hex.EncodeToString(sum[:]),
+			IdentityType: identityType,
+			Salt:         salt,
+		}
+	}
+
+	identifiers := []*models.BadgeIdentityObject{
+		identity(badgeIdentityTypeStudent, cert.StudentCode+"@"+university.UniversityCode),
+	}
+	if user.Email != "" {
+		identifiers = append(identifiers, identity(badgeIdentityTypeEmail, strings.ToLower(user.Email)))
+	}
+	return identifiers, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetCertificateHistory trả mọi giao dịch của văn bằng trên sổ cái, mỗi giao dịch ghép với bản ghi MongoDB có cùng tx_id.
+// Văn bằng không còn trong MongoDB chỉ quản trị hệ thống được xem, khi đó chỉ có dữ liệu sổ cái.
+func (s *blockchainService) GetCertificateHistory(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateLedgerHistory, error) {
+	history := &models.CertificateLedgerHistory{
+		CertificateID: id.Hex(),
+		Transactions:  []*models.LedgerTransaction{},
+		StatusHistory: []*models.CertificateStatusChange{},
+		Versions:      []*models.CertificateVersion{},
+	}
+
+	cert, err := s.certRepo.GetCertificateByID(ctx, id)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if cert != nil {
+		if err := checkCertificateViewer(claims, cert); err != nil {
+			return nil, err
+		}
+		history.InDatabase = true
+		if cert.StatusHistory != nil {
+			history.StatusHistory = cert.StatusHistory
+		}
+		versions, err := s.versionRepo.FindByCertificateID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if versions != nil {
+			history.Versions = versions
+		}
+	} else if claims.Role != common.RoleAdmin {
+		return nil, common.ErrCertificateNotFound
+	}
+
+	entries, err := s.ledger.GetCertificateHistory(ctx, id.Hex())
+	switch {
+	case err == nil:
+		history.Transactions = ledgerTransactions(entries)
+	case cert == nil:
+		return nil, common.ErrCertificateNotFound
+	case cert.BlockchainTxID != "" && cert.BatchID.IsZero():
+		history.LedgerError = err.Error()
+	}
+
+	// Văn bằng còn nằm trong lô chỉ có giao dịch neo gốc Merkle, không có bản ghi riêng
+	if cert != nil && !cert.BatchID.IsZero() {
+		tx := &models.LedgerTransaction{
+			TxID:     cert.BlockchainTxID,
+			Event:    models.LedgerEventAnchorBatch,
+			Version:  cert.CurrentVersion(),
+			CertHash: cert.CertHash,
+			BatchID:  cert.BatchID.Hex(),
+		}
+		if batch, err := s.ledger.GetBatchByID(ctx, cert.BatchID.Hex()); err == nil {
+			tx.MerkleRoot = batch.MerkleRoot
+			tx.SubmittedBy = batch.SubmittedBy
+		} else {
+			history.LedgerError = err.Error()
+		}
+		history.Transactions = append(history.Transactions, tx)
+	}
+
+	if cert != nil {
+		joinLedgerAudit(history.Transactions, cert, history.Versions)
+	}
+	return history, nil
+}
+
+// ledgerTransactions chuyển lịch sử khóa trên sổ cái thành danh sách giao dịch theo thứ tự thời gian
+func ledgerTransactions(entries []*models.LedgerHistoryEntry) []*models.LedgerTransaction {
+	sorted := make([]*models.LedgerHistoryEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	txs := make([]*models.LedgerTransaction, 0, len(sorted))
+	var prev *models.CertificateOnChain
+	for _, entry := range sorted {
+		tx := &models.LedgerTransaction{TxID: entry.TxID, Timestamp: entry.Timestamp}
+		value := entry.Value
+		switch {
+		case entry.IsDelete:
+			tx.Event = models.LedgerEventDelete
+		case prev == nil:
+			tx.Event = models.LedgerEventIssue
+		case value != nil && value.Revoked && !prev.Revoked:
+			tx.Event = models.LedgerEventRevoke
+		default:
+			tx.Event = models.LedgerEventUpdate
+		}
+		if value != nil {
+			tx.Version = value.Version
+			tx.SubmittedBy = value.SubmittedBy
+			tx.CertHash = value.CertHash
+			tx.Revoked = value.Revoked
+			tx.BatchID = value.BatchID
+			tx.MerkleRoot = value.MerkleRoot
+			prev = value
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// joinLedgerAudit ghép giao dịch với nhật ký MongoDB: phiên bản đính chính, thu hồi và chuyển trạng thái ghi tx_id
+func joinLedgerAudit(txs []*models.LedgerTransaction, cert *models.Certificate, versions []*models.CertificateVersion) {
+	records := make(map[string]*models.LedgerAuditRecord)
+	for _, change := range cert.StatusHistory {
+		if change.TxID == "" {
+			continue
+		}
+		record := &models.LedgerAuditRecord{
+			Source:    models.AuditSourceStatusHistory,
+			Action:    change.Action,
+			Role:      change.Role,
+			Timestamp: change.ChangedAt,
+		}
+		if !change.ChangedBy.IsZero() {
+			record.ActorID = change.ChangedBy.Hex()
+		}
+		records[change.TxID] = record
+	}
+	for _, version := range versions {
+		if version.TxID == "" {
+			continue
+		}
+		record := &models.LedgerAuditRecord{
+			Source:    models.AuditSourceVersion,
+			Action:    models.CertificateActionAmend,
+			Reason:    version.Reason,
+			Version:   version.Version,
+			Timestamp: version.CreatedAt,
+		}
+		if !version.AmendedBy.IsZero() {
+			record.ActorID = version.AmendedBy.Hex()
+		}
+		// Bản gốc lưu lại tx_id của lần ghi đầu tiên, không ghi đè bản ghi neo trong status_history
+		if _, ok := records[version.TxID]; !ok || version.Version > 1 {
+			records[version.TxID] = record
+		}
+	}
+	if revocation := cert.Revocation; revocation != nil && revocation.TxID != "" {
+		records[revocation.TxID] = &models.LedgerAuditRecord{
+			Source:    models.AuditSourceRevocation,
+			Action:    revocation.ReasonCode,
+			ActorID:   revocation.RevokedBy.Hex(),
+			Reason:    revocation.Reason,
+			Timestamp: revocation.RevokedAt,
+		}
+	}
+
+	for _, tx := range txs {
+		tx.Audit = records[tx.TxID]
+		if tx.Timestamp.IsZero() && tx.Audit != nil {
+			tx.Timestamp = tx.Audit.Timestamp
+		}
+	}
+}
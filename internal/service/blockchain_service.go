@@ -2,130 +2,1035 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
 	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/database"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/merkle"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/signing"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type BlockchainService interface {
-	PushCertificateToChain(ctx context.Context, certificateID primitive.ObjectID) (string, error)
+	PushCertificateToChain(ctx context.Context, certificateID primitive.ObjectID) (*models.BlockchainJob, error)
+	AnchorCertificateBatch(ctx context.Context, certificateIDs []primitive.ObjectID) (*models.CertificateBatch, *models.BlockchainJob, error)
+	GetBatch(ctx context.Context, id primitive.ObjectID) (*models.CertificateBatch, error)
+	ProcessNextJob(ctx context.Context) (bool, error)
+	ReleaseStaleJobs(ctx context.Context) error
+	GetJob(ctx context.Context, id primitive.ObjectID) (*models.BlockchainJob, error)
+	SearchJobs(ctx context.Context, params models.SearchBlockchainJobParams) ([]*models.BlockchainJob, int64, error)
+	RetryJob(ctx context.Context, id primitive.ObjectID) (*models.BlockchainJob, error)
 	GetCertificateFromChain(ctx context.Context, certificateID string) (*models.CertificateOnChain, error)
-	VerifyCertificateIntegrity(ctx context.Context, certID string) (bool, string, *models.CertificateOnChain, error)
+	VerifyCertificateIntegrity(ctx context.Context, certID string) (*models.IntegrityReport, error)
+	PublicVerify(ctx context.Context, universityCode, serialNumber, token string) (*models.PublicVerifyResult, error)
+	VerifyCertificateFile(ctx context.Context, fileData []byte) (*models.PublicVerifyResult, error)
+	VerifyDisclosure(ctx context.Context, req *models.VerifyDisclosureRequest) (*models.DisclosureVerifyResult, error)
+	GetCertificateHistory(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateLedgerHistory, error)
+	GetCertificatePrivateDetails(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificatePrivateDetailsResult, error)
 }
 
+const (
+	blockchainJobMaxAttempts = 5
+	blockchainJobBaseBackoff = 30 * time.Second
+	blockchainJobMaxBackoff  = 30 * time.Minute
+	blockchainJobLockTimeout = 10 * time.Minute
+)
+
 type blockchainService struct {
 	certRepo       repository.CertificateRepository
+	versionRepo    repository.CertificateVersionRepository
+	jobRepo        repository.BlockchainJobRepository
+	batchRepo      repository.CertificateBatchRepository
 	userRepo       repository.UserRepository
 	facultyRepo    repository.FacultyRepository
 	universityRepo repository.UniversityRepository
-	fabricClient   *blockchain.FabricClient
+	minioClient    *database.MinioClient
+	ledger         blockchain.Ledger
 }
 
 func NewBlockchainService(
 	certRepo repository.CertificateRepository,
+	versionRepo repository.CertificateVersionRepository,
+	jobRepo repository.BlockchainJobRepository,
+	batchRepo repository.CertificateBatchRepository,
 	userRepo repository.UserRepository,
 	facultyRepo repository.FacultyRepository,
 	universityRepo repository.UniversityRepository,
-	fabricClient *blockchain.FabricClient,
+	minioClient *database.MinioClient,
+	ledger blockchain.Ledger,
 ) BlockchainService {
 	return &blockchainService{
 		certRepo:       certRepo,
+		versionRepo:    versionRepo,
+		jobRepo:        jobRepo,
+		batchRepo:      batchRepo,
 		userRepo:       userRepo,
 		facultyRepo:    facultyRepo,
 		universityRepo: universityRepo,
-		fabricClient:   fabricClient,
+		minioClient:    minioClient,
+		ledger:         ledger,
 	}
 }
 
-func (s *blockchainService) PushCertificateToChain(ctx context.Context, certificateID primitive.ObjectID) (string, error) {
+// PushCertificateToChain đưa văn bằng vào outbox, worker sẽ ghi lên blockchain và thử lại khi lỗi
+func (s *blockchainService) PushCertificateToChain(ctx context.Context, certificateID primitive.ObjectID) (*models.BlockchainJob, error) {
 	cert, err := s.certRepo.GetCertificateByID(ctx, certificateID)
 	if err != nil || cert == nil {
-		return "", common.ErrCertificateNotFound
+		return nil, common.ErrCertificateNotFound
 	}
-	if cert.CertHash == "" {
-		return "", fmt.Errorf("certificate chưa có cert_hash")
+	scope, err := callerUniversityScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.IsZero() && cert.UniversityID != scope {
+		return nil, common.ErrCertificateAccessDenied
+	}
+	if err := validateAnchorable(cert); err != nil {
+		return nil, err
 	}
 
-	chainData := models.CertificateOnChain{
-		CertID:              cert.ID.Hex(),
-		CertHash:            cert.CertHash,
-		HashFile:            cert.HashFile,
-		UniversitySignature: "",
-		DateOfIssuing:       cert.IssueDate.Format("2006-01-02"),
-		SerialNumber:        cert.SerialNumber,
-		RegNo:               cert.RegNo,
-		Version:             1,
-		UpdatedDate:         cert.UpdatedAt.Format("2006-01-02 15:04:05"),
+	// Đã có tác vụ đang chờ thì trả về tác vụ đó, tránh ghi trùng
+	existing, err := s.jobRepo.FindActiveByCertificateID(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
 	}
 
-	txID, err := s.fabricClient.IssueCertificate(chainData)
+	now := time.Now()
+	job := &models.BlockchainJob{
+		ID:            primitive.NewObjectID(),
+		Type:          models.BlockchainJobTypeAnchor,
+		CertificateID: cert.ID,
+		UniversityID:  cert.UniversityID,
+		Status:        models.BlockchainJobPending,
+		MaxAttempts:   blockchainJobMaxAttempts,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if claims, ok := ctx.Value(utils.ClaimsContextKey).(*utils.CustomClaims); ok && claims != nil {
+		job.CreatedBy, _ = primitive.ObjectIDFromHex(claims.AccountID)
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("không thể tạo tác vụ ghi blockchain: %w", err)
+	}
+	return job, nil
+}
+
+// AnchorCertificateBatch gom các văn bằng vào một cây Merkle và đưa gốc cây vào outbox để ghi bằng một giao dịch
+func (s *blockchainService) AnchorCertificateBatch(ctx context.Context, certificateIDs []primitive.ObjectID) (*models.CertificateBatch, *models.BlockchainJob, error) {
+	var universityID primitive.ObjectID
+	seen := make(map[primitive.ObjectID]bool, len(certificateIDs))
+	leaves := make([]*models.CertificateBatchLeaf, 0, len(certificateIDs))
+	ids := make([]primitive.ObjectID, 0, len(certificateIDs))
+	for _, id := range certificateIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		cert, err := s.certRepo.GetCertificateByID(ctx, id)
+		if err != nil || cert == nil {
+			return nil, nil, fmt.Errorf("%w: %s", common.ErrCertificateNotFound, id.Hex())
+		}
+		if universityID.IsZero() {
+			universityID = cert.UniversityID
+		} else if cert.UniversityID != universityID {
+			return nil, nil, common.ErrCertificateBatchMixedUniversity
+		}
+		if cert.BlockchainTxID != "" {
+			return nil, nil, fmt.Errorf("%w: %s", common.ErrCertificateAlreadyAnchored, id.Hex())
+		}
+		if err := validateAnchorable(cert); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", err, id.Hex())
+		}
+		active, err := s.jobRepo.FindActiveByCertificateID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if active != nil {
+			return nil, nil, fmt.Errorf("%w: %s", common.ErrCertificateAlreadyQueued, id.Hex())
+		}
+
+		leaves = append(leaves, &models.CertificateBatchLeaf{CertificateID: cert.ID, CertHash: cert.CertHash})
+		ids = append(ids, cert.ID)
+	}
+
+	var createdBy primitive.ObjectID
+	if claims, ok := ctx.Value(utils.ClaimsContextKey).(*utils.CustomClaims); ok && claims != nil {
+		createdBy, _ = primitive.ObjectIDFromHex(claims.AccountID)
+		if claims.Role != common.RoleAdmin && claims.UniversityID != universityID.Hex() {
+			return nil, nil, common.ErrCertificateAccessDenied
+		}
+	}
+
+	tree, err := buildBatchTree(leaves)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	batch := &models.CertificateBatch{
+		ID:           primitive.NewObjectID(),
+		UniversityID: universityID,
+		MerkleRoot:   tree.Root(),
+		Size:         tree.Size(),
+		Leaves:       leaves,
+		Status:       models.CertificateBatchPending,
+		CreatedBy:    createdBy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.batchRepo.Create(ctx, batch); err != nil {
+		return nil, nil, fmt.Errorf("không thể tạo lô văn bằng: %w", err)
+	}
+
+	job := &models.BlockchainJob{
+		ID:             primitive.NewObjectID(),
+		Type:           models.BlockchainJobTypeAnchorBatch,
+		BatchID:        batch.ID,
+		CertificateIDs: ids,
+		UniversityID:   universityID,
+		Status:         models.BlockchainJobPending,
+		MaxAttempts:    blockchainJobMaxAttempts,
+		NextAttemptAt:  now,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, nil, fmt.Errorf("không thể tạo tác vụ ghi blockchain: %w", err)
+	}
+	return batch, job, nil
+}
+
+func (s *blockchainService) GetBatch(ctx context.Context, id primitive.ObjectID) (*models.CertificateBatch, error) {
+	batch, err := s.batchRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, common.ErrCertificateBatchNotFound
+	}
+	// Lô của trường khác trả như không tồn tại để không lộ ID
+	scope, err := callerUniversityScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.IsZero() && batch.UniversityID != scope {
+		return nil, common.ErrCertificateBatchNotFound
+	}
+	return batch, nil
+}
+
+func validateAnchorable(cert *models.Certificate) error {
+	if cert.CertHash == "" {
+		return fmt.Errorf("certificate chưa có cert_hash")
+	}
+	if cert.Revoked {
+		return common.ErrCertificateRevoked
+	}
+	if _, err := nextCertificateStatus(cert, models.CertificateActionAnchor, ""); err != nil {
+		return common.ErrCertificateNotApproved
+	}
+	return nil
+}
+
+// ProcessNextJob xử lý một tác vụ đến hạn, trả về false nếu hàng đợi trống
+func (s *blockchainService) ProcessNextJob(ctx context.Context) (bool, error) {
+	job, err := s.jobRepo.ClaimNext(ctx, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	attempt := &models.BlockchainJobAttempt{
+		Attempt:   job.Attempts + 1,
+		StartedAt: time.Now(),
+	}
+	var txID string
+	switch job.Type {
+	case models.BlockchainJobTypeAnchorBatch:
+		txID, err = s.anchorBatch(ctx, job)
+	case models.BlockchainJobTypeSync:
+		txID, err = s.syncCertificate(ctx, job)
+	default:
+		txID, err = s.anchorCertificate(ctx, job)
+	}
+	attempt.FinishedAt = time.Now()
+	attempt.TxID = txID
+
+	set := bson.M{
+		"attempts":   attempt.Attempt,
+		"updated_at": attempt.FinishedAt,
+	}
+	if txID != "" {
+		set["tx_id"] = txID
+	}
+
+	switch {
+	case err == nil:
+		set["status"] = models.BlockchainJobSucceeded
+		set["last_error"] = ""
+	case isPermanentJobError(err) || attempt.Attempt >= job.MaxAttempts:
+		attempt.Error = err.Error()
+		set["status"] = models.BlockchainJobFailed
+		set["last_error"] = err.Error()
+	default:
+		attempt.Error = err.Error()
+		set["status"] = models.BlockchainJobPending
+		set["last_error"] = err.Error()
+		set["next_attempt_at"] = time.Now().Add(blockchainJobBackoff(attempt.Attempt))
 	}
 
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"history": attempt},
+	}
+	if updateErr := s.jobRepo.UpdateByID(ctx, job.ID, update); updateErr != nil {
+		return true, fmt.Errorf("không thể cập nhật tác vụ %s: %w", job.ID.Hex(), updateErr)
+	}
+	return true, nil
+}
+
+// anchorCertificate ghi văn bằng lên sổ cái rồi cập nhật Mongo; nếu lần trước đã ghi được giao dịch thì chỉ cập nhật lại Mongo
+func (s *blockchainService) anchorCertificate(ctx context.Context, job *models.BlockchainJob) (string, error) {
+	cert, err := s.certRepo.GetCertificateByID(ctx, job.CertificateID)
+	if err != nil || cert == nil {
+		return "", common.ErrCertificateNotFound
+	}
+	if cert.BlockchainTxID != "" {
+		return cert.BlockchainTxID, nil
+	}
+
+	txID := job.TxID
+	if txID == "" {
+		if err := validateAnchorable(cert); err != nil {
+			return "", err
+		}
+		if err := s.ensureFieldCommitment(ctx, cert); err != nil {
+			return "", err
+		}
+		private, err := loadPrivateDetails(ctx, s.userRepo, s.facultyRepo, s.universityRepo, cert)
+		if err != nil {
+			return "", err
+		}
+		ledger, universityCode, err := universityLedger(ctx, s.ledger, s.universityRepo, cert.UniversityID)
+		if err != nil {
+			return "", err
+		}
+		txID, err = ledger.IssueCertificate(ctx, buildCertificateOnChain(cert, universityCode), private)
+		if err != nil {
+			return "", err
+		}
+		// Lưu ngay tx_id để lần thử sau không ghi trùng lên sổ cái nếu bước cập nhật Mongo lỗi
+		if err := s.jobRepo.UpdateByID(ctx, job.ID, bson.M{"$set": bson.M{"tx_id": txID}}); err != nil {
+			return txID, fmt.Errorf("không thể lưu tx_id của tác vụ: %w", err)
+		}
+	}
+
+	from := cert.CurrentStatus()
+	to := models.CertificateStatusAnchored
 	update := bson.M{
 		"$set": bson.M{
 			"blockchain_tx_id": txID,
+			"status":           to,
 			"updated_at":       time.Now(),
 		},
+		"$push": bson.M{"status_history": newAnchorStatusChange(from, to, txID)},
+	}
+	if err := s.certRepo.UpdateCertificateByID(ctx, cert.ID, update); err != nil {
+		return txID, fmt.Errorf("không thể cập nhật blockchain_tx_id: %v", err)
 	}
-	if err := s.certRepo.UpdateCertificateByID(ctx, certificateID, update); err != nil {
-		return "", fmt.Errorf("không thể cập nhật blockchain_tx_id: %v", err)
+	return txID, nil
+}
+
+// syncCertificate ghi trạng thái hiện tại trong Mongo của văn bằng đã đính chính lên sổ cái, gỡ văn bằng khỏi lô Merkle nếu có
+// rồi gắn tx_id cho phiên bản của tác vụ
+func (s *blockchainService) syncCertificate(ctx context.Context, job *models.BlockchainJob) (string, error) {
+	cert, err := s.certRepo.GetCertificateByID(ctx, job.CertificateID)
+	if err != nil || cert == nil {
+		return "", common.ErrCertificateNotFound
+	}
+	if cert.BlockchainTxID == "" {
+		return "", common.ErrCertificateNotApproved
+	}
+
+	txID := job.TxID
+	if txID == "" {
+		txID, err = syncCertificateOnChain(ctx, s.ledger, s.userRepo, s.facultyRepo, s.universityRepo, cert)
+		if err != nil {
+			return "", err
+		}
+		if err := s.jobRepo.UpdateByID(ctx, job.ID, bson.M{"$set": bson.M{"tx_id": txID}}); err != nil {
+			return txID, fmt.Errorf("không thể lưu tx_id của tác vụ: %w", err)
+		}
 	}
 
+	if !cert.BatchID.IsZero() {
+		update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+		unsetCertificateBatch(cert, update)
+		if err := s.certRepo.UpdateCertificateByID(ctx, cert.ID, update); err != nil {
+			return txID, fmt.Errorf("không thể gỡ văn bằng khỏi lô: %w", err)
+		}
+	}
+	if err := s.versionRepo.SetTxID(ctx, cert.ID, job.Version, txID); err != nil {
+		return txID, fmt.Errorf("không thể lưu tx_id của phiên bản: %w", err)
+	}
 	return txID, nil
 }
 
-func (s *blockchainService) GetCertificateFromChain(ctx context.Context, certificateID string) (*models.CertificateOnChain, error) {
-	cert, err := s.fabricClient.GetCertificateByID(certificateID)
+// newAnchorStatusChange ghi lần neo văn bằng lên sổ cái, tx_id dùng để ghép lịch sử sổ cái với status_history
+func newAnchorStatusChange(from, to, txID string) *models.CertificateStatusChange {
+	change := newStatusChange(models.CertificateActionAnchor, from, to, "", nil)
+	change.TxID = txID
+	return change
+}
+
+// universityLedger trả sổ cái gửi giao dịch dưới định danh Fabric của trường cấp văn bằng, kèm mã trường để ghi vào bản ghi
+func universityLedger(ctx context.Context, ledger blockchain.Ledger, universityRepo repository.UniversityRepository, universityID primitive.ObjectID) (blockchain.Ledger, string, error) {
+	university, err := universityRepo.FindByID(ctx, universityID)
+	if err != nil || university == nil {
+		return nil, "", common.ErrUniversityNotFound
+	}
+	universityLedger, err := ledger.ForUniversity(university.UniversityCode)
+	if err != nil {
+		return nil, "", err
+	}
+	return universityLedger, university.UniversityCode, nil
+}
+
+// ensureFieldCommitment cam kết các trường cho văn bằng tạo trước khi có công bố chọn lọc, phải lưu trước khi ghi gốc lên sổ cái
+func (s *blockchainService) ensureFieldCommitment(ctx context.Context, cert *models.Certificate) error {
+	if cert.FieldCommitment != nil {
+		return nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return common.ErrUserNotExisted
+	}
+	faculty, err := s.facultyRepo.FindByID(ctx, cert.FacultyID)
+	if err != nil || faculty == nil {
+		return common.ErrFacultyNotFound
+	}
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return common.ErrUniversityNotFound
+	}
+	commitment, err := buildFieldCommitment(nil, certificateFieldValues(cert, user, faculty, university))
+	if err != nil {
+		return err
+	}
+	if err := s.certRepo.UpdateCertificateByID(ctx, cert.ID, bson.M{"$set": bson.M{"field_commitment": commitment}}); err != nil {
+		return fmt.Errorf("không thể lưu cam kết các trường: %w", err)
+	}
+	cert.FieldCommitment = commitment
+	return nil
+}
+
+// anchorBatch ghi gốc Merkle của lô lên sổ cái rồi lưu đường chứng minh vào từng văn bằng
+func (s *blockchainService) anchorBatch(ctx context.Context, job *models.BlockchainJob) (string, error) {
+	batch, err := s.batchRepo.FindByID(ctx, job.BatchID)
+	if err != nil {
+		return "", err
+	}
+	if batch == nil {
+		return "", common.ErrCertificateBatchNotFound
+	}
+	tree, err := buildBatchTree(batch.Leaves)
+	if err != nil {
+		return "", err
+	}
+	if tree.Root() != batch.MerkleRoot {
+		return "", common.ErrCertificateBatchStale
+	}
+
+	certs := make([]*models.Certificate, len(batch.Leaves))
+	for i, leaf := range batch.Leaves {
+		cert, err := s.certRepo.GetCertificateByID(ctx, leaf.CertificateID)
+		if err != nil || cert == nil {
+			return "", common.ErrCertificateNotFound
+		}
+		certs[i] = cert
+	}
+
+	txID := job.TxID
+	if txID == "" {
+		for i, cert := range certs {
+			// Văn bằng bị đính chính hoặc đã được ghi riêng sau khi tạo lô thì gốc cây không còn đúng, phải tạo lô mới
+			if cert.CertHash != batch.Leaves[i].CertHash || cert.BlockchainTxID != "" {
+				return "", fmt.Errorf("%w: %s", common.ErrCertificateBatchStale, cert.ID.Hex())
+			}
+			if err := validateAnchorable(cert); err != nil {
+				return "", err
+			}
+		}
+		ledger, universityCode, err := universityLedger(ctx, s.ledger, s.universityRepo, batch.UniversityID)
+		if err != nil {
+			return "", err
+		}
+		txID, err = ledger.AnchorBatch(ctx, models.CertificateBatchOnChain{
+			BatchID:        batch.ID.Hex(),
+			UniversityCode: universityCode,
+			MerkleRoot:     batch.MerkleRoot,
+			Size:           batch.Size,
+			AnchoredDate:   time.Now().Format("2006-01-02"),
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := s.jobRepo.UpdateByID(ctx, job.ID, bson.M{"$set": bson.M{"tx_id": txID}}); err != nil {
+			return txID, fmt.Errorf("không thể lưu tx_id của tác vụ: %w", err)
+		}
+	}
+
+	now := time.Now()
+	for i, cert := range certs {
+		if cert.BlockchainTxID == txID {
+			continue
+		}
+		proof, err := tree.Proof(i)
+		if err != nil {
+			return txID, err
+		}
+		from := cert.CurrentStatus()
+		to := models.CertificateStatusAnchored
+		update := bson.M{
+			"$set": bson.M{
+				"blockchain_tx_id": txID,
+				"batch_id":         batch.ID,
+				"merkle_proof":     toMerkleProofSteps(proof),
+				"status":           to,
+				"updated_at":       now,
+			},
+			"$push": bson.M{"status_history": newAnchorStatusChange(from, to, txID)},
+		}
+		if err := s.certRepo.UpdateCertificateByID(ctx, cert.ID, update); err != nil {
+			return txID, fmt.Errorf("không thể cập nhật văn bằng %s trong lô: %v", cert.ID.Hex(), err)
+		}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.CertificateBatchAnchored,
+			"tx_id":       txID,
+			"anchored_at": now,
+			"updated_at":  now,
+		},
+	}
+	if err := s.batchRepo.UpdateByID(ctx, batch.ID, update); err != nil {
+		return txID, fmt.Errorf("không thể cập nhật lô văn bằng: %w", err)
+	}
+	return txID, nil
+}
+
+func buildBatchTree(leaves []*models.CertificateBatchLeaf) (*merkle.Tree, error) {
+	hashes := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = leaf.CertHash
+	}
+	return merkle.Build(hashes)
+}
+
+func toMerkleProofSteps(proof []merkle.ProofStep) []*models.MerkleProofStep {
+	steps := make([]*models.MerkleProofStep, len(proof))
+	for i, p := range proof {
+		steps[i] = &models.MerkleProofStep{Hash: p.Hash, Left: p.Left}
+	}
+	return steps
+}
+
+func fromMerkleProofSteps(steps []*models.MerkleProofStep) []merkle.ProofStep {
+	proof := make([]merkle.ProofStep, 0, len(steps))
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		proof = append(proof, merkle.ProofStep{Hash: step.Hash, Left: step.Left})
+	}
+	return proof
+}
+
+func (s *blockchainService) ReleaseStaleJobs(ctx context.Context) error {
+	n, err := s.jobRepo.ReleaseStale(ctx, time.Now().Add(-blockchainJobLockTimeout))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[BlockchainWorker] Trả lại hàng đợi %d tác vụ bị kẹt", n)
+	}
+	return nil
+}
+
+func (s *blockchainService) GetJob(ctx context.Context, id primitive.ObjectID) (*models.BlockchainJob, error) {
+	job, err := s.jobRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return cert, nil
+	if job == nil {
+		return nil, common.ErrBlockchainJobNotFound
+	}
+	scope, err := callerUniversityScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.IsZero() && job.UniversityID != scope {
+		return nil, common.ErrBlockchainJobNotFound
+	}
+	return job, nil
 }
 
-func (s *blockchainService) VerifyCertificateIntegrity(ctx context.Context, certID string) (bool, string, *models.CertificateOnChain, error) {
-	onChainCert, err := s.fabricClient.GetCertificateByID(certID)
+func (s *blockchainService) SearchJobs(ctx context.Context, params models.SearchBlockchainJobParams) ([]*models.BlockchainJob, int64, error) {
+	filter := bson.M{}
+	if params.Status != "" {
+		filter["status"] = params.Status
+	}
+	scope, err := callerUniversityScope(ctx)
 	if err != nil {
-		return false, "", nil, fmt.Errorf("lỗi lấy từ blockchain: %w", err)
+		return nil, 0, err
 	}
+	if !scope.IsZero() {
+		filter["university_id"] = scope
+	}
+	return s.jobRepo.Find(ctx, filter, params.Page, params.PageSize)
+}
 
+// RetryJob đưa tác vụ thất bại trở lại hàng đợi với số lần thử mới
+func (s *blockchainService) RetryJob(ctx context.Context, id primitive.ObjectID) (*models.BlockchainJob, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.BlockchainJobFailed {
+		return nil, common.ErrBlockchainJobNotRetryable
+	}
+
+	now := time.Now()
+	job.Status = models.BlockchainJobPending
+	job.MaxAttempts = job.Attempts + blockchainJobMaxAttempts
+	job.NextAttemptAt = now
+	job.UpdatedAt = now
+	update := bson.M{
+		"$set": bson.M{
+			"status":          job.Status,
+			"max_attempts":    job.MaxAttempts,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		},
+	}
+	if err := s.jobRepo.UpdateByID(ctx, job.ID, update); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func isPermanentJobError(err error) bool {
+	return errors.Is(err, common.ErrCertificateNotFound) ||
+		errors.Is(err, common.ErrCertificateRevoked) ||
+		errors.Is(err, common.ErrCertificateNotApproved) ||
+		errors.Is(err, common.ErrCertificateBatchNotFound) ||
+		errors.Is(err, common.ErrCertificateBatchStale) ||
+		errors.Is(err, common.ErrUniversityNotFound)
+}
+
+func blockchainJobBackoff(attempt int) time.Duration {
+	d := blockchainJobBaseBackoff << (attempt - 1)
+	if d <= 0 || d > blockchainJobMaxBackoff {
+		return blockchainJobMaxBackoff
+	}
+	return d
+}
+
+func (s *blockchainService) GetCertificateFromChain(ctx context.Context, certificateID string) (*models.CertificateOnChain, error) {
+	cert, err := s.ledger.GetCertificateByID(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// VerifyCertificateIntegrity đối chiếu văn bằng trong MongoDB và file trên MinIO với sổ cái, trả về kết quả từng bước kiểm tra
+func (s *blockchainService) VerifyCertificateIntegrity(ctx context.Context, certID string) (*models.IntegrityReport, error) {
 	certificateObjID, err := primitive.ObjectIDFromHex(certID)
 	if err != nil {
-		return false, "", nil, fmt.Errorf("certID không hợp lệ: %w", err)
+		return nil, fmt.Errorf("certID không hợp lệ: %w", err)
 	}
 
 	cert, err := s.certRepo.GetCertificateByID(ctx, certificateObjID)
 	if err != nil {
-		return false, "", nil, fmt.Errorf("không tìm thấy văn bằng trong MongoDB: %w", err)
+		return nil, fmt.Errorf("không tìm thấy văn bằng trong MongoDB: %w", err)
 	}
 
 	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
 	if err != nil {
-		return false, "", nil, fmt.Errorf("không tìm thấy sinh viên: %w", err)
+		return nil, fmt.Errorf("không tìm thấy sinh viên: %w", err)
 	}
 
 	faculty, err := s.facultyRepo.FindByID(ctx, cert.FacultyID)
 	if err != nil {
-		return false, "", nil, fmt.Errorf("không tìm thấy khoa: %w", err)
+		return nil, fmt.Errorf("không tìm thấy khoa: %w", err)
 	}
 
 	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
 	if err != nil {
-		return false, "", nil, fmt.Errorf("không tìm thấy trường đại học: %w", err)
+		return nil, fmt.Errorf("không tìm thấy trường đại học: %w", err)
 	}
 
 	localHash := generateCertificateHash(cert, user, faculty, university)
+	report := &models.IntegrityReport{CertificateID: certID}
+
+	if !cert.BatchID.IsZero() {
+		return s.verifyBatchedCertificate(ctx, report, cert, university, localHash)
+	}
+
+	onChainCert, err := s.ledger.GetCertificateByID(ctx, certID)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi lấy từ blockchain: %w", err)
+	}
+	report.OnChain = onChainCert
+
+	report.Add(compareCheck(models.IntegrityCheckCertHash, localHash, onChainCert.CertHash, "Dữ liệu đã bị thay đổi!"))
+	report.Add(s.checkStoredFile(ctx, cert, onChainCert.HashFile))
+	report.Add(compareCheck(models.IntegrityCheckSerialNumber, cert.SerialNumber, onChainCert.SerialNumber, "Số hiệu không khớp với blockchain"))
+	report.Add(compareCheck(models.IntegrityCheckRegNo, cert.RegNo, onChainCert.RegNo, "Số vào sổ gốc không khớp với blockchain"))
+	report.Add(compareCheck(models.IntegrityCheckIssueDate, cert.IssueDate.Format("2006-01-02"), onChainCert.DateOfIssuing, "Ngày cấp không khớp với blockchain"))
+	report.Add(revocationCheck(cert.Revoked || onChainCert.Revoked))
+	report.Add(signatureCheck(university, onChainCert.CertHash, onChainCert.UniversitySignature))
+
+	report.Finish("Dữ liệu khớp hoàn toàn với blockchain")
+	return report, nil
+}
+
+// verifyBatchedCertificate xác minh văn bằng neo theo lô: mã băm tính lại phải dẫn tới gốc Merkle đã ghi trên sổ cái.
+// Lô chỉ neo cert_hash nên file được so với hash_file trong MongoDB, các trường khác đã nằm trong cert_hash.
+func (s *blockchainService) verifyBatchedCertificate(ctx context.Context, report *models.IntegrityReport, cert *models.Certificate, university *models.University, localHash string) (*models.IntegrityReport, error) {
+	onChainBatch, err := s.ledger.GetBatchByID(ctx, cert.BatchID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("lỗi lấy từ blockchain: %w", err)
+	}
+
+	onChainCert := buildCertificateOnChain(cert, university.UniversityCode)
+	onChainCert.Revoked = cert.Revoked
+	onChainCert.BatchID = onChainBatch.BatchID
+	onChainCert.MerkleRoot = onChainBatch.MerkleRoot
+	report.OnChain = &onChainCert
+
+	proofCheck := &models.IntegrityCheck{
+		Name:     models.IntegrityCheckMerkleProof,
+		Passed:   merkle.Verify(localHash, fromMerkleProofSteps(cert.MerkleProof), onChainBatch.MerkleRoot),
+		Expected: localHash,
+		Actual:   onChainBatch.MerkleRoot,
+	}
+	if !proofCheck.Passed {
+		proofCheck.Message = "Dữ liệu đã bị thay đổi!"
+	}
+	report.Add(proofCheck)
+	// Lá Merkle chỉ là cert_hash nên file chỉ đối chiếu được với mã băm trong MongoDB, không được sổ cái bảo đảm
+	fileCheck := s.checkStoredFile(ctx, cert, cert.HashFile)
+	if fileCheck.Passed {
+		fileCheck.Message = "File khớp với mã băm trong MongoDB, lô trên blockchain không neo mã băm file"
+	}
+	report.Add(fileCheck)
+	for _, name := range []string{models.IntegrityCheckSerialNumber, models.IntegrityCheckRegNo, models.IntegrityCheckIssueDate} {
+		report.Add(&models.IntegrityCheck{Name: name, Skipped: true, Message: "Đã được kiểm tra qua cert_hash của lô"})
+	}
+	report.Add(revocationCheck(cert.Revoked))
+	report.Add(signatureCheck(university, localHash, cert.Signature))
+
+	report.Finish("Dữ liệu khớp với gốc Merkle của lô trên blockchain")
+	return report, nil
+}
+
+// checkStoredFile tải file văn bằng từ MinIO và so mã băm với expectedHash
+func (s *blockchainService) checkStoredFile(ctx context.Context, cert *models.Certificate, expectedHash string) *models.IntegrityCheck {
+	check := &models.IntegrityCheck{Name: models.IntegrityCheckFileHash, Actual: expectedHash}
+	if cert.Path == "" {
+		check.Skipped = true
+		check.Message = "Văn bằng chưa có file"
+		return check
+	}
+	if expectedHash == "" {
+		check.Skipped = true
+		check.Message = "Blockchain chưa có mã băm file"
+		return check
+	}
+
+	fileData, err := s.minioClient.DownloadFile(ctx, cert.Path)
+	if err != nil {
+		check.Message = fmt.Sprintf("Không tải được file văn bằng: %v", err)
+		return check
+	}
+	sum := sha256.Sum256(fileData)
+	check.Expected = hex.EncodeToString(sum[:])
+	check.Passed = check.Expected == expectedHash
+	if !check.Passed {
+		check.Message = "File văn bằng không khớp với blockchain"
+	}
+	return check
+}
+
+func compareCheck(name, expected, actual, failMessage string) *models.IntegrityCheck {
+	check := &models.IntegrityCheck{
+		Name:     name,
+		Passed:   expected == actual,
+		Expected: expected,
+		Actual:   actual,
+	}
+	if !check.Passed {
+		check.Message = failMessage
+	}
+	return check
+}
+
+func revocationCheck(revoked bool) *models.IntegrityCheck {
+	check := &models.IntegrityCheck{Name: models.IntegrityCheckRevocation, Passed: !revoked}
+	if revoked {
+		check.Message = "Văn bằng đã bị thu hồi"
+	}
+	return check
+}
+
+func signatureCheck(university *models.University, certHash, signature string) *models.IntegrityCheck {
+	check := &models.IntegrityCheck{Name: models.IntegrityCheckSignature}
+	if signature == "" {
+		check.Skipped = true
+		check.Message = "Văn bằng chưa được ký số"
+		return check
+	}
+	if msg := checkUniversitySignature(university, certHash, signature); msg != "" {
+		check.Message = msg
+		return check
+	}
+	check.Passed = true
+	return check
+}
 
-	if localHash != onChainCert.CertHash {
-		return false, "Dữ liệu đã bị thay đổi!", onChainCert, nil
+// checkUniversitySignature trả về thông báo lỗi nếu chữ ký của trường trên mã băm không hợp lệ, chuỗi rỗng nếu hợp lệ hoặc chưa ký
+func checkUniversitySignature(university *models.University, certHash, signature string) string {
+	if signature == "" {
+		return ""
+	}
+	if university.SigningCertificate == "" {
+		return "Trường chưa đăng ký khóa công khai để xác minh chữ ký"
+	}
+	if err := signing.VerifyHash(university.SigningCertificate, certHash, signature); err != nil {
+		return "Chữ ký số của trường không hợp lệ"
+	}
+	return ""
+}
+
+// PublicVerify xác minh văn bằng theo mã trường + số hiệu hoặc token trong mã QR, chỉ trả về thông tin không định danh
+func (s *blockchainService) PublicVerify(ctx context.Context, universityCode, serialNumber, token string) (*models.PublicVerifyResult, error) {
+	var cert *models.Certificate
+	if token != "" {
+		certID, err := utils.ParseVerifyToken(token)
+		if err != nil {
+			return nil, common.ErrInvalidToken
+		}
+		cert, err = s.certRepo.GetCertificateByID(ctx, certID)
+		if err != nil || cert == nil {
+			return nil, common.ErrCertificateNotFound
+		}
+	} else {
+		university, err := s.universityRepo.FindByCode(ctx, universityCode)
+		if err != nil {
+			return nil, err
+		}
+		if university == nil {
+			return nil, common.ErrUniversityNotFound
+		}
+		cert, err = s.certRepo.FindBySerialAndUniversity(ctx, serialNumber, university.ID)
+		if err != nil || cert == nil {
+			return nil, common.ErrCertificateNotFound
+		}
+	}
+
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+
+	result := &models.PublicVerifyResult{
+		CertificateType: cert.CertificateType,
+		CertificateName: cert.Name,
+		SerialNumber:    cert.SerialNumber,
+		IssueDate:       cert.IssueDate.Format("2006-01-02"),
+		UniversityCode:  university.UniversityCode,
+		UniversityName:  university.UniversityName,
+	}
+
+	if cert.BlockchainTxID == "" {
+		result.Status = models.PublicVerifyNotAnchored
+		result.Message = "Văn bằng chưa được ghi lên blockchain"
+		return result, nil
+	}
+
+	report, err := s.VerifyCertificateIntegrity(ctx, cert.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	result.Valid = report.Valid
+	result.Message = report.Message
+	switch {
+	case report.Valid:
+		result.Status = models.PublicVerifyValid
+	case report.Revoked:
+		result.Status = models.PublicVerifyRevoked
+	default:
+		result.Status = models.PublicVerifyTampered
+	}
+	return result, nil
+}
+
+// VerifyCertificateFile xác minh bản sao văn bằng bằng cách so mã băm file với hash_file đã ghi trên blockchain
+func (s *blockchainService) VerifyCertificateFile(ctx context.Context, fileData []byte) (*models.PublicVerifyResult, error) {
+	sum := sha256.Sum256(fileData)
+	fileHash := hex.EncodeToString(sum[:])
+
+	cert, err := s.certRepo.FindByHashFile(ctx, fileHash)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, common.ErrCertificateNotFound
 	}
 
-	return true, "Dữ liệu khớp hoàn toàn với blockchain", onChainCert, nil
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+
+	result := &models.PublicVerifyResult{
+		CertificateType: cert.CertificateType,
+		CertificateName: cert.Name,
+		SerialNumber:    cert.SerialNumber,
+		IssueDate:       cert.IssueDate.Format("2006-01-02"),
+		UniversityCode:  university.UniversityCode,
+		UniversityName:  university.UniversityName,
+		FileHash:        fileHash,
+	}
+
+	if cert.BlockchainTxID == "" {
+		result.Status = models.PublicVerifyNotAnchored
+		result.Message = "Văn bằng chưa được ghi lên blockchain"
+		return result, nil
+	}
+
+	// Lô Merkle chỉ neo cert_hash: dữ liệu văn bằng được xác minh qua đường chứng minh,
+	// còn file chỉ khớp với mã băm lưu trong MongoDB nên không báo là được blockchain bảo đảm
+	if !cert.BatchID.IsZero() {
+		report, err := s.VerifyCertificateIntegrity(ctx, cert.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		fileLedgerBacked := false
+		result.FileLedgerBacked = &fileLedgerBacked
+		result.Valid = report.Valid
+		result.Message = report.Message
+		switch {
+		case report.Valid:
+			result.Status = models.PublicVerifyValid
+			result.Message = "Dữ liệu văn bằng khớp với blockchain; file chỉ được đối chiếu với mã băm lưu trong hệ thống vì lô không neo mã băm file"
+		case report.Revoked:
+			result.Status = models.PublicVerifyRevoked
+		default:
+			result.Status = models.PublicVerifyTampered
+		}
+		return result, nil
+	}
+
+	onChainCert, err := s.ledger.GetCertificateByID(ctx, cert.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("lỗi lấy từ blockchain: %w", err)
+	}
+	fileLedgerBacked := true
+	result.FileLedgerBacked = &fileLedgerBacked
+
+	switch {
+	case onChainCert.HashFile != fileHash:
+		result.Status = models.PublicVerifyTampered
+		result.Message = "File không khớp với bản ghi trên blockchain"
+	case onChainCert.Revoked || cert.Revoked:
+		result.Status = models.PublicVerifyRevoked
+		result.Message = "Văn bằng đã bị thu hồi"
+	default:
+		result.Status = models.PublicVerifyValid
+		result.Valid = true
+		result.Message = "File là bản gốc, chưa bị chỉnh sửa"
+	}
+	return result, nil
+}
+
+// VerifyDisclosure kiểm tra từng trường được công bố dẫn tới gốc các trường đã ghi trên sổ cái, không cần đọc MongoDB
+func (s *blockchainService) VerifyDisclosure(ctx context.Context, req *models.VerifyDisclosureRequest) (*models.DisclosureVerifyResult, error) {
+	onChain, err := s.ledger.GetCertificateByID(ctx, req.CertificateID)
+	if err != nil {
+		return nil, common.ErrCertificateNotFound
+	}
+
+	result := &models.DisclosureVerifyResult{
+		CertificateID: req.CertificateID,
+		Revoked:       onChain.Revoked,
+		FieldsRoot:    onChain.FieldsRoot,
+		Fields:        make([]*models.DisclosedFieldResult, 0, len(req.Fields)),
+	}
+	if onChain.FieldsRoot == "" {
+		result.Message = "Văn bằng chưa ghi cam kết các trường lên blockchain"
+		return result, nil
+	}
+
+	allValid := req.FieldsRoot == "" || req.FieldsRoot == onChain.FieldsRoot
+	for _, field := range req.Fields {
+		fieldResult := &models.DisclosedFieldResult{Name: field.Name, Value: field.Value}
+		if models.IsDisclosureField(field.Name) {
+			if leaf, err := certificateFieldLeaf(field.Name, field.Value, field.Salt); err == nil {
+				fieldResult.Valid = merkle.Verify(leaf, fromMerkleProofSteps(field.Proof), onChain.FieldsRoot)
+			}
+		}
+		allValid = allValid && fieldResult.Valid
+		result.Fields = append(result.Fields, fieldResult)
+	}
+
+	switch {
+	case !allValid:
+		result.Message = "Có trường không khớp với cam kết trên blockchain"
+	case onChain.Revoked:
+		result.Message = "Văn bằng đã bị thu hồi"
+	default:
+		result.Valid = true
+		result.Message = "Các trường được công bố khớp với blockchain"
+	}
+	return result, nil
+}
+
+func buildCertificateOnChain(cert *models.Certificate, universityCode string) models.CertificateOnChain {
+	return models.CertificateOnChain{
+		CertID:              cert.ID.Hex(),
+		UniversityCode:      universityCode,
+		CertHash:            cert.CertHash,
+		HashFile:            cert.HashFile,
+		UniversitySignature: cert.Signature,
+		DateOfIssuing:       cert.IssueDate.Format("2006-01-02"),
+		SerialNumber:        cert.SerialNumber,
+		RegNo:               cert.RegNo,
+		Version:             cert.CurrentVersion(),
+		HashVersion:         cert.CurrentHashVersion(),
+		UpdatedDate:         cert.UpdatedAt.Format("2006-01-02 15:04:05"),
+		FieldsRoot:          cert.FieldsRoot(),
+	}
 }
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// BlockchainWorker định kỳ lấy các tác vụ trong outbox và ghi lên blockchain
+type BlockchainWorker struct {
+	blockchainSvc BlockchainService
+	interval      time.Duration
+}
+
+func NewBlockchainWorker(blockchainSvc BlockchainService, interval time.Duration) *BlockchainWorker {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &BlockchainWorker{
+		blockchainSvc: blockchainSvc,
+		interval:      interval,
+	}
+}
+
+// Run chạy đến khi ctx bị hủy
+func (w *BlockchainWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *BlockchainWorker) drain(ctx context.Context) {
+	if err := w.blockchainSvc.ReleaseStaleJobs(ctx); err != nil {
+		log.Printf("[BlockchainWorker] ReleaseStaleJobs error: %v", err)
+	}
+	for ctx.Err() == nil {
+		processed, err := w.blockchainSvc.ProcessNextJob(ctx)
+		if err != nil {
+			log.Printf("[BlockchainWorker] ProcessNextJob error: %v", err)
+		}
+		if !processed {
+			return
+		}
+	}
+}
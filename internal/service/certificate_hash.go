@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+)
+
+const certificateHashSaltSize = 32
+
+// generateCertificateHash tính cert_hash theo phiên bản lược đồ đã lưu trong văn bằng,
+// văn bằng cũ chưa có hash_version vẫn dùng lược đồ v1 để tiếp tục xác minh được
+func generateCertificateHash(cert *models.Certificate, user *models.User, faculty *models.Faculty, university *models.University) string {
+	switch cert.CurrentHashVersion() {
+	case models.CertificateHashV1:
+		return generateCertificateHashV1(cert, user, faculty, university)
+	case models.CertificateHashV2:
+		return generateCertificateHashV2(cert, user, faculty, university)
+	default:
+		// Phiên bản không xác định thì trả rỗng để mọi phép so sánh đều thất bại
+		return ""
+	}
+}
+
+// assignCertificateHashScheme gán lược đồ băm hiện hành và muối ngẫu nhiên mới cho văn bằng
+func assignCertificateHashScheme(cert *models.Certificate) error {
+	salt := make([]byte, certificateHashSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("không thể sinh muối cho mã băm văn bằng: %w", err)
+	}
+	cert.HashVersion = models.CurrentCertificateHashVersion
+	cert.HashSalt = hex.EncodeToString(salt)
+	return nil
+}
+
+// v1: các trường nối bằng dấu |, GPA định dạng %f, không có muối
+func generateCertificateHashV1(cert *models.Certificate, user *models.User, faculty *models.Faculty, university *models.University) string {
+	data := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%f|%s",
+		user.FullName,                       // họ tên
+		user.DateOfBirth,                    // ngày sinh
+		cert.StudentCode,                    // mã sv
+		user.CitizenIdNumber,                // căn cước công dân
+		user.Email,                          // email
+		university.UniversityCode,           // mã trường
+		faculty.FacultyCode,                 // mã khoa
+		cert.Major,                          // ngành đào tạo
+		cert.GPA,                            // gpa
+		cert.IssueDate.Format("2006-01-02"), // ngày cấp
+	)
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// canonicalCertificateV2 là dữ liệu được cam kết ở lược đồ v2.
+// Các trường xếp theo thứ tự bảng chữ cái của khóa JSON và đều là chuỗi để bản mã hóa là duy nhất.
+type canonicalCertificateV2 struct {
+	CitizenID      string `json:"citizen_id"`
+	Course         string `json:"course"`
+	DateOfBirth    string `json:"date_of_birth"`
+	EducationType  string `json:"education_type"`
+	Email          string `json:"email"`
+	FacultyCode    string `json:"faculty_code"`
+	FullName       string `json:"full_name"`
+	GPA            string `json:"gpa"`
+	GraduationRank string `json:"graduation_rank"`
+	HashVersion    int    `json:"hash_version"`
+	IssueDate      string `json:"issue_date"`
+	Major          string `json:"major"`
+	Name           string `json:"name"`
+	RegNo          string `json:"registration_number"`
+	SerialNumber   string `json:"serial_number"`
+	StudentCode    string `json:"student_code"`
+	UniversityCode string `json:"university_code"`
+}
+
+// v2: JSON chuẩn hóa có hash_version, cam kết bằng HMAC-SHA256 với muối riêng của từng văn bằng
+// để không thể dò ngược CCCD, ngày sinh, email từ mã băm công khai trên sổ cái
+func generateCertificateHashV2(cert *models.Certificate, user *models.User, faculty *models.Faculty, university *models.University) string {
+	salt, err := hex.DecodeString(cert.HashSalt)
+	if err != nil || len(salt) == 0 {
+		return ""
+	}
+	data, err := canonicalJSON(canonicalCertificateV2{
+		CitizenID:      user.CitizenIdNumber,
+		Course:         cert.Course,
+		DateOfBirth:    user.DateOfBirth,
+		EducationType:  cert.EducationType,
+		Email:          user.Email,
+		FacultyCode:    faculty.FacultyCode,
+		FullName:       user.FullName,
+		GPA:            strconv.FormatFloat(cert.GPA, 'f', -1, 64),
+		GraduationRank: cert.GraduationRank,
+		HashVersion:    models.CertificateHashV2,
+		IssueDate:      cert.IssueDate.Format("2006-01-02"),
+		Major:          cert.Major,
+		Name:           cert.Name,
+		RegNo:          cert.RegNo,
+		SerialNumber:   cert.SerialNumber,
+		StudentCode:    cert.StudentCode,
+		UniversityCode: university.UniversityCode,
+	})
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalJSON mã hóa không escape HTML và bỏ ký tự xuống dòng cuối
+func canonicalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+)
+
+// Giá trị mong đợi tính ngoài Go từ chuỗi ghi trong test, đổi thứ tự trường hay cách mã hóa sẽ làm văn bằng đã cấp không còn xác minh được
+const (
+	goldenHashV1      = "b1ed83c86e2106625a8d906f9668676ccad31fa76b47ddd79d30512adbbecae2"
+	goldenHashV2      = "6e0a2f869592cb75c86ef91f30466dbb35da67755b9af948bb431b817261e729"
+	goldenCanonicalV2 = `{"citizen_id":"001200000001","course":"K50","date_of_birth":"2000-01-15","education_type":"Chính quy","email":"a.nguyen@example.com","faculty_code":"CNTT","full_name":"Nguyễn Văn A","gpa":"3.25","graduation_rank":"Giỏi","hash_version":2,"issue_date":"2024-06-30","major":"An toàn thông tin","name":"Bằng kỹ sư <A&B>","registration_number":"VB-001","serial_number":"S001","student_code":"CT050101","university_code":"KMA"}`
+	goldenHashSaltHex = "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+)
+
+func goldenHashFixture(hashVersion int, salt string) (*models.Certificate, *models.User, *models.Faculty, *models.University) {
+	cert := &models.Certificate{
+		StudentCode:    "CT050101",
+		Name:           "Bằng kỹ sư <A&B>",
+		Major:          "An toàn thông tin",
+		Course:         "K50",
+		EducationType:  "Chính quy",
+		GraduationRank: "Giỏi",
+		GPA:            3.25,
+		RegNo:          "VB-001",
+		SerialNumber:   "S001",
+		IssueDate:      time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+		HashVersion:    hashVersion,
+		HashSalt:       salt,
+	}
+	user := &models.User{
+		FullName:        "Nguyễn Văn A",
+		DateOfBirth:     "2000-01-15",
+		CitizenIdNumber: "001200000001",
+		Email:           "a.nguyen@example.com",
+	}
+	return cert, user, &models.Faculty{FacultyCode: "CNTT"}, &models.University{UniversityCode: "KMA"}
+}
+
+func TestGenerateCertificateHashGoldenVectors(t *testing.T) {
+	tests := []struct {
+		name        string
+		hashVersion int
+		salt        string
+		want        string
+	}{
+		{name: "văn bằng cũ chưa có hash_version dùng v1", hashVersion: 0, want: goldenHashV1},
+		{name: "v1 bỏ qua muối", hashVersion: models.CertificateHashV1, salt: goldenHashSaltHex, want: goldenHashV1},
+		{name: "v2", hashVersion: models.CertificateHashV2, salt: goldenHashSaltHex, want: goldenHashV2},
+		{name: "v2 thiếu muối", hashVersion: models.CertificateHashV2, want: ""},
+		{name: "v2 muối không phải hex", hashVersion: models.CertificateHashV2, salt: "zz", want: ""},
+		{name: "phiên bản không xác định", hashVersion: 3, salt: goldenHashSaltHex, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, user, faculty, university := goldenHashFixture(tt.hashVersion, tt.salt)
+			if got := generateCertificateHash(cert, user, faculty, university); got != tt.want {
+				t.Fatalf("cert_hash = %q, muốn %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalCertificateV2(t *testing.T) {
+	data, err := canonicalJSON(canonicalCertificateV2{
+		CitizenID:      "001200000001",
+		Course:         "K50",
+		DateOfBirth:    "2000-01-15",
+		EducationType:  "Chính quy",
+		Email:          "a.nguyen@example.com",
+		FacultyCode:    "CNTT",
+		FullName:       "Nguyễn Văn A",
+		GPA:            "3.25",
+		GraduationRank: "Giỏi",
+		HashVersion:    models.CertificateHashV2,
+		IssueDate:      "2024-06-30",
+		Major:          "An toàn thông tin",
+		Name:           "Bằng kỹ sư <A&B>",
+		RegNo:          "VB-001",
+		SerialNumber:   "S001",
+		StudentCode:    "CT050101",
+		UniversityCode: "KMA",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != goldenCanonicalV2 {
+		t.Fatalf("JSON chuẩn hóa = %s\nmuốn %s", data, goldenCanonicalV2)
+	}
+}
+
+func TestGenerateCertificateHashV2DependsOnSalt(t *testing.T) {
+	cert, user, faculty, university := goldenHashFixture(models.CertificateHashV2, goldenHashSaltHex)
+	if err := assignCertificateHashScheme(cert); err != nil {
+		t.Fatal(err)
+	}
+	if cert.HashVersion != models.CurrentCertificateHashVersion {
+		t.Fatalf("hash_version = %d, muốn %d", cert.HashVersion, models.CurrentCertificateHashVersion)
+	}
+	if salt, err := hex.DecodeString(cert.HashSalt); err != nil || len(salt) != certificateHashSaltSize {
+		t.Fatalf("muối = %q, lỗi = %v", cert.HashSalt, err)
+	}
+	if got := generateCertificateHash(cert, user, faculty, university); got == goldenHashV2 || got == "" {
+		t.Fatalf("cert_hash với muối mới = %q, muốn khác mã băm của muối cũ", got)
+	}
+}
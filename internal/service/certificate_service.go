@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -14,7 +15,10 @@ import (
 	"github.com/vnkmasc/Kmasc/app/backend/internal/mapper"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
 	"github.com/vnkmasc/Kmasc/app/backend/pkg/database"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/diploma"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/signing"
 	"github.com/vnkmasc/Kmasc/app/backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -26,36 +30,63 @@ type CertificateService interface {
 	DeleteCertificateByID(ctx context.Context, id primitive.ObjectID) error
 	DeleteCertificate(ctx context.Context, id primitive.ObjectID) error
 	UploadCertificateFile(ctx context.Context, certificateID primitive.ObjectID, fileData []byte, filename string, isDegree bool, certificateName string) (string, error)
-	GetCertificateByID(ctx context.Context, id primitive.ObjectID) (*models.CertificateResponse, error)
+	GetCertificateByID(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateResponse, error)
 	GetCertificateBySerialAndUniversity(ctx context.Context, serial string, universityID primitive.ObjectID) (*models.Certificate, error)
 	GetCertificateByUserID(ctx context.Context, userID primitive.ObjectID) (*models.CertificateResponse, error)
 	GetCertificatesByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.CertificateResponse, error)
 	CreateCertificate(ctx context.Context, claims *utils.CustomClaims, req *models.CreateCertificateRequest) error
 	GetSimpleCertificatesByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.CertificateSimpleResponse, error)
 	SearchCertificates(ctx context.Context, params models.SearchCertificateParams) ([]*models.CertificateResponse, int64, error)
+	RevokeCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.RevokeCertificateRequest) error
+	AmendCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.AmendCertificateRequest) (*models.CertificateResponse, error)
+	GetCertificateVersions(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) ([]*models.CertificateVersionResponse, error)
+	SignCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateResponse, error)
+	VerifyCertificateSignature(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (bool, error)
+	GenerateCertificateFile(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (string, error)
+	TransitionCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.CertificateTransitionRequest) (*models.CertificateStatusResponse, error)
+	GetCertificateStatusHistory(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateStatusResponse, error)
+	CreateDisclosure(ctx context.Context, userID, id primitive.ObjectID, fields []string) (*models.SelectiveDisclosure, error)
 }
 
 type certificateService struct {
 	certificateRepo repository.CertificateRepository
+	versionRepo     repository.CertificateVersionRepository
+	jobRepo         repository.BlockchainJobRepository
+	certTypeRepo    repository.CertificateTypeRepository
 	userRepo        repository.UserRepository
 	facultyRepo     repository.FacultyRepository
 	universityRepo  repository.UniversityRepository
 	minioClient     *database.MinioClient
+	ledger          blockchain.Ledger
+	keyStore        *signing.KeyStore
+	renderer        *diploma.Renderer
 }
 
 func NewCertificateService(
 	certificateRepo repository.CertificateRepository,
+	versionRepo repository.CertificateVersionRepository,
+	jobRepo repository.BlockchainJobRepository,
+	certTypeRepo repository.CertificateTypeRepository,
 	userRepo repository.UserRepository,
 	facultyRepo repository.FacultyRepository,
 	universityRepo repository.UniversityRepository,
 	minioClient *database.MinioClient,
+	ledger blockchain.Ledger,
+	keyStore *signing.KeyStore,
+	renderer *diploma.Renderer,
 ) CertificateService {
 	return &certificateService{
 		certificateRepo: certificateRepo,
+		versionRepo:     versionRepo,
+		jobRepo:         jobRepo,
+		certTypeRepo:    certTypeRepo,
 		userRepo:        userRepo,
 		facultyRepo:     facultyRepo,
 		universityRepo:  universityRepo,
 		minioClient:     minioClient,
+		ledger:          ledger,
+		keyStore:        keyStore,
+		renderer:        renderer,
 	}
 }
 
@@ -73,8 +104,15 @@ func (s *certificateService) CreateCertificate(ctx context.Context, claims *util
 		return fmt.Errorf("người dùng chưa được gán khoa")
 	}
 
+	certType, err := resolveCertificateType(ctx, s.certTypeRepo, universityID, req.CertificateType)
+	if err != nil {
+		return err
+	}
+	// Văn bằng lưu tên hiển thị của loại để tương thích với dữ liệu đã cấp
+	req.CertificateType = certType.DisplayName
+
 	// Validate đầu vào
-	if err := s.validateDegreeRequest(ctx, req, universityID); err != nil {
+	if err := s.validateDegreeRequest(ctx, req, universityID, certType); err != nil {
 		return err
 	}
 	if err := s.checkDuplicateSerialAndRegNo(ctx, universityID, req); err != nil {
@@ -92,7 +130,14 @@ func (s *certificateService) CreateCertificate(ctx context.Context, claims *util
 
 	// Khởi tạo object văn bằng
 	cert := models.NewCertificate(req, user, universityID)
+	if err := assignCertificateHashScheme(cert); err != nil {
+		return err
+	}
 	cert.CertHash = generateCertificateHash(cert, user, faculty, university)
+	cert.FieldCommitment, err = buildFieldCommitment(nil, certificateFieldValues(cert, user, faculty, university))
+	if err != nil {
+		return err
+	}
 
 	// Lưu vào Mongo
 	if err := s.certificateRepo.CreateCertificate(ctx, cert); err != nil {
@@ -100,7 +145,7 @@ func (s *certificateService) CreateCertificate(ctx context.Context, claims *util
 	}
 
 	// Cập nhật trạng thái sinh viên nếu cần
-	s.updateUserStatusIfNeeded(ctx, user, req.CertificateType)
+	s.updateUserStatusIfNeeded(ctx, user, certType)
 
 	return nil
 }
@@ -131,18 +176,8 @@ func (s *certificateService) checkDuplicateSerialAndRegNo(
 	return nil
 }
 
-func (s *certificateService) updateUserStatusIfNeeded(ctx context.Context, user *models.User, certType string) {
-	var newStatus int
-	switch strings.TrimSpace(certType) {
-	case "Cử nhân":
-		newStatus = 1
-	case "Kỹ sư":
-		newStatus = 2
-	case "Thạc sĩ":
-		newStatus = 3
-	case "Tiến sĩ":
-		newStatus = 4
-	}
+func (s *certificateService) updateUserStatusIfNeeded(ctx context.Context, user *models.User, certType *models.CertificateType) {
+	newStatus := certType.StudentStatus
 	currentStatus, _ := strconv.Atoi(fmt.Sprintf("%v", user.Status))
 	if newStatus != 0 && currentStatus != newStatus {
 		update := bson.M{
@@ -155,37 +190,18 @@ func (s *certificateService) updateUserStatusIfNeeded(ctx context.Context, user
 	}
 }
 
-func generateCertificateHash(cert *models.Certificate, user *models.User, faculty *models.Faculty, university *models.University) string {
-	data := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%f|%s",
-		user.FullName,                       // họ tên
-		user.DateOfBirth,                    // ngày sinh
-		cert.StudentCode,                    // mã sv
-		user.CitizenIdNumber,                // căn cước công dân
-		user.Email,                          // email
-		university.UniversityCode,           // mã trường
-		faculty.FacultyCode,                 // mã khoa
-		cert.Major,                          // ngành đào tạo
-		cert.GPA,                            // gpa
-		cert.IssueDate.Format("2006-01-02"), // ngày cấp
-	)
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:])
-}
-
-func (s *certificateService) validateDegreeRequest(ctx context.Context, req *models.CreateCertificateRequest, universityID primitive.ObjectID) error {
-	if req.CertificateType == "" || req.SerialNumber == "" || req.RegNo == "" || req.IssueDate.IsZero() {
-		return common.ErrMissingRequiredFieldsForDegree
-	}
-
-	singleDegreeTypes := map[string]bool{
-		"Cử nhân": true,
-		"Thạc sĩ": true,
-		"Tiến sĩ": true,
-		"Kỹ sư":   true,
+func (s *certificateService) validateDegreeRequest(ctx context.Context, req *models.CreateCertificateRequest, universityID primitive.ObjectID, certType *models.CertificateType) error {
+	for _, field := range certType.RequiredFields {
+		if isCertificateFieldEmpty(req, field) {
+			if certType.IsDegree {
+				return common.ErrMissingRequiredFieldsForDegree
+			}
+			return common.ErrMissingRequiredFieldsForCertificate
+		}
 	}
 
-	if singleDegreeTypes[req.CertificateType] {
-		alreadyIssued, err := s.certificateRepo.ExistsDegreeByStudentCodeAndType(ctx, req.StudentCode, universityID, req.CertificateType)
+	if certType.IsDegree {
+		alreadyIssued, err := s.certificateRepo.ExistsDegreeByStudentCodeAndType(ctx, req.StudentCode, universityID, certType.DisplayName)
 		if err != nil {
 			return err
 		}
@@ -197,6 +213,26 @@ func (s *certificateService) validateDegreeRequest(ctx context.Context, req *mod
 	return nil
 }
 
+func isCertificateFieldEmpty(req *models.CreateCertificateRequest, field string) bool {
+	switch field {
+	case models.CertificateFieldSerialNumber:
+		return strings.TrimSpace(req.SerialNumber) == ""
+	case models.CertificateFieldRegNo:
+		return strings.TrimSpace(req.RegNo) == ""
+	case models.CertificateFieldIssueDate:
+		return req.IssueDate.IsZero()
+	case models.CertificateFieldName:
+		return strings.TrimSpace(req.Name) == ""
+	case models.CertificateFieldGPA:
+		return req.GPA == 0
+	case models.CertificateFieldGraduationRank:
+		return strings.TrimSpace(req.GraduationRank) == ""
+	case models.CertificateFieldEducationType:
+		return strings.TrimSpace(req.EducationType) == ""
+	}
+	return false
+}
+
 func (s *certificateService) GetCertificateByStudentCodeAndNameAndUniversity(ctx context.Context, studentCode, name string, universityID primitive.ObjectID) (*models.Certificate, error) {
 	return s.certificateRepo.FindCertificateByStudentCodeAndName(ctx, studentCode, name, universityID)
 }
@@ -248,7 +284,7 @@ func (s *certificateService) GetAllCertificates(ctx context.Context) ([]*models.
 	return responses, nil
 }
 
-func (s *certificateService) GetCertificateByID(ctx context.Context, id primitive.ObjectID) (*models.CertificateResponse, error) {
+func (s *certificateService) GetCertificateByID(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateResponse, error) {
 	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -256,6 +292,9 @@ func (s *certificateService) GetCertificateByID(ctx context.Context, id primitiv
 	if cert == nil {
 		return nil, common.ErrCertificateNotFound
 	}
+	if err := checkCertificateViewer(claims, cert); err != nil {
+		return nil, err
+	}
 
 	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
 	if err != nil || user == nil {
@@ -383,6 +422,8 @@ func (s *certificateService) GetCertificateByUserID(ctx context.Context, userID
 		UniversityCode:  university.UniversityCode,
 		UniversityName:  university.UniversityName,
 		Signed:          cert.Signed,
+		Revoked:         cert.Revoked,
+		Revocation:      cert.Revocation,
 		CreatedAt:       cert.CreatedAt,
 		UpdatedAt:       cert.UpdatedAt,
 	}, nil
@@ -407,7 +448,12 @@ func (s *certificateService) SearchCertificates(ctx context.Context, params mode
 		filter["student_code"] = bson.M{"$regex": params.StudentCode, "$options": "i"}
 	}
 	if params.CertificateType != "" {
-		filter["certificate_type"] = bson.M{"$regex": params.CertificateType, "$options": "i"}
+		// Lọc chính xác nếu là mã/tên trong danh mục, ngược lại tìm gần đúng như trước
+		if certType, err := resolveCertificateType(ctx, s.certTypeRepo, universityID, params.CertificateType); err == nil {
+			filter["certificate_type"] = certType.DisplayName
+		} else {
+			filter["certificate_type"] = bson.M{"$regex": params.CertificateType, "$options": "i"}
+		}
 	}
 	if params.Signed != nil {
 		filter["signed"] = *params.Signed
@@ -494,6 +540,8 @@ func (s *certificateService) GetCertificatesByUserID(ctx context.Context, userID
 			UniversityCode:  university.UniversityCode,
 			UniversityName:  university.UniversityName,
 			Signed:          cert.Signed,
+			Revoked:         cert.Revoked,
+			Revocation:      cert.Revocation,
 			CreatedAt:       cert.CreatedAt,
 			UpdatedAt:       cert.UpdatedAt,
 		}
@@ -507,6 +555,807 @@ func (s *certificateService) DeleteCertificateByID(ctx context.Context, id primi
 	return s.certificateRepo.DeleteCertificateByID(ctx, id)
 }
 
+func (s *certificateService) RevokeCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.RevokeCertificateRequest) error {
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return common.ErrInvalidToken
+	}
+	accountID, err := primitive.ObjectIDFromHex(claims.AccountID)
+	if err != nil {
+		return common.ErrInvalidToken
+	}
+
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return common.ErrCertificateNotFound
+	}
+	if claims.Role != common.RoleAdmin && cert.UniversityID != universityID {
+		return common.ErrCertificateAccessDenied
+	}
+	if cert.Revoked {
+		return common.ErrCertificateRevoked
+	}
+
+	now := time.Now()
+	revocation := &models.CertificateRevocation{
+		ReasonCode:     req.ReasonCode,
+		Reason:         strings.TrimSpace(req.Reason),
+		DecisionNumber: strings.TrimSpace(req.DecisionNumber),
+		RevokedBy:      accountID,
+		RevokedAt:      now,
+	}
+
+	// Văn bằng đã ghi lên blockchain thì phải thu hồi trên sổ cái trước khi cập nhật MongoDB
+	if cert.BlockchainTxID != "" {
+		ledger, universityCode, err := universityLedger(ctx, s.ledger, s.universityRepo, cert.UniversityID)
+		if err != nil {
+			return err
+		}
+		if !cert.BatchID.IsZero() {
+			if err := s.detachCertificateFromBatch(ctx, ledger, universityCode, cert); err != nil {
+				return err
+			}
+		}
+		txID, err := ledger.RevokeCertificate(ctx, cert.ID.Hex(), revocation.ReasonCode, revocation.DecisionNumber, now.Format("2006-01-02"))
+		if err != nil {
+			return fmt.Errorf("không thể thu hồi văn bằng trên blockchain: %w", err)
+		}
+		revocation.TxID = txID
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"revoked":    true,
+			"revocation": revocation,
+			"updated_at": now,
+		},
+	}
+	return s.certificateRepo.UpdateCertificateByID(ctx, id, update)
+}
+
+// detachCertificateFromBatch tạo bản ghi riêng trên sổ cái cho văn bằng neo theo lô rồi gỡ văn bằng khỏi lô ngay,
+// trước giao dịch tiếp theo. Bản ghi riêng đã có từ lần thử trước thì không ghi lại, tránh lỗi trùng khiến không bao giờ thu hồi được.
+func (s *certificateService) detachCertificateFromBatch(ctx context.Context, ledger blockchain.Ledger, universityCode string, cert *models.Certificate) error {
+	_, err := ledger.GetCertificateByID(ctx, cert.ID.Hex())
+	switch {
+	case errors.Is(err, blockchain.ErrNotFound):
+		private, err := loadPrivateDetails(ctx, s.userRepo, s.facultyRepo, s.universityRepo, cert)
+		if err != nil {
+			return err
+		}
+		txID, err := ledger.IssueCertificate(ctx, buildCertificateOnChain(cert, universityCode), private)
+		if err != nil {
+			return fmt.Errorf("không thể ghi văn bằng lên blockchain trước khi thu hồi: %w", err)
+		}
+		if err := s.versionRepo.SetTxID(ctx, cert.ID, cert.Version, txID); err != nil {
+			return fmt.Errorf("không thể lưu tx_id của phiên bản: %w", err)
+		}
+	case err != nil:
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+	unsetCertificateBatch(cert, update)
+	if err := s.certificateRepo.UpdateCertificateByID(ctx, cert.ID, update); err != nil {
+		return fmt.Errorf("không thể gỡ văn bằng khỏi lô: %w", err)
+	}
+	return nil
+}
+
+// syncCertificateOnChain ghi phiên bản hiện tại của văn bằng lên sổ cái.
+// Văn bằng neo theo lô chỉ có gốc Merkle trên sổ cái nên lần thay đổi đầu tiên phải tạo bản ghi riêng.
+func syncCertificateOnChain(ctx context.Context, ledger blockchain.Ledger, userRepo repository.UserRepository, facultyRepo repository.FacultyRepository, universityRepo repository.UniversityRepository, cert *models.Certificate) (string, error) {
+	ledger, universityCode, err := universityLedger(ctx, ledger, universityRepo, cert.UniversityID)
+	if err != nil {
+		return "", err
+	}
+	private, err := loadPrivateDetails(ctx, userRepo, facultyRepo, universityRepo, cert)
+	if err != nil {
+		return "", err
+	}
+	if !cert.BatchID.IsZero() {
+		return ledger.IssueCertificate(ctx, buildCertificateOnChain(cert, universityCode), private)
+	}
+	return ledger.UpdateCertificate(ctx, buildCertificateOnChain(cert, universityCode), private)
+}
+
+// unsetCertificateBatch gỡ văn bằng khỏi lô Merkle sau khi đã có bản ghi riêng trên sổ cái
+func unsetCertificateBatch(cert *models.Certificate, update bson.M) {
+	if cert.BatchID.IsZero() {
+		return
+	}
+	update["$unset"] = bson.M{"batch_id": "", "merkle_proof": ""}
+	cert.BatchID = primitive.NilObjectID
+	cert.MerkleProof = nil
+}
+
+func (s *certificateService) AmendCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.AmendCertificateRequest) (*models.CertificateResponse, error) {
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return nil, common.ErrInvalidToken
+	}
+	accountID, err := primitive.ObjectIDFromHex(claims.AccountID)
+	if err != nil {
+		return nil, common.ErrInvalidToken
+	}
+
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if cert.UniversityID != universityID {
+		return nil, common.ErrCertificateAccessDenied
+	}
+	if cert.Revoked {
+		return nil, common.ErrCertificateRevoked
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return nil, common.ErrUserNotExisted
+	}
+	faculty, err := s.facultyRepo.FindByID(ctx, cert.FacultyID)
+	if err != nil || faculty == nil {
+		return nil, common.ErrFacultyNotFound
+	}
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+
+	previous := models.NewCertificateSnapshot(cert)
+	applyCertificateAmendment(cert, req)
+	if len(diffCertificateSnapshots(previous, models.NewCertificateSnapshot(cert))) == 0 {
+		return nil, common.ErrNoFieldsToUpdate
+	}
+
+	// Chỉ ghi khi văn bằng chưa bị đính chính bởi yêu cầu khác kể từ lúc đọc, văn bằng cũ chưa có trường version
+	storedVersion := cert.Version
+	filter := bson.M{"_id": cert.ID, "version": storedVersion}
+	if storedVersion == 0 {
+		filter["version"] = bson.M{"$in": []any{0, nil}}
+	}
+	originalVersion := cert.CurrentVersion()
+
+	now := time.Now()
+	cert.Version = cert.CurrentVersion() + 1
+	cert.UpdatedAt = now
+	// Mã băm phải tính lại nên chuyển luôn văn bằng cũ sang lược đồ băm hiện hành
+	if cert.CurrentHashVersion() < models.CurrentCertificateHashVersion {
+		if err := assignCertificateHashScheme(cert); err != nil {
+			return nil, err
+		}
+	}
+	cert.CertHash = generateCertificateHash(cert, user, faculty, university)
+	cert.FieldCommitment, err = buildFieldCommitment(cert.FieldCommitment, certificateFieldValues(cert, user, faculty, university))
+	if err != nil {
+		return nil, err
+	}
+	// Mã băm thay đổi nên chữ ký cũ không còn hiệu lực, trường phải ký lại phiên bản mới
+	cert.Signed = false
+	cert.Signature = ""
+	fromStatus := cert.CurrentStatus()
+	cert.Status = fromStatus
+	if fromStatus == models.CertificateStatusSigned {
+		cert.Status = models.CertificateStatusRectorApproved
+	}
+
+	version := &models.CertificateVersion{
+		ID:             primitive.NewObjectID(),
+		CertificateID:  cert.ID,
+		Version:        cert.Version,
+		Snapshot:       models.NewCertificateSnapshot(cert),
+		Reason:         strings.TrimSpace(req.Reason),
+		DecisionNumber: strings.TrimSpace(req.DecisionNumber),
+		AmendedBy:      accountID,
+		CreatedAt:      now,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":             cert.Name,
+			"major":            cert.Major,
+			"course":           cert.Course,
+			"gpa":              cert.GPA,
+			"graduation_rank":  cert.GraduationRank,
+			"education_type":   cert.EducationType,
+			"description":      cert.Description,
+			"issue_date":       cert.IssueDate,
+			"cert_hash":        cert.CertHash,
+			"hash_version":     cert.HashVersion,
+			"hash_salt":        cert.HashSalt,
+			"field_commitment": cert.FieldCommitment,
+			"version":          cert.Version,
+			"signed":           false,
+			"signature":        "",
+			"status":           cert.Status,
+			"updated_at":       now,
+		},
+		"$push": bson.M{"status_history": newStatusChange(models.CertificateActionAmend, fromStatus, cert.Status, req.Reason, claims)},
+	}
+	matched, err := s.certificateRepo.UpdateCertificateIfMatch(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return nil, common.ErrCertificateVersionConflict
+	}
+
+	// Lần đính chính đầu tiên: lưu lại bản gốc vào lịch sử
+	count, err := s.versionRepo.CountByCertificateID(ctx, cert.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		original := &models.CertificateVersion{
+			ID:            primitive.NewObjectID(),
+			CertificateID: cert.ID,
+			Version:       originalVersion,
+			Snapshot:      previous,
+			TxID:          cert.BlockchainTxID,
+			CreatedAt:     cert.CreatedAt,
+		}
+		if err := s.versionRepo.Create(ctx, original); err != nil {
+			return nil, fmt.Errorf("không thể lưu phiên bản gốc: %w", err)
+		}
+	}
+	if err := s.versionRepo.Create(ctx, version); err != nil {
+		return nil, fmt.Errorf("không thể lưu lịch sử phiên bản: %w", err)
+	}
+
+	// Văn bằng đã ghi lên blockchain thì đưa phiên bản mới vào outbox, worker cập nhật sổ cái và gắn tx_id cho phiên bản
+	if cert.BlockchainTxID != "" {
+		if err := s.enqueueCertificateSync(ctx, cert, accountID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return mapper.MapCertificateToResponse(cert, user, faculty, university), nil
+}
+
+func (s *certificateService) enqueueCertificateSync(ctx context.Context, cert *models.Certificate, createdBy primitive.ObjectID, now time.Time) error {
+	job := &models.BlockchainJob{
+		ID:            primitive.NewObjectID(),
+		Type:          models.BlockchainJobTypeSync,
+		CertificateID: cert.ID,
+		Version:       cert.Version,
+		UniversityID:  cert.UniversityID,
+		Status:        models.BlockchainJobPending,
+		MaxAttempts:   blockchainJobMaxAttempts,
+		NextAttemptAt: now,
+		CreatedBy:     createdBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return fmt.Errorf("không thể tạo tác vụ cập nhật blockchain: %w", err)
+	}
+	return nil
+}
+
+func applyCertificateAmendment(cert *models.Certificate, req *models.AmendCertificateRequest) {
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		cert.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Major != nil && strings.TrimSpace(*req.Major) != "" {
+		cert.Major = strings.TrimSpace(*req.Major)
+	}
+	if req.Course != nil && strings.TrimSpace(*req.Course) != "" {
+		cert.Course = strings.TrimSpace(*req.Course)
+	}
+	if req.GPA != nil {
+		cert.GPA = *req.GPA
+	}
+	if req.GraduationRank != nil {
+		cert.GraduationRank = strings.TrimSpace(*req.GraduationRank)
+	}
+	if req.EducationType != nil {
+		cert.EducationType = strings.TrimSpace(*req.EducationType)
+	}
+	if req.Description != nil {
+		cert.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.IssueDate != nil && !req.IssueDate.IsZero() {
+		cert.IssueDate = *req.IssueDate
+	}
+}
+
+func diffCertificateSnapshots(oldSnap, newSnap models.CertificateSnapshot) []models.FieldDiff {
+	fields := []struct {
+		name     string
+		oldValue string
+		newValue string
+	}{
+		{"name", oldSnap.Name, newSnap.Name},
+		{"major", oldSnap.Major, newSnap.Major},
+		{"course", oldSnap.Course, newSnap.Course},
+		{"gpa", strconv.FormatFloat(oldSnap.GPA, 'f', -1, 64), strconv.FormatFloat(newSnap.GPA, 'f', -1, 64)},
+		{"graduation_rank", oldSnap.GraduationRank, newSnap.GraduationRank},
+		{"education_type", oldSnap.EducationType, newSnap.EducationType},
+		{"description", oldSnap.Description, newSnap.Description},
+		{"issue_date", oldSnap.IssueDate.Format("02/01/2006"), newSnap.IssueDate.Format("02/01/2006")},
+		{"cert_hash", oldSnap.CertHash, newSnap.CertHash},
+		{"hash_file", oldSnap.HashFile, newSnap.HashFile},
+	}
+
+	diffs := []models.FieldDiff{}
+	for _, f := range fields {
+		if f.oldValue != f.newValue {
+			diffs = append(diffs, models.FieldDiff{Field: f.name, OldValue: f.oldValue, NewValue: f.newValue})
+		}
+	}
+	return diffs
+}
+
+// GetCertificateVersions trả lịch sử đính chính, chỉ cán bộ của trường cấp, sinh viên sở hữu văn bằng và quản trị hệ thống được xem
+func (s *certificateService) GetCertificateVersions(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) ([]*models.CertificateVersionResponse, error) {
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if err := checkCertificateViewer(claims, cert); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.versionRepo.FindByCertificateID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Văn bằng chưa từng được đính chính: chỉ có một phiên bản hiện tại
+	if len(versions) == 0 {
+		versions = []*models.CertificateVersion{{
+			CertificateID: cert.ID,
+			Version:       cert.CurrentVersion(),
+			Snapshot:      models.NewCertificateSnapshot(cert),
+			TxID:          cert.BlockchainTxID,
+			CreatedAt:     cert.CreatedAt,
+		}}
+	}
+
+	responses := make([]*models.CertificateVersionResponse, 0, len(versions))
+	for i, v := range versions {
+		resp := &models.CertificateVersionResponse{
+			Version:        v.Version,
+			Snapshot:       v.Snapshot,
+			Reason:         v.Reason,
+			DecisionNumber: v.DecisionNumber,
+			TxID:           v.TxID,
+			CreatedAt:      v.CreatedAt,
+			Changes:        []models.FieldDiff{},
+		}
+		if !v.AmendedBy.IsZero() {
+			resp.AmendedBy = v.AmendedBy.Hex()
+		}
+		if i > 0 {
+			resp.Changes = diffCertificateSnapshots(versions[i-1].Snapshot, v.Snapshot)
+		}
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+func (s *certificateService) SignCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateResponse, error) {
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return nil, common.ErrInvalidToken
+	}
+	accountID, err := primitive.ObjectIDFromHex(claims.AccountID)
+	if err != nil {
+		return nil, common.ErrInvalidToken
+	}
+
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if cert.UniversityID != universityID {
+		return nil, common.ErrCertificateAccessDenied
+	}
+	if cert.Revoked {
+		return nil, common.ErrCertificateRevoked
+	}
+	if cert.Signed && cert.Signature != "" {
+		return nil, common.ErrCertificateAlreadySigned
+	}
+	if cert.CertHash == "" {
+		return nil, fmt.Errorf("certificate chưa có cert_hash")
+	}
+
+	// Chỉ ký văn bằng đã được ban giám hiệu duyệt, hoặc ký lại văn bằng đã ghi lên blockchain sau khi đính chính
+	from := cert.CurrentStatus()
+	to := from
+	if from != models.CertificateStatusAnchored {
+		to, err = nextCertificateStatus(cert, models.CertificateActionSign, claims.Role)
+		if errors.Is(err, common.ErrInvalidStatusTransition) {
+			return nil, common.ErrCertificateNotApproved
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return nil, common.ErrUserNotExisted
+	}
+	faculty, err := s.facultyRepo.FindByID(ctx, cert.FacultyID)
+	if err != nil || faculty == nil {
+		return nil, common.ErrFacultyNotFound
+	}
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+	if university.SigningCertificate == "" {
+		return nil, common.ErrSigningKeyNotFound
+	}
+
+	// Ký trên mã băm tính lại từ dữ liệu hiện tại, tránh ký lên cert_hash đã bị sửa trực tiếp trong DB
+	certHash := generateCertificateHash(cert, user, faculty, university)
+	if certHash != cert.CertHash {
+		return nil, fmt.Errorf("cert_hash không khớp với dữ liệu văn bằng")
+	}
+
+	key, err := s.keyStore.Load(university.UniversityCode)
+	if err != nil {
+		if errors.Is(err, signing.ErrKeyNotFound) {
+			return nil, common.ErrSigningKeyNotFound
+		}
+		return nil, err
+	}
+	signature, err := signing.SignHash(key, certHash)
+	if err != nil {
+		return nil, err
+	}
+	if err := signing.VerifyHash(university.SigningCertificate, certHash, signature); err != nil {
+		return nil, fmt.Errorf("khóa ký không khớp với chứng thư đã đăng ký của trường: %w", err)
+	}
+
+	now := time.Now()
+	cert.Signed = true
+	cert.SignedAt = now
+	cert.Signature = signature
+	cert.Status = to
+	cert.UpdatedAt = now
+
+	update := bson.M{
+		"$set": bson.M{
+			"signed":     true,
+			"signed_at":  now,
+			"signature":  signature,
+			"status":     to,
+			"updated_at": now,
+		},
+		"$push": bson.M{"status_history": newStatusChange(models.CertificateActionSign, from, to, "", claims)},
+	}
+	if err := s.certificateRepo.UpdateCertificateByID(ctx, cert.ID, update); err != nil {
+		return nil, err
+	}
+
+	// Văn bằng đã ghi lên blockchain thì đưa chữ ký mới vào outbox như khi đính chính, worker cập nhật sổ cái
+	if cert.BlockchainTxID != "" {
+		if err := s.enqueueCertificateSync(ctx, cert, accountID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return mapper.MapCertificateToResponse(cert, user, faculty, university), nil
+}
+
+func (s *certificateService) VerifyCertificateSignature(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (bool, error) {
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return false, common.ErrCertificateNotFound
+	}
+	if err := checkCertificateViewer(claims, cert); err != nil {
+		return false, err
+	}
+	if !cert.Signed || cert.Signature == "" {
+		return false, common.ErrCertificateNotSigned
+	}
+
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return false, common.ErrUniversityNotFound
+	}
+	if university.SigningCertificate == "" {
+		return false, common.ErrSigningKeyNotFound
+	}
+
+	if err := signing.VerifyHash(university.SigningCertificate, cert.CertHash, cert.Signature); err != nil {
+		if errors.Is(err, signing.ErrInvalidSignature) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GenerateCertificateFile dựng file PDF văn bằng từ mẫu và lưu lên MinIO như file tải lên thủ công
+func (s *certificateService) GenerateCertificateFile(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (string, error) {
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return "", common.ErrInvalidToken
+	}
+
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return "", common.ErrCertificateNotFound
+	}
+	if cert.UniversityID != universityID {
+		return "", common.ErrCertificateAccessDenied
+	}
+	if cert.Revoked {
+		return "", common.ErrCertificateRevoked
+	}
+	if cert.Path != "" {
+		return "", common.ErrCertificateFileExists
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return "", common.ErrUserNotExisted
+	}
+	faculty, err := s.facultyRepo.FindByID(ctx, cert.FacultyID)
+	if err != nil || faculty == nil {
+		return "", common.ErrFacultyNotFound
+	}
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return "", common.ErrUniversityNotFound
+	}
+
+	verifyToken, err := utils.GenerateVerifyToken(cert.ID)
+	if err != nil {
+		return "", fmt.Errorf("không thể tạo mã xác minh: %w", err)
+	}
+
+	pdf, err := s.renderer.Render(&diploma.Data{
+		UniversityName:  university.UniversityName,
+		UniversityCode:  university.UniversityCode,
+		CertificateType: cert.CertificateType,
+		CertificateName: cert.Name,
+		StudentName:     user.FullName,
+		StudentCode:     user.StudentCode,
+		DateOfBirth:     user.DateOfBirth,
+		FacultyName:     faculty.FacultyName,
+		Major:           cert.Major,
+		Course:          cert.Course,
+		GraduationRank:  cert.GraduationRank,
+		EducationType:   cert.EducationType,
+		SerialNumber:    cert.SerialNumber,
+		RegNo:           cert.RegNo,
+		IssueDate:       cert.IssueDate,
+		VerifyToken:     verifyToken,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Đặt tên file theo cùng quy tắc với UploadCertificateFile: văn bằng theo số hiệu, chứng chỉ theo mã sinh viên
+	isDegree := false
+	if certType, err := resolveCertificateType(ctx, s.certTypeRepo, cert.UniversityID, cert.CertificateType); err == nil {
+		isDegree = certType.IsDegree
+	}
+	filename := cert.StudentCode + ".pdf"
+	if isDegree {
+		filename = cert.SerialNumber + ".pdf"
+	}
+
+	return s.UploadCertificateFile(ctx, cert.ID, pdf, filename, isDegree, cert.Name)
+}
+
+type certificateTransition struct {
+	from  []string
+	to    string
+	roles []string // Để trống nếu thao tác do hệ thống thực hiện
+}
+
+// Bảng chuyển trạng thái của quy trình cấp văn bằng và vai trò được phép thực hiện
+var certificateTransitions = map[string]certificateTransition{
+	// Quản trị trường chỉ lập và trình văn bằng, các bước duyệt và ký do cán bộ khoa, ban giám hiệu thực hiện
+	models.CertificateActionSubmit: {
+		from:  []string{models.CertificateStatusDraft},
+		to:    models.CertificateStatusSubmitted,
+		roles: []string{common.RoleUniversityAdmin, common.RoleFacultyStaff},
+	},
+	models.CertificateActionFacultyApprove: {
+		from:  []string{models.CertificateStatusSubmitted},
+		to:    models.CertificateStatusFacultyApproved,
+		roles: []string{common.RoleFacultyStaff},
+	},
+	models.CertificateActionRectorApprove: {
+		from:  []string{models.CertificateStatusFacultyApproved},
+		to:    models.CertificateStatusRectorApproved,
+		roles: []string{common.RoleRector},
+	},
+	models.CertificateActionReject: {
+		from:  []string{models.CertificateStatusSubmitted, models.CertificateStatusFacultyApproved, models.CertificateStatusRectorApproved},
+		to:    models.CertificateStatusDraft,
+		roles: []string{common.RoleFacultyStaff, common.RoleRector},
+	},
+	models.CertificateActionSign: {
+		from:  []string{models.CertificateStatusRectorApproved},
+		to:    models.CertificateStatusSigned,
+		roles: []string{common.RoleRector},
+	},
+	models.CertificateActionAnchor: {
+		from: []string{models.CertificateStatusSigned},
+		to:   models.CertificateStatusAnchored,
+	},
+}
+
+// nextCertificateStatus kiểm tra thao tác có hợp lệ với trạng thái hiện tại và vai trò không, trả về trạng thái mới
+func nextCertificateStatus(cert *models.Certificate, action, role string) (string, error) {
+	t, ok := certificateTransitions[action]
+	if !ok {
+		return "", common.ErrInvalidStatusTransition
+	}
+	if len(t.roles) > 0 && !containsString(t.roles, role) {
+		return "", common.ErrTransitionNotPermitted
+	}
+	if !containsString(t.from, cert.CurrentStatus()) {
+		return "", common.ErrInvalidStatusTransition
+	}
+	return t.to, nil
+}
+
+func newStatusChange(action, from, to, comment string, claims *utils.CustomClaims) *models.CertificateStatusChange {
+	change := &models.CertificateStatusChange{
+		Action:    action,
+		From:      from,
+		To:        to,
+		Comment:   strings.TrimSpace(comment),
+		ChangedAt: time.Now(),
+	}
+	if claims != nil {
+		change.ChangedBy, _ = primitive.ObjectIDFromHex(claims.AccountID)
+		change.Role = claims.Role
+	}
+	return change
+}
+
+// lastStatusChange trả lần gần nhất văn bằng được chuyển trạng thái bằng thao tác action
+func lastStatusChange(cert *models.Certificate, action string) *models.CertificateStatusChange {
+	for i := len(cert.StatusHistory) - 1; i >= 0; i-- {
+		if change := cert.StatusHistory[i]; change != nil && change.Action == action {
+			return change
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *certificateService) TransitionCertificate(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.CertificateTransitionRequest) (*models.CertificateStatusResponse, error) {
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return nil, common.ErrInvalidToken
+	}
+
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if cert.UniversityID != universityID {
+		return nil, common.ErrCertificateAccessDenied
+	}
+	if cert.Revoked {
+		return nil, common.ErrCertificateRevoked
+	}
+	if req.Action == models.CertificateActionReject && strings.TrimSpace(req.Comment) == "" {
+		return nil, common.ErrCommentRequired
+	}
+
+	from := cert.CurrentStatus()
+	to, err := nextCertificateStatus(cert, req.Action, claims.Role)
+	if err != nil {
+		return nil, err
+	}
+	// Cán bộ khoa đã trình văn bằng thì không được tự duyệt cấp khoa
+	if req.Action == models.CertificateActionFacultyApprove {
+		if submit := lastStatusChange(cert, models.CertificateActionSubmit); submit != nil && submit.ChangedBy.Hex() == claims.AccountID {
+			return nil, common.ErrTransitionSameActor
+		}
+	}
+
+	change := newStatusChange(req.Action, from, to, req.Comment, claims)
+	update := bson.M{
+		"$set": bson.M{
+			"status":     to,
+			"updated_at": change.ChangedAt,
+		},
+		"$push": bson.M{"status_history": change},
+	}
+	// Chỉ chuyển khi trạng thái chưa bị yêu cầu khác thay đổi, văn bằng cũ chưa có trường status
+	filter := bson.M{"_id": cert.ID, "status": cert.Status}
+	if cert.Status == "" {
+		filter["status"] = bson.M{"$in": []any{"", nil}}
+	}
+	matched, err := s.certificateRepo.UpdateCertificateIfMatch(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return nil, common.ErrCertificateStatusConflict
+	}
+
+	return &models.CertificateStatusResponse{
+		CertificateID: cert.ID.Hex(),
+		Status:        to,
+		History:       append(cert.StatusHistory, change),
+	}, nil
+}
+
+func (s *certificateService) GetCertificateStatusHistory(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateStatusResponse, error) {
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if err := checkCertificateViewer(claims, cert); err != nil {
+		return nil, err
+	}
+
+	history := cert.StatusHistory
+	if history == nil {
+		history = []*models.CertificateStatusChange{}
+	}
+	return &models.CertificateStatusResponse{
+		CertificateID: cert.ID.Hex(),
+		Status:        cert.CurrentStatus(),
+		History:       history,
+	}, nil
+}
+
+// CreateDisclosure trích các trường sinh viên chọn công bố cùng đường chứng minh tới gốc các trường đã ghi trên sổ cái
+func (s *certificateService) CreateDisclosure(ctx context.Context, userID, id primitive.ObjectID, fields []string) (*models.SelectiveDisclosure, error) {
+	cert, err := s.certificateRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if cert.UserID != userID {
+		return nil, common.ErrCertificateAccessDenied
+	}
+	// Văn bằng neo theo lô chỉ có cert_hash trong gốc của lô, gốc các trường chưa nằm trên sổ cái
+	if cert.BlockchainTxID == "" || !cert.BatchID.IsZero() || cert.FieldCommitment == nil {
+		return nil, common.ErrDisclosureNotAvailable
+	}
+
+	names := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !models.IsDisclosureField(field) {
+			return nil, common.ErrInvalidDisclosureField
+		}
+		if !seen[field] {
+			seen[field] = true
+			names = append(names, field)
+		}
+	}
+	if len(names) == 0 {
+		return nil, common.ErrInvalidDisclosureField
+	}
+
+	disclosed, err := discloseFields(cert.FieldCommitment, names)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SelectiveDisclosure{
+		CertificateID: cert.ID.Hex(),
+		FieldsRoot:    cert.FieldCommitment.Root,
+		Fields:        disclosed,
+	}, nil
+}
+
 func (s *certificateService) GetSimpleCertificatesByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.CertificateSimpleResponse, error) {
 	certs, err := s.certificateRepo.GetByUserID(ctx, userID)
 	if err != nil {
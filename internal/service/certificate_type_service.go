@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CertificateTypeService interface {
+	GetCertificateTypes(ctx context.Context, universityID primitive.ObjectID) ([]*models.CertificateTypeResponse, error)
+	CreateCertificateType(ctx context.Context, claims *utils.CustomClaims, req *models.CreateCertificateTypeRequest) (*models.CertificateTypeResponse, error)
+	UpdateCertificateType(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.UpdateCertificateTypeRequest) (*models.CertificateTypeResponse, error)
+	DeleteCertificateType(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) error
+}
+
+type certificateTypeService struct {
+	certificateTypeRepo repository.CertificateTypeRepository
+	certificateRepo     repository.CertificateRepository
+}
+
+func NewCertificateTypeService(certificateTypeRepo repository.CertificateTypeRepository, certificateRepo repository.CertificateRepository) CertificateTypeService {
+	return &certificateTypeService{
+		certificateTypeRepo: certificateTypeRepo,
+		certificateRepo:     certificateRepo,
+	}
+}
+
+var degreeRequiredFields = []string{
+	models.CertificateFieldSerialNumber,
+	models.CertificateFieldRegNo,
+	models.CertificateFieldIssueDate,
+}
+
+// Danh mục mặc định áp dụng cho mọi trường, trường có thể ghi đè bằng cách tạo loại cùng mã
+var defaultCertificateTypes = []models.CertificateType{
+	{Code: "bachelor", DisplayName: "Cử nhân", IsDegree: true, RequiredFields: degreeRequiredFields, StudentStatus: 1},
+	{Code: "engineer", DisplayName: "Kỹ sư", IsDegree: true, RequiredFields: degreeRequiredFields, StudentStatus: 2},
+	{Code: "master", DisplayName: "Thạc sĩ", IsDegree: true, RequiredFields: degreeRequiredFields, StudentStatus: 3},
+	{Code: "doctor", DisplayName: "Tiến sĩ", IsDegree: true, RequiredFields: degreeRequiredFields, StudentStatus: 4},
+}
+
+// resolveCertificateType tìm loại văn bằng theo mã hoặc tên hiển thị, ưu tiên danh mục riêng của trường
+func resolveCertificateType(ctx context.Context, repo repository.CertificateTypeRepository, universityID primitive.ObjectID, value string) (*models.CertificateType, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, common.ErrCertificateTypeNotFound
+	}
+
+	certType, err := repo.FindByCodeOrName(ctx, universityID, value)
+	if err != nil {
+		return nil, err
+	}
+	if certType != nil {
+		return certType, nil
+	}
+
+	for i := range defaultCertificateTypes {
+		d := defaultCertificateTypes[i]
+		if strings.EqualFold(d.Code, value) || d.DisplayName == value {
+			// Trường đã ghi đè loại mặc định này bằng mã hoặc tên khác thì không dùng bản mặc định
+			if overridden, err := repo.FindByCodeOrName(ctx, universityID, d.Code); err != nil {
+				return nil, err
+			} else if overridden != nil {
+				return overridden, nil
+			}
+			return &d, nil
+		}
+	}
+	return nil, common.ErrCertificateTypeNotFound
+}
+
+func (s *certificateTypeService) GetCertificateTypes(ctx context.Context, universityID primitive.ObjectID) ([]*models.CertificateTypeResponse, error) {
+	types, err := s.certificateTypeRepo.FindAllByUniversityID(ctx, universityID)
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := make(map[string]bool)
+	var resp []*models.CertificateTypeResponse
+	for _, t := range types {
+		overridden[t.Code] = true
+		overridden[t.DisplayName] = true
+		resp = append(resp, mapCertificateTypeToResponse(t, false))
+	}
+	for i := range defaultCertificateTypes {
+		d := &defaultCertificateTypes[i]
+		if !overridden[d.Code] && !overridden[d.DisplayName] {
+			resp = append(resp, mapCertificateTypeToResponse(d, true))
+		}
+	}
+	return resp, nil
+}
+
+func (s *certificateTypeService) CreateCertificateType(ctx context.Context, claims *utils.CustomClaims, req *models.CreateCertificateTypeRequest) (*models.CertificateTypeResponse, error) {
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return nil, common.ErrInvalidToken
+	}
+
+	code := strings.TrimSpace(req.Code)
+	displayName := strings.TrimSpace(req.DisplayName)
+	for _, value := range []string{code, displayName} {
+		existing, err := s.certificateTypeRepo.FindByCodeOrName(ctx, universityID, value)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, common.ErrCertificateTypeExists
+		}
+	}
+
+	now := time.Now()
+	certType := &models.CertificateType{
+		ID:             primitive.NewObjectID(),
+		UniversityID:   universityID,
+		Code:           code,
+		DisplayName:    displayName,
+		IsDegree:       req.IsDegree,
+		RequiredFields: normalizeRequiredFields(req.RequiredFields),
+		StudentStatus:  req.StudentStatus,
+		Description:    req.Description,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.certificateTypeRepo.Create(ctx, certType); err != nil {
+		return nil, err
+	}
+	return mapCertificateTypeToResponse(certType, false), nil
+}
+
+func (s *certificateTypeService) UpdateCertificateType(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID, req *models.UpdateCertificateTypeRequest) (*models.CertificateTypeResponse, error) {
+	certType, err := s.findOwnedCertificateType(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	certType.IsDegree = req.IsDegree
+	certType.RequiredFields = normalizeRequiredFields(req.RequiredFields)
+	certType.StudentStatus = req.StudentStatus
+	certType.Description = req.Description
+	certType.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"is_degree":       certType.IsDegree,
+		"required_fields": certType.RequiredFields,
+		"student_status":  certType.StudentStatus,
+		"description":     certType.Description,
+		"updated_at":      certType.UpdatedAt,
+	}
+	if err := s.certificateTypeRepo.Update(ctx, id, update); err != nil {
+		return nil, err
+	}
+	return mapCertificateTypeToResponse(certType, false), nil
+}
+
+func (s *certificateTypeService) DeleteCertificateType(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) error {
+	certType, err := s.findOwnedCertificateType(ctx, claims, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.certificateRepo.FindOne(ctx, bson.M{
+		"university_id":    certType.UniversityID,
+		"certificate_type": certType.DisplayName,
+	})
+	if err == nil {
+		return common.ErrCertificateTypeInUse
+	}
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	return s.certificateTypeRepo.DeleteByID(ctx, id)
+}
+
+func (s *certificateTypeService) findOwnedCertificateType(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateType, error) {
+	universityID, err := primitive.ObjectIDFromHex(claims.UniversityID)
+	if err != nil {
+		return nil, common.ErrInvalidToken
+	}
+	certType, err := s.certificateTypeRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if certType == nil || certType.UniversityID != universityID {
+		return nil, common.ErrCertificateTypeNotFound
+	}
+	return certType, nil
+}
+
+func normalizeRequiredFields(fields []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f != "" && !seen[f] {
+			seen[f] = true
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func mapCertificateTypeToResponse(t *models.CertificateType, isDefault bool) *models.CertificateTypeResponse {
+	resp := &models.CertificateTypeResponse{
+		Code:           t.Code,
+		DisplayName:    t.DisplayName,
+		IsDegree:       t.IsDegree,
+		RequiredFields: t.RequiredFields,
+		StudentStatus:  t.StudentStatus,
+		Description:    t.Description,
+		IsDefault:      isDefault,
+	}
+	if !t.ID.IsZero() {
+		resp.ID = t.ID.Hex()
+	}
+	return resp
+}
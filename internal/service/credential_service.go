@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/credential"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/signing"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const credentialStatusType = "KmascCertificateStatus"
+
+type CredentialService interface {
+	ExportCertificateCredential(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateCredential, error)
+	VerifyCredential(ctx context.Context, token string) (*models.CredentialVerifyResult, error)
+}
+
+type credentialService struct {
+	certRepo       repository.CertificateRepository
+	certTypeRepo   repository.CertificateTypeRepository
+	userRepo       repository.UserRepository
+	facultyRepo    repository.FacultyRepository
+	universityRepo repository.UniversityRepository
+	keyStore       *signing.KeyStore
+	ledger         blockchain.Ledger
+	baseURL        string
+}
+
+func NewCredentialService(
+	certRepo repository.CertificateRepository,
+	certTypeRepo repository.CertificateTypeRepository,
+	userRepo repository.UserRepository,
+	facultyRepo repository.FacultyRepository,
+	universityRepo repository.UniversityRepository,
+	keyStore *signing.KeyStore,
+	ledger blockchain.Ledger,
+	baseURL string,
+) CredentialService {
+	return &credentialService{
+		certRepo:       certRepo,
+		certTypeRepo:   certTypeRepo,
+		userRepo:       userRepo,
+		facultyRepo:    facultyRepo,
+		universityRepo: universityRepo,
+		keyStore:       keyStore,
+		ledger:         ledger,
+		baseURL:        baseURL,
+	}
+}
+
+// ExportCertificateCredential xuất văn bằng đã ký thành W3C Verifiable Credential, ký JWT-VC bằng khóa của trường
+func (s *credentialService) ExportCertificateCredential(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificateCredential, error) {
+	cert, err := s.certRepo.GetCertificateByID(ctx, id)
+	if err != nil || cert == nil {
+		return nil, common.ErrCertificateNotFound
+	}
+	if err := checkCertificateViewer(claims, cert); err != nil {
+		return nil, err
+	}
+	if cert.Revoked {
+		return nil, common.ErrCertificateRevoked
+	}
+	if !cert.Signed || cert.Signature == "" {
+		return nil, common.ErrCertificateNotSigned
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return nil, common.ErrUserNotExisted
+	}
+	faculty, err := s.facultyRepo.FindByID(ctx, cert.FacultyID)
+	if err != nil || faculty == nil {
+		return nil, common.ErrFacultyNotFound
+	}
+	university, err := s.universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+	key, err := loadUniversityKey(s.keyStore, university.UniversityCode)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialType := models.CredentialTypeCertificate
+	if certType, err := resolveCertificateType(ctx, s.certTypeRepo, cert.UniversityID, cert.CertificateType); err == nil && certType.IsDegree {
+		credentialType = models.CredentialTypeDegree
+	}
+	verifyToken, err := utils.GenerateVerifyToken(cert.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	issuedAt := cert.SignedAt
+	if issuedAt.IsZero() {
+		issuedAt = cert.IssueDate
+	}
+	vc := &models.VerifiableCredential{
+		Context: []any{credential.ContextV1, map[string]string{"@vocab": s.baseURL + "/vocab#"}},
+		ID:      s.credentialID(cert.ID),
+		Type:    []string{credential.TypeVerifiableCredential, credentialType},
+		Issuer: &models.CredentialIssuer{
+			ID:             s.issuerID(university.UniversityCode),
+			Name:           university.UniversityName,
+			UniversityCode: university.UniversityCode,
+		},
+		IssuanceDate: issuedAt.UTC().Format(time.RFC3339),
+		CredentialSubject: &models.DegreeCredentialSubject{
+			ID:          "urn:kmasc:student:" + user.ID.Hex(),
+			Name:        user.FullName,
+			StudentCode: cert.StudentCode,
+			BirthDate:   user.DateOfBirth,
+			Degree: &models.CredentialDegree{
+				Type:               cert.CertificateType,
+				Name:               cert.Name,
+				Major:              cert.Major,
+				Course:             cert.Course,
+				Faculty:            faculty.FacultyName,
+				GPA:                cert.GPA,
+				GraduationRank:     cert.GraduationRank,
+				EducationType:      cert.EducationType,
+				SerialNumber:       cert.SerialNumber,
+				RegistrationNumber: cert.RegNo,
+				IssueDate:          cert.IssueDate.Format("2006-01-02"),
+				Version:            cert.CurrentVersion(),
+				CertHash:           cert.CertHash,
+				BlockchainTxID:     cert.BlockchainTxID,
+			},
+		},
+		CredentialStatus: &models.CredentialStatus{
+			ID:   s.baseURL + "/api/v1/public/verify?" + url.Values{"token": {verifyToken}}.Encode(),
+			Type: credentialStatusType,
+		},
+	}
+
+	raw, err := json.Marshal(vc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal lỗi: %v", err)
+	}
+	// Ánh xạ claim JWT theo VC-JWT: iss, sub, jti, nbf lấy từ credential
+	token, err := credential.Sign(key, vc.Issuer.ID, &credential.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    vc.Issuer.ID,
+			Subject:   vc.CredentialSubject.ID,
+			ID:        vc.ID,
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		VC: raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.CertificateCredential{Credential: vc, JWT: token}, nil
+}
+
+// VerifyCredential kiểm tra chữ ký JWT-VC, bên phát hành và trạng thái thu hồi của văn bằng
+func (s *credentialService) VerifyCredential(ctx context.Context, token string) (*models.CredentialVerifyResult, error) {
+	claims, err := credential.ParseUnverified(token)
+	if err != nil {
+		return nil, common.ErrInvalidCredential
+	}
+	var vc models.VerifiableCredential
+	if err := json.Unmarshal(claims.VC, &vc); err != nil || vc.Issuer == nil || vc.CredentialSubject == nil || vc.CredentialSubject.Degree == nil {
+		return nil, common.ErrInvalidCredential
+	}
+	result := &models.CredentialVerifyResult{Credential: &vc}
+	certID, err := s.certificateIDFromCredential(vc.ID)
+	if err != nil {
+		return nil, common.ErrInvalidCredential
+	}
+	result.CertificateID = certID.Hex()
+
+	issuerCheck := &models.IntegrityCheck{Name: models.IntegrityCheckIssuer, Expected: s.issuerID(vc.Issuer.UniversityCode), Actual: vc.Issuer.ID}
+	university, err := s.universityRepo.FindByCode(ctx, vc.Issuer.UniversityCode)
+	switch {
+	case err != nil || university == nil:
+		issuerCheck.Message = "Bên phát hành không phải trường trong hệ thống"
+	case vc.Issuer.ID != issuerCheck.Expected || claims.Issuer != vc.Issuer.ID:
+		issuerCheck.Message = "Bên phát hành không khớp với trường cấp văn bằng"
+	case university.SigningCertificate == "":
+		issuerCheck.Message = "Trường chưa đăng ký khóa ký số"
+	default:
+		issuerCheck.Passed = true
+	}
+	result.Add(issuerCheck)
+	if !issuerCheck.Passed {
+		result.Add(&models.IntegrityCheck{Name: models.IntegrityCheckProof, Skipped: true, Message: "Không xác định được khóa của bên phát hành"})
+		result.Finish("")
+		return result, nil
+	}
+
+	result.Add(credentialProofCheck(university, token, vc.ID, vc.CredentialSubject.ID))
+
+	cert, err := s.certRepo.GetCertificateByID(ctx, certID)
+	if err != nil || cert == nil || cert.UniversityID != university.ID {
+		result.Add(&models.IntegrityCheck{Name: models.IntegrityCheckCertificate, Message: "Không tìm thấy văn bằng của credential"})
+		result.Finish("")
+		return result, nil
+	}
+
+	result.Add(credentialRevocationCheck(ctx, s.ledger, cert, &result.IntegrityReport))
+
+	degree := vc.CredentialSubject.Degree
+	versionCheck := compareCheck(models.IntegrityCheckVersion, cert.CertHash, degree.CertHash, "Văn bằng đã được đính chính sau khi xuất credential")
+	if degree.Version != cert.CurrentVersion() {
+		versionCheck.Passed = false
+		versionCheck.Message = "Văn bằng đã được đính chính sau khi xuất credential"
+	}
+	result.Add(versionCheck)
+
+	result.Finish("Credential hợp lệ")
+	return result, nil
+}
+
+func (s *credentialService) issuerID(universityCode string) string {
+	return s.baseURL + "/api/v1/universities/" + url.PathEscape(universityCode) + "/signing-certificate"
+}
+
+func (s *credentialService) credentialID(id primitive.ObjectID) string {
+	return s.baseURL + "/api/v1/certificates/" + id.Hex() + "/vc"
+}
+
+func (s *credentialService) certificateIDFromCredential(credentialID string) (primitive.ObjectID, error) {
+	return objectIDFromURL(credentialID, s.baseURL+"/api/v1/certificates/", "/vc")
+}
+
+// objectIDFromURL lấy ID nằm giữa prefix và suffix của một id dạng URL do hệ thống phát hành
+func objectIDFromURL(rawURL, prefix, suffix string) (primitive.ObjectID, error) {
+	if !strings.HasPrefix(rawURL, prefix) || !strings.HasSuffix(rawURL, suffix) {
+		return primitive.NilObjectID, common.ErrInvalidCredential
+	}
+	return primitive.ObjectIDFromHex(strings.TrimSuffix(strings.TrimPrefix(rawURL, prefix), suffix))
+}
+
+// loadUniversityKey đọc khóa ký của trường, thiếu khóa trả về ErrSigningKeyNotFound
+func loadUniversityKey(keyStore *signing.KeyStore, universityCode string) (*ecdsa.PrivateKey, error) {
+	key, err := keyStore.Load(universityCode)
+	if err != nil {
+		if errors.Is(err, signing.ErrKeyNotFound) {
+			return nil, common.ErrSigningKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// credentialProofCheck kiểm tra chữ ký JWT bằng chứng thư của trường và claim jti, sub khớp với nội dung credential
+func credentialProofCheck(university *models.University, token, credentialID, subjectID string) *models.IntegrityCheck {
+	check := &models.IntegrityCheck{Name: models.IntegrityCheckProof}
+	if pub, err := signing.ParsePublicKey(university.SigningCertificate); err != nil {
+		check.Message = "Chứng thư khóa công khai của trường không hợp lệ"
+	} else if verified, err := credential.Verify(token, pub); err != nil {
+		check.Message = "Chữ ký của credential không hợp lệ"
+	} else if verified.ID != credentialID || verified.Subject != subjectID {
+		check.Message = "Claim JWT không khớp với nội dung credential"
+	} else {
+		check.Passed = true
+	}
+	return check
+}
+
+// credentialRevocationCheck lấy trạng thái thu hồi từ sổ cái nếu văn bằng có bản ghi riêng, MongoDB là nguồn dự phòng
+func credentialRevocationCheck(ctx context.Context, ledger blockchain.Ledger, cert *models.Certificate, report *models.IntegrityReport) *models.IntegrityCheck {
+	revoked := cert.Revoked
+	if cert.BlockchainTxID != "" && cert.BatchID.IsZero() {
+		if onChain, err := ledger.GetCertificateByID(ctx, cert.ID.Hex()); err == nil {
+			report.OnChain = onChain
+			revoked = revoked || onChain.Revoked
+		}
+	}
+	return revocationCheck(revoked)
+}
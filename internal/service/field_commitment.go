@@ -0,0 +1,131 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/merkle"
+)
+
+const certificateFieldSaltSize = 16
+
+// certificateFieldValues lấy giá trị dạng chuỗi của từng trường được cam kết
+func certificateFieldValues(cert *models.Certificate, user *models.User, faculty *models.Faculty, university *models.University) map[string]string {
+	return map[string]string{
+		models.DisclosureFieldStudentName:     user.FullName,
+		models.DisclosureFieldDateOfBirth:     user.DateOfBirth,
+		models.DisclosureFieldStudentCode:     cert.StudentCode,
+		models.DisclosureFieldUniversityCode:  university.UniversityCode,
+		models.DisclosureFieldUniversityName:  university.UniversityName,
+		models.DisclosureFieldFacultyCode:     faculty.FacultyCode,
+		models.DisclosureFieldCertificateType: cert.CertificateType,
+		models.DisclosureFieldName:            cert.Name,
+		models.DisclosureFieldMajor:           cert.Major,
+		models.DisclosureFieldCourse:          cert.Course,
+		models.DisclosureFieldGPA:             strconv.FormatFloat(cert.GPA, 'f', -1, 64),
+		models.DisclosureFieldGraduationRank:  cert.GraduationRank,
+		models.DisclosureFieldEducationType:   cert.EducationType,
+		models.DisclosureFieldIssueDate:       cert.IssueDate.Format("2006-01-02"),
+		models.DisclosureFieldGraduationYear:  strconv.Itoa(cert.IssueDate.Year()),
+		models.DisclosureFieldSerialNumber:    cert.SerialNumber,
+		models.DisclosureFieldRegNo:           cert.RegNo,
+	}
+}
+
+// buildFieldCommitment cam kết lại các trường theo dữ liệu hiện tại.
+// Trường không đổi giá trị giữ nguyên muối cũ để lá của nó không đổi giữa các phiên bản.
+func buildFieldCommitment(previous *models.CertificateFieldCommitment, values map[string]string) (*models.CertificateFieldCommitment, error) {
+	oldFields := make(map[string]*models.CertificateField)
+	if previous != nil {
+		for _, field := range previous.Fields {
+			oldFields[field.Name] = field
+		}
+	}
+
+	commitment := &models.CertificateFieldCommitment{}
+	leaves := make([]string, 0, len(models.DisclosureFields))
+	for _, name := range models.DisclosureFields {
+		field := &models.CertificateField{Name: name, Value: values[name]}
+		if old, ok := oldFields[name]; ok && old.Value == field.Value && old.Salt != "" {
+			field.Salt = old.Salt
+		} else {
+			salt := make([]byte, certificateFieldSaltSize)
+			if _, err := rand.Read(salt); err != nil {
+				return nil, fmt.Errorf("không thể sinh muối cho trường %s: %w", name, err)
+			}
+			field.Salt = hex.EncodeToString(salt)
+		}
+		leaf, err := certificateFieldLeaf(field.Name, field.Value, field.Salt)
+		if err != nil {
+			return nil, err
+		}
+		commitment.Fields = append(commitment.Fields, field)
+		leaves = append(leaves, leaf)
+	}
+
+	tree, err := merkle.Build(leaves)
+	if err != nil {
+		return nil, err
+	}
+	commitment.Root = tree.Root()
+	return commitment, nil
+}
+
+// certificateFieldLeaf là lá của cây các trường: sha256 của JSON chuẩn hóa {name, salt, value}
+func certificateFieldLeaf(name, value, salt string) (string, error) {
+	data, err := canonicalJSON(struct {
+		Name  string `json:"name"`
+		Salt  string `json:"salt"`
+		Value string `json:"value"`
+	}{name, salt, value})
+	if err != nil {
+		return "", fmt.Errorf("không thể mã hóa trường %s: %w", name, err)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// discloseFields trích các trường được chọn cùng đường chứng minh tới gốc đã cam kết
+func discloseFields(commitment *models.CertificateFieldCommitment, names []string) ([]*models.DisclosedField, error) {
+	leaves := make([]string, len(commitment.Fields))
+	index := make(map[string]int, len(commitment.Fields))
+	for i, field := range commitment.Fields {
+		leaf, err := certificateFieldLeaf(field.Name, field.Value, field.Salt)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+		index[field.Name] = i
+	}
+	tree, err := merkle.Build(leaves)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Root() != commitment.Root {
+		return nil, fmt.Errorf("cam kết các trường của văn bằng không khớp với gốc đã lưu")
+	}
+
+	disclosed := make([]*models.DisclosedField, 0, len(names))
+	for _, name := range names {
+		i, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("văn bằng chưa cam kết trường %s", name)
+		}
+		proof, err := tree.Proof(i)
+		if err != nil {
+			return nil, err
+		}
+		field := commitment.Fields[i]
+		disclosed = append(disclosed, &models.DisclosedField{
+			Name:  field.Name,
+			Value: field.Value,
+			Salt:  field.Salt,
+			Proof: toMerkleProofSteps(proof),
+		})
+	}
+	return disclosed, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// loadPrivateDetails lấy các trường riêng của văn bằng để ghi vào private data collection, mỗi lần ghi dùng muối mới
+func loadPrivateDetails(ctx context.Context, userRepo repository.UserRepository, facultyRepo repository.FacultyRepository, universityRepo repository.UniversityRepository, cert *models.Certificate) (*models.CertificatePrivateDetails, error) {
+	user, err := userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return nil, common.ErrUserNotExisted
+	}
+	faculty, err := facultyRepo.FindByID(ctx, cert.FacultyID)
+	if err != nil || faculty == nil {
+		return nil, common.ErrFacultyNotFound
+	}
+	university, err := universityRepo.FindByID(ctx, cert.UniversityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+
+	values := certificateFieldValues(cert, user, faculty, university)
+	fields := make(map[string]string, len(models.PrivateDetailFields))
+	for _, name := range models.PrivateDetailFields {
+		fields[name] = values[name]
+	}
+	salt := make([]byte, certificateFieldSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("không thể sinh muối cho dữ liệu riêng: %w", err)
+	}
+	return &models.CertificatePrivateDetails{
+		CertID: cert.ID.Hex(),
+		Fields: fields,
+		Salt:   hex.EncodeToString(salt),
+	}, nil
+}
+
+// GetCertificatePrivateDetails đọc dữ liệu riêng của văn bằng từ private data collection và đối chiếu với mã băm trên kênh công khai.
+// Chỉ đọc được khi tổ chức của server thuộc collection; văn bằng không còn trong MongoDB chỉ quản trị hệ thống được xem.
+func (s *blockchainService) GetCertificatePrivateDetails(ctx context.Context, claims *utils.CustomClaims, id primitive.ObjectID) (*models.CertificatePrivateDetailsResult, error) {
+	cert, err := s.certRepo.GetCertificateByID(ctx, id)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if cert != nil {
+		if err := checkCertificateViewer(claims, cert); err != nil {
+			return nil, err
+		}
+	} else if claims.Role != common.RoleAdmin {
+		return nil, common.ErrCertificateNotFound
+	}
+
+	onChain, err := s.ledger.GetCertificateByID(ctx, id.Hex())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := s.ledger.GetCertificatePrivateDetails(ctx, id.Hex())
+	if err != nil {
+		return nil, err
+	}
+	var details models.CertificatePrivateDetails
+	if err := json.Unmarshal(raw, &details); err != nil {
+		return nil, fmt.Errorf("dữ liệu riêng trên sổ cái không hợp lệ: %w", err)
+	}
+
+	sum := sha256.Sum256(raw)
+	computed := hex.EncodeToString(sum[:])
+	return &models.CertificatePrivateDetailsResult{
+		CertificateID:   id.Hex(),
+		Collection:      onChain.PrivateCollection,
+		PrivateDataHash: onChain.PrivateDataHash,
+		ComputedHash:    computed,
+		HashMatches:     computed == onChain.PrivateDataHash,
+		Details:         &details,
+	}, nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/blockchain"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/merkle"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Nguồn kích hoạt một lần đối soát
+const (
+	ReconciliationTriggerCLI      = "cli"
+	ReconciliationTriggerSchedule = "schedule"
+	ReconciliationTriggerAPI      = "api"
+)
+
+type ReconciliationService interface {
+	Run(ctx context.Context, trigger string) (*models.ReconciliationReport, error)
+	GetReport(ctx context.Context, id primitive.ObjectID) (*models.ReconciliationReport, error)
+	SearchReports(ctx context.Context, params models.SearchReconciliationReportParams) ([]*models.ReconciliationReport, int64, error)
+}
+
+type reconciliationService struct {
+	certRepo       repository.CertificateRepository
+	reportRepo     repository.ReconciliationReportRepository
+	userRepo       repository.UserRepository
+	facultyRepo    repository.FacultyRepository
+	universityRepo repository.UniversityRepository
+	ledger         blockchain.Ledger
+}
+
+func NewReconciliationService(
+	certRepo repository.CertificateRepository,
+	reportRepo repository.ReconciliationReportRepository,
+	userRepo repository.UserRepository,
+	facultyRepo repository.FacultyRepository,
+	universityRepo repository.UniversityRepository,
+	ledger blockchain.Ledger,
+) ReconciliationService {
+	return &reconciliationService{
+		certRepo:       certRepo,
+		reportRepo:     reportRepo,
+		userRepo:       userRepo,
+		facultyRepo:    facultyRepo,
+		universityRepo: universityRepo,
+		ledger:         ledger,
+	}
+}
+
+// Run đối chiếu toàn bộ văn bằng đã ghi blockchain trong MongoDB với sổ cái và lưu báo cáo sai lệch
+func (s *reconciliationService) Run(ctx context.Context, trigger string) (*models.ReconciliationReport, error) {
+	report := &models.ReconciliationReport{
+		ID:        primitive.NewObjectID(),
+		Trigger:   trigger,
+		StartedAt: time.Now(),
+		Summary:   map[string]int{},
+		Drifts:    []*models.ReconciliationDrift{},
+	}
+
+	runErr := s.reconcile(ctx, report)
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	report.FinishedAt = time.Now()
+	for _, drift := range report.Drifts {
+		report.Summary[drift.Type]++
+	}
+
+	if err := s.reportRepo.Create(ctx, report); err != nil {
+		return report, fmt.Errorf("không thể lưu báo cáo đối soát: %w", err)
+	}
+	return report, runErr
+}
+
+func (s *reconciliationService) reconcile(ctx context.Context, report *models.ReconciliationReport) error {
+	onChainCerts, err := s.ledger.GetAllCertificates(ctx)
+	if err != nil {
+		return fmt.Errorf("lỗi lấy danh sách văn bằng trên sổ cái: %w", err)
+	}
+	report.OnChain = len(onChainCerts)
+	onChainByID := make(map[string]*models.CertificateOnChain, len(onChainCerts))
+	for _, onChain := range onChainCerts {
+		onChainByID[onChain.CertID] = onChain
+	}
+
+	certs, err := s.certRepo.FindAnchored(ctx)
+	if err != nil {
+		return fmt.Errorf("lỗi lấy văn bằng từ MongoDB: %w", err)
+	}
+
+	hasher := newCertificateHasher(s.userRepo, s.facultyRepo, s.universityRepo)
+	batches := make(map[primitive.ObjectID]*models.CertificateBatchOnChain)
+	seen := make(map[string]bool, len(certs))
+	for _, cert := range certs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		report.Checked++
+		certID := cert.ID.Hex()
+		seen[certID] = true
+
+		localHash, err := hasher.hash(ctx, cert)
+		if err != nil {
+			report.Drifts = append(report.Drifts, newDrift(models.DriftCheckFailed, cert, "", "", err.Error()))
+			continue
+		}
+
+		// Văn bằng neo theo lô không có bản ghi riêng, đối chiếu qua gốc Merkle của lô
+		if !cert.BatchID.IsZero() {
+			batch, ok := batches[cert.BatchID]
+			if !ok {
+				batch, err = s.ledger.GetBatchByID(ctx, cert.BatchID.Hex())
+				if err != nil {
+					batch = nil
+				}
+				batches[cert.BatchID] = batch
+			}
+			switch {
+			case batch == nil:
+				report.Drifts = append(report.Drifts, newDrift(models.DriftMissingOnChain, cert, cert.BatchID.Hex(), "", "Không tìm thấy lô Merkle trên sổ cái"))
+			case !merkle.Verify(localHash, fromMerkleProofSteps(cert.MerkleProof), batch.MerkleRoot):
+				report.Drifts = append(report.Drifts, newDrift(models.DriftHashMismatch, cert, localHash, batch.MerkleRoot, "Mã băm không dẫn tới gốc Merkle của lô"))
+			}
+			continue
+		}
+
+		onChain, ok := onChainByID[certID]
+		if !ok {
+			report.Drifts = append(report.Drifts, newDrift(models.DriftMissingOnChain, cert, cert.BlockchainTxID, "", "Sổ cái không có bản ghi của văn bằng"))
+			continue
+		}
+		if localHash != onChain.CertHash {
+			report.Drifts = append(report.Drifts, newDrift(models.DriftHashMismatch, cert, localHash, onChain.CertHash, ""))
+		}
+		if cert.HashFile != onChain.HashFile {
+			report.Drifts = append(report.Drifts, newDrift(models.DriftFileMismatch, cert, cert.HashFile, onChain.HashFile, ""))
+		}
+	}
+
+	for certID, onChain := range onChainByID {
+		if seen[certID] {
+			continue
+		}
+		drift := &models.ReconciliationDrift{
+			Type:          models.DriftMissingInDB,
+			CertificateID: certID,
+			SerialNumber:  onChain.SerialNumber,
+			Actual:        onChain.CertHash,
+			Detail:        "MongoDB không có văn bằng",
+		}
+		if id, err := primitive.ObjectIDFromHex(certID); err == nil {
+			if cert, err := s.certRepo.GetCertificateByID(ctx, id); err == nil && cert != nil {
+				drift.UniversityID = cert.UniversityID.Hex()
+				drift.Detail = "MongoDB chưa ghi nhận giao dịch blockchain của văn bằng"
+			}
+		}
+		report.Drifts = append(report.Drifts, drift)
+	}
+	return nil
+}
+
+func newDrift(driftType string, cert *models.Certificate, expected, actual, detail string) *models.ReconciliationDrift {
+	return &models.ReconciliationDrift{
+		Type:          driftType,
+		CertificateID: cert.ID.Hex(),
+		SerialNumber:  cert.SerialNumber,
+		UniversityID:  cert.UniversityID.Hex(),
+		Expected:      expected,
+		Actual:        actual,
+		Detail:        detail,
+	}
+}
+
+func (s *reconciliationService) GetReport(ctx context.Context, id primitive.ObjectID) (*models.ReconciliationReport, error) {
+	report, err := s.reportRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, common.ErrReconciliationReportNotFound
+	}
+	return report, nil
+}
+
+func (s *reconciliationService) SearchReports(ctx context.Context, params models.SearchReconciliationReportParams) ([]*models.ReconciliationReport, int64, error) {
+	return s.reportRepo.Find(ctx, params.Page, params.PageSize)
+}
+
+// certificateHasher tính lại cert_hash, lưu tạm khoa và trường để không truy vấn lặp lại khi duyệt nhiều văn bằng
+type certificateHasher struct {
+	userRepo       repository.UserRepository
+	facultyRepo    repository.FacultyRepository
+	universityRepo repository.UniversityRepository
+	faculties      map[primitive.ObjectID]*models.Faculty
+	universities   map[primitive.ObjectID]*models.University
+}
+
+func newCertificateHasher(userRepo repository.UserRepository, facultyRepo repository.FacultyRepository, universityRepo repository.UniversityRepository) *certificateHasher {
+	return &certificateHasher{
+		userRepo:       userRepo,
+		facultyRepo:    facultyRepo,
+		universityRepo: universityRepo,
+		faculties:      make(map[primitive.ObjectID]*models.Faculty),
+		universities:   make(map[primitive.ObjectID]*models.University),
+	}
+}
+
+func (h *certificateHasher) hash(ctx context.Context, cert *models.Certificate) (string, error) {
+	user, err := h.userRepo.GetUserByID(ctx, cert.UserID)
+	if err != nil || user == nil {
+		return "", errors.New("không tìm thấy sinh viên")
+	}
+
+	faculty, ok := h.faculties[cert.FacultyID]
+	if !ok {
+		faculty, err = h.facultyRepo.FindByID(ctx, cert.FacultyID)
+		if err != nil || faculty == nil {
+			return "", errors.New("không tìm thấy khoa")
+		}
+		h.faculties[cert.FacultyID] = faculty
+	}
+
+	university, ok := h.universities[cert.UniversityID]
+	if !ok {
+		university, err = h.universityRepo.FindByID(ctx, cert.UniversityID)
+		if err != nil || university == nil {
+			return "", errors.New("không tìm thấy trường đại học")
+		}
+		h.universities[cert.UniversityID] = university
+	}
+
+	return generateCertificateHash(cert, user, faculty, university), nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ReconciliationWorker định kỳ đối soát MongoDB với sổ cái
+type ReconciliationWorker struct {
+	reconciliationSvc ReconciliationService
+	interval          time.Duration
+}
+
+func NewReconciliationWorker(reconciliationSvc ReconciliationService, interval time.Duration) *ReconciliationWorker {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &ReconciliationWorker{
+		reconciliationSvc: reconciliationSvc,
+		interval:          interval,
+	}
+}
+
+// Run chạy đến khi ctx bị hủy, lần đối soát đầu tiên sau một chu kỳ để không làm chậm lúc khởi động
+func (w *ReconciliationWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := w.reconciliationSvc.Run(ctx, ReconciliationTriggerSchedule)
+			if err != nil {
+				log.Printf("[ReconciliationWorker] Run error: %v", err)
+				continue
+			}
+			if report.HasDrift() {
+				log.Printf("[ReconciliationWorker] Phát hiện %d sai lệch, xem báo cáo %s", len(report.Drifts), report.ID.Hex())
+			}
+		}
+	}
+}
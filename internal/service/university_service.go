@@ -10,6 +10,7 @@ import (
 	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/repository"
+	"github.com/vnkmasc/Kmasc/app/backend/pkg/signing"
 	"github.com/vnkmasc/Kmasc/app/backend/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -21,23 +22,28 @@ type UniversityService interface {
 	GetUniversitiesByStatus(ctx context.Context, status string) ([]*models.University, error)
 	GetUniversityByID(ctx context.Context, id primitive.ObjectID) (*models.University, error)
 	GetUniversityByCode(ctx context.Context, code string) (*models.University, error)
+	GenerateSigningKey(ctx context.Context, universityID primitive.ObjectID) (*models.SigningKeyResponse, error)
+	GetSigningCertificate(ctx context.Context, universityCode string) (*models.SigningKeyResponse, error)
 }
 
 type universityService struct {
 	universityRepo repository.UniversityRepository
 	authRepo       repository.AuthRepository
 	emailSender    utils.EmailSender
+	keyStore       *signing.KeyStore
 }
 
 func NewUniversityService(
 	universityRepo repository.UniversityRepository,
 	authRepo repository.AuthRepository,
 	emailSender utils.EmailSender,
+	keyStore *signing.KeyStore,
 ) UniversityService {
 	return &universityService{
 		universityRepo: universityRepo,
 		authRepo:       authRepo,
 		emailSender:    emailSender,
+		keyStore:       keyStore,
 	}
 }
 
@@ -181,3 +187,56 @@ func (s *universityService) GetUniversitiesByStatus(ctx context.Context, status
 func (s *universityService) GetUniversityByCode(ctx context.Context, code string) (*models.University, error) {
 	return s.universityRepo.GetUniversityByCode(ctx, code)
 }
+
+func (s *universityService) GenerateSigningKey(ctx context.Context, universityID primitive.ObjectID) (*models.SigningKeyResponse, error) {
+	university, err := s.universityRepo.FindByID(ctx, universityID)
+	if err != nil || university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+	// Không cho phép thay khóa vì các chữ ký đã phát hành sẽ không còn xác minh được
+	if university.SigningCertificate != "" || s.keyStore.Exists(university.UniversityCode) {
+		return nil, common.ErrSigningKeyExists
+	}
+
+	keyPEM, certPEM, err := signing.GenerateKey(university.UniversityCode, university.UniversityName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.keyStore.Save(university.UniversityCode, keyPEM); err != nil {
+		if errors.Is(err, signing.ErrInvalidUniversityCode) {
+			return nil, common.ErrInvalidUniversityCode
+		}
+		return nil, err
+	}
+	// Không lưu được chứng thư thì xóa file khóa, nếu không trường sẽ bị chặn tạo lại khóa vì file đã tồn tại
+	if err := s.universityRepo.UpdateSigningCertificate(ctx, university.ID, string(certPEM)); err != nil {
+		if delErr := s.keyStore.Delete(university.UniversityCode); delErr != nil {
+			log.Printf("[UniversityService] Không thể xóa khóa ký số của trường %s: %v", university.UniversityCode, delErr)
+		}
+		return nil, err
+	}
+
+	return &models.SigningKeyResponse{
+		UniversityCode:     university.UniversityCode,
+		SigningCertificate: string(certPEM),
+		CreatedAt:          time.Now().Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+func (s *universityService) GetSigningCertificate(ctx context.Context, universityCode string) (*models.SigningKeyResponse, error) {
+	university, err := s.universityRepo.FindByCode(ctx, universityCode)
+	if err != nil {
+		return nil, err
+	}
+	if university == nil {
+		return nil, common.ErrUniversityNotFound
+	}
+	if university.SigningCertificate == "" {
+		return nil, common.ErrSigningKeyNotFound
+	}
+	return &models.SigningKeyResponse{
+		UniversityCode:     university.UniversityCode,
+		SigningCertificate: university.SigningCertificate,
+		CreatedAt:          university.SigningKeyCreatedAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
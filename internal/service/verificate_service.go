@@ -15,6 +15,7 @@ type VerificationService interface {
 	CreateVerificationCode(ctx context.Context, code *models.VerificationCode) error
 	GetCodesByUser(ctx context.Context, userID primitive.ObjectID, page, pageSize int64) ([]models.VerificationCodeResponse, int64, error)
 	VerifyCode(ctx context.Context, code, viewType string) (*models.VerificationCode, *models.CertificateResponse, error)
+	GetDisclosure(ctx context.Context, code string) (*models.SelectiveDisclosure, error)
 }
 
 type verificationService struct {
@@ -34,6 +35,13 @@ func (s *verificationService) CreateVerificationCode(ctx context.Context, code *
 	code.Code = generateRandomCode(8)
 	code.CreatedAt = time.Now()
 
+	// Kiểm tra trước văn bằng có thể công bố các trường đã chọn để không tạo mã không dùng được
+	if len(code.DisclosedFields) > 0 {
+		if _, err := s.certificateService.CreateDisclosure(ctx, code.UserID, code.CertificateID, code.DisclosedFields); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.Save(ctx, code)
 }
 
@@ -61,6 +69,7 @@ func (s *verificationService) GetCodesByUser(ctx context.Context, userID primiti
 			CanViewScore:     code.CanViewScore,
 			CanViewData:      code.CanViewData,
 			CanViewFile:      code.CanViewFile,
+			DisclosedFields:  code.DisclosedFields,
 			ExpiredInMinutes: minutesRemaining,
 			CreatedAt:        code.CreatedAt,
 		})
@@ -105,3 +114,18 @@ func (s *verificationService) VerifyCode(ctx context.Context, code, viewType str
 
 	return vc, nil, nil
 }
+
+// GetDisclosure trả các trường sinh viên đã chọn công bố cùng đường chứng minh để bên xác minh đối chiếu với sổ cái
+func (s *verificationService) GetDisclosure(ctx context.Context, code string) (*models.SelectiveDisclosure, error) {
+	vc, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, errors.New("mã không tồn tại")
+	}
+	if time.Now().After(vc.ExpiredAt) {
+		return nil, errors.New("mã đã hết hạn")
+	}
+	if len(vc.DisclosedFields) == 0 {
+		return nil, errors.New("không có quyền xem trường nào của văn bằng")
+	}
+	return s.certificateService.CreateDisclosure(ctx, vc.UserID, vc.CertificateID, vc.DisclosedFields)
+}
@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// circuitBreaker ngắt các lời gọi tới Fabric sau nhiều lỗi kết nối liên tiếp để trả lỗi ngay thay vì chờ hết thời gian chờ.
+// Hết thời gian nghỉ thì cho một lời gọi thử, thành công thì đóng lại.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow trả ErrUnavailable khi mạch đang mở; hết thời gian nghỉ thì chỉ một lời gọi được đi qua để thử
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return fmt.Errorf("%w: tạm ngắt kết nối Fabric sau %d lỗi liên tiếp", ErrUnavailable, b.failures)
+	}
+	b.probing = true
+	return nil
+}
+
+// record ghi nhận kết quả một lời gọi, chỉ lỗi kết nối và quá thời gian mới tính là lỗi của mạng
+func (b *circuitBreaker) record(networkFailure bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !networkFailure {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
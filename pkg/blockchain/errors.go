@@ -0,0 +1,12 @@
+package blockchain
+
+import "errors"
+
+// Lỗi sổ cái đã phân loại, handler dùng errors.Is để trả mã HTTP tương ứng
+var (
+	ErrNotFound          = errors.New("ledger_record_not_found")
+	ErrEndorsementFailed = errors.New("ledger_endorsement_failed")
+	ErrTimeout           = errors.New("ledger_timeout")
+	ErrUnavailable       = errors.New("ledger_unavailable")
+	ErrPrivateDataDenied = errors.New("ledger_private_data_denied") // Tổ chức của định danh không thuộc private data collection
+)
@@ -0,0 +1,269 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fabricCAAlreadyRegistered là mã lỗi Fabric CA trả về khi định danh đã được đăng ký
+const fabricCAAlreadyRegistered = 74
+
+// FabricCAClient đăng ký và cấp chứng thư qua REST API của Fabric CA.
+// Registrar (tài khoản bootstrap của CA) được enroll lần đầu khi cần và chỉ giữ trong bộ nhớ.
+type FabricCAClient struct {
+	url             string
+	caName          string
+	affiliation     string
+	registrarID     string
+	registrarSecret string
+	httpClient      *http.Client
+
+	mu            sync.Mutex
+	registrarCert []byte
+	registrarKey  *ecdsa.PrivateKey
+}
+
+func NewFabricCAClient(cfg *FabricConfig) (*FabricCAClient, error) {
+	if cfg.CARegistrarSecret == "" {
+		return nil, fmt.Errorf("thiếu FABRIC_CA_REGISTRAR_SECRET")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CATLSCertPath != "" {
+		caPEM, err := os.ReadFile(cfg.CATLSCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("lỗi đọc chứng thư TLS của Fabric CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("chứng thư TLS của Fabric CA không hợp lệ")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &FabricCAClient{
+		url:             strings.TrimRight(cfg.CAURL, "/"),
+		caName:          cfg.CAName,
+		affiliation:     cfg.CAAffiliation,
+		registrarID:     cfg.CARegistrarID,
+		registrarSecret: cfg.CARegistrarSecret,
+		httpClient:      &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}, nil
+}
+
+type fabricCAResponse struct {
+	Success bool            `json:"success"`
+	Result  json.RawMessage `json:"result"`
+	Errors  []fabricCAError `json:"errors"`
+}
+
+type fabricCAError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *fabricCAError) Error() string {
+	return fmt.Sprintf("Fabric CA lỗi %d: %s", e.Code, e.Message)
+}
+
+type fabricCAAttribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	ECert bool   `json:"ecert"`
+}
+
+// Enroll đăng ký định danh client mang các thuộc tính rồi enroll bằng CSR.
+// Mật khẩu enroll được suy ra từ registrar secret nên có thể enroll lại khi mất wallet.
+func (c *FabricCAClient) Enroll(enrollmentID string, attrs map[string]string, csrPEM []byte) ([]byte, error) {
+	secret := c.enrollmentSecret(enrollmentID)
+	if err := c.register(enrollmentID, secret, attrs); err != nil {
+		return nil, err
+	}
+	return c.enroll(enrollmentID, secret, csrPEM)
+}
+
+func (c *FabricCAClient) enrollmentSecret(enrollmentID string) string {
+	mac := hmac.New(sha256.New, []byte(c.registrarSecret))
+	mac.Write([]byte(enrollmentID))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+func (c *FabricCAClient) enroll(enrollmentID, secret string, csrPEM []byte) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{
+		"certificate_request": string(csrPEM),
+		"caname":              c.caName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.url+"/api/v1/enroll", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(enrollmentID, secret)
+
+	result, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("enroll %s lỗi: %w", enrollmentID, err)
+	}
+	var enrolled struct {
+		Cert string `json:"Cert"`
+	}
+	if err := json.Unmarshal(result, &enrolled); err != nil {
+		return nil, fmt.Errorf("unmarshal lỗi: %v", err)
+	}
+	certPEM, err := base64.StdEncoding.DecodeString(enrolled.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("chứng thư Fabric CA trả về không hợp lệ: %w", err)
+	}
+	return certPEM, nil
+}
+
+// register đăng ký định danh bằng token của registrar, định danh đã tồn tại thì bỏ qua
+func (c *FabricCAClient) register(enrollmentID, secret string, attrs map[string]string) error {
+	certPEM, key, err := c.registrar()
+	if err != nil {
+		return err
+	}
+
+	attributes := make([]fabricCAAttribute, 0, len(attrs))
+	for name, value := range attrs {
+		attributes = append(attributes, fabricCAAttribute{Name: name, Value: value, ECert: true})
+	}
+	body, err := json.Marshal(map[string]any{
+		"id":          enrollmentID,
+		"type":        "client",
+		"secret":      secret,
+		"affiliation": c.affiliation,
+		"attrs":       attributes,
+		"caname":      c.caName,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.url+"/api/v1/register", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	token, err := fabricCAToken(certPEM, key, req.Method, req.URL.RequestURI(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", token)
+
+	if _, err := c.do(req); err != nil {
+		var caErr *fabricCAError
+		if errors.As(err, &caErr) && caErr.Code == fabricCAAlreadyRegistered {
+			return nil
+		}
+		return fmt.Errorf("đăng ký %s lỗi: %w", enrollmentID, err)
+	}
+	return nil
+}
+
+// registrar enroll tài khoản bootstrap của CA một lần để ký token cho các yêu cầu đăng ký
+func (c *FabricCAClient) registrar() ([]byte, *ecdsa.PrivateKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registrarKey != nil {
+		return c.registrarCert, c.registrarKey, nil
+	}
+
+	keyPEM, csrPEM, err := newEnrollmentRequest(c.registrarID)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM, err := c.enroll(c.registrarID, c.registrarSecret, csrPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parseECPrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.registrarCert, c.registrarKey = certPEM, key
+	return certPEM, key, nil
+}
+
+func (c *FabricCAClient) do(req *http.Request) (json.RawMessage, error) {
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var caResp fabricCAResponse
+	if err := json.Unmarshal(raw, &caResp); err != nil {
+		return nil, fmt.Errorf("Fabric CA trả về HTTP %d không đúng định dạng", resp.StatusCode)
+	}
+	if !caResp.Success {
+		if len(caResp.Errors) > 0 {
+			return nil, &caResp.Errors[0]
+		}
+		return nil, fmt.Errorf("Fabric CA trả về HTTP %d", resp.StatusCode)
+	}
+	return caResp.Result, nil
+}
+
+// fabricCAToken tạo token xác thực theo định dạng của Fabric CA: base64(cert).base64(chữ ký ECDSA)
+// trên chuỗi method.base64(uri).base64(body).base64(cert)
+func fabricCAToken(certPEM []byte, key *ecdsa.PrivateKey, method, uri string, body []byte) (string, error) {
+	b64 := base64.StdEncoding.EncodeToString
+	b64Cert := b64(certPEM)
+	payload := method + "." + b64([]byte(uri)) + "." + b64(body) + "." + b64Cert
+	digest := sha256.Sum256([]byte(payload))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("lỗi ký token Fabric CA: %w", err)
+	}
+	// Fabric chỉ chấp nhận chữ ký low-S
+	halfOrder := new(big.Int).Rsh(key.Curve.Params().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(key.Curve.Params().N, s)
+	}
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return "", err
+	}
+	return b64Cert + "." + b64(sig), nil
+}
+
+func parseECPrivateKeyPEM(keyPEM []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("private key không đúng định dạng PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key không phải ECDSA")
+	}
+	return key, nil
+}
@@ -1,14 +1,24 @@
 package blockchain
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/vnkmasc/Kmasc/app/backend/chaincode/certificate"
 	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+	grpccodes "google.golang.org/grpc/codes"
 )
 
 type FabricConfig struct {
@@ -19,6 +29,24 @@ type FabricConfig struct {
 	Identity      string
 	MSPID         string
 	CredPath      string
+
+	// Định danh của từng trường được cấp bởi Fabric CA, không cấu hình CAURL thì dùng CA cục bộ tại LocalCAPath
+	CAURL             string
+	CAName            string
+	CATLSCertPath     string
+	CARegistrarID     string
+	CARegistrarSecret string
+	CAAffiliation     string
+	LocalCAPath       string
+
+	CallTimeout      time.Duration // Thời gian chờ mặc định của một lời gọi khi ctx không có deadline
+	BreakerThreshold int           // Số lỗi kết nối liên tiếp trước khi ngắt mạch, 0 để tắt
+	BreakerCooldown  time.Duration // Thời gian ngắt mạch trước khi thử lại
+
+	// Dữ liệu riêng của văn bằng ghi vào PrivateCollection, để trống thì chỉ ghi mã băm như trước.
+	// PrivatePeers là peer của các tổ chức thuộc collection, dùng để ghi và đọc dữ liệu riêng; để trống thì SDK tự chọn peer.
+	PrivateCollection string
+	PrivatePeers      []string
 }
 
 func NewFabricConfigFromEnv() *FabricConfig {
@@ -30,6 +58,21 @@ func NewFabricConfigFromEnv() *FabricConfig {
 		Identity:      getEnv("FABRIC_IDENTITY", "admin"),
 		MSPID:         getEnv("FABRIC_MSP_ID", "Org1MSP"),
 		CredPath:      getEnv("FABRIC_ADMIN_CRED_PATH", ""),
+
+		CAURL:             getEnv("FABRIC_CA_URL", ""),
+		CAName:            getEnv("FABRIC_CA_NAME", ""),
+		CATLSCertPath:     getEnv("FABRIC_CA_TLS_CERT", ""),
+		CARegistrarID:     getEnv("FABRIC_CA_REGISTRAR_ID", "admin"),
+		CARegistrarSecret: getEnv("FABRIC_CA_REGISTRAR_SECRET", ""),
+		CAAffiliation:     getEnv("FABRIC_CA_AFFILIATION", ""),
+		LocalCAPath:       getEnv("FABRIC_LOCAL_CA_PATH", "./keys/fabric-ca"),
+
+		CallTimeout:      getDurationEnv("FABRIC_CALL_TIMEOUT", 30*time.Second),
+		BreakerThreshold: getIntEnv("FABRIC_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  getDurationEnv("FABRIC_BREAKER_COOLDOWN", 30*time.Second),
+
+		PrivateCollection: getEnv("FABRIC_PRIVATE_COLLECTION", ""),
+		PrivatePeers:      getListEnv("FABRIC_PRIVATE_PEERS"),
 	}
 }
 
@@ -40,9 +83,39 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+func getListEnv(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func getIntEnv(key string, fallback int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// FabricClient gửi giao dịch dưới một định danh trong wallet; client mặc định dùng FABRIC_IDENTITY,
+// client của từng trường lấy qua ForUniversity. Các client dùng chung bộ ngắt mạch vì cùng một mạng Fabric.
 type FabricClient struct {
-	cfg      *FabricConfig
-	contract *gateway.Contract
+	cfg        *FabricConfig
+	conn       *fabricConnection
+	breaker    *circuitBreaker
+	identities *fabricIdentities
 }
 
 func NewFabricClient(cfg *FabricConfig) (*FabricClient, error) {
@@ -80,46 +153,241 @@ func NewFabricClient(cfg *FabricConfig) (*FabricClient, error) {
 		fmt.Println("✅ Đã import identity vào ví")
 	}
 
-	// Kết nối gateway
+	enroller, err := NewEnroller(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi khởi tạo CA: %w", err)
+	}
+
+	// Kết nối thử lúc khởi động; lỗi thì vẫn tạo client, lời gọi sau sẽ kết nối lại
+	conn := &fabricConnection{cfg: cfg, wallet: wallet, label: cfg.Identity}
+	if _, err := conn.get(); err != nil {
+		log.Printf("Chưa kết nối được Fabric, sẽ thử lại ở lời gọi tiếp theo: %v", err)
+	}
+
+	breaker := newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	return &FabricClient{
+		cfg:     cfg,
+		conn:    conn,
+		breaker: breaker,
+		identities: &fabricIdentities{
+			cfg:      cfg,
+			wallet:   wallet,
+			enroller: enroller,
+			breaker:  breaker,
+			clients:  make(map[string]*FabricClient),
+		},
+	}, nil
+}
+
+// fabricConnection giữ gateway của một định danh; gateway lỗi kết nối thì bị đóng và được tạo lại ở lời gọi sau
+type fabricConnection struct {
+	cfg    *FabricConfig
+	wallet *gateway.Wallet
+	label  string
+
+	mu       sync.Mutex
+	gw       *gateway.Gateway
+	contract *gateway.Contract
+}
+
+func (c *fabricConnection) get() (*gateway.Contract, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.contract != nil {
+		return c.contract, nil
+	}
+
 	gw, err := gateway.Connect(
-		gateway.WithConfig(config.FromFile(filepath.Clean(cfg.CCPPath))),
-		gateway.WithIdentity(wallet, cfg.Identity),
+		gateway.WithConfig(config.FromFile(filepath.Clean(c.cfg.CCPPath))),
+		gateway.WithIdentity(c.wallet, c.label),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("lỗi kết nối gateway: %v", err)
+		return nil, fmt.Errorf("%w: lỗi kết nối gateway: %v", ErrUnavailable, err)
+	}
+	network, err := gw.GetNetwork(c.cfg.ChannelName)
+	if err != nil {
+		gw.Close()
+		return nil, fmt.Errorf("%w: lỗi lấy network: %v", ErrUnavailable, err)
 	}
 
-	network, err := gw.GetNetwork(cfg.ChannelName)
+	c.gw = gw
+	c.contract = network.GetContract(c.cfg.ChaincodeName)
+	return c.contract, nil
+}
+
+// reset đóng gateway nếu contract vẫn là contract đã lỗi, tránh đóng kết nối mới do lời gọi khác vừa tạo
+func (c *fabricConnection) reset(stale *gateway.Contract) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.contract != stale || c.gw == nil {
+		return
+	}
+	c.gw.Close()
+	c.gw = nil
+	c.contract = nil
+}
+
+// ForUniversity trả client gửi giao dịch dưới định danh của trường; trường chưa có định danh trong wallet thì được enroll với CA
+func (fc *FabricClient) ForUniversity(universityCode string) (Ledger, error) {
+	return fc.identities.client(universityCode)
+}
+
+// call gọi chaincode với deadline của ctx (hoặc FABRIC_CALL_TIMEOUT) và phân loại lỗi.
+// SDK không nhận context nên hết hạn thì trả ErrTimeout ngay, giao dịch submit khi đó có thể vẫn được ghi.
+func (fc *FabricClient) call(ctx context.Context, submit bool, opts []gateway.TransactionOption, fn string, args ...string) ([]byte, error) {
+	if err := fc.breaker.allow(); err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Deadline(); !ok && fc.cfg.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fc.cfg.CallTimeout)
+		defer cancel()
+	}
+
+	contract, err := fc.conn.get()
 	if err != nil {
-		return nil, fmt.Errorf("lỗi lấy network: %v", err)
+		fc.breaker.record(true)
+		return nil, err
 	}
 
-	contract := network.GetContract(cfg.ChaincodeName)
+	type result struct {
+		payload []byte
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		txn, err := contract.CreateTransaction(fn, opts...)
+		switch {
+		case err != nil:
+			r.err = err
+		case submit:
+			r.payload, r.err = txn.Submit(args...)
+		default:
+			r.payload, r.err = txn.Evaluate(args...)
+		}
+		done <- r
+	}()
 
-	return &FabricClient{
-		cfg:      cfg,
-		contract: contract,
-	}, nil
+	select {
+	case r := <-done:
+		if r.err == nil {
+			fc.breaker.record(false)
+			return r.payload, nil
+		}
+		err := classifyFabricError(r.err)
+		networkFailure := errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout)
+		if networkFailure {
+			fc.conn.reset(contract)
+		}
+		fc.breaker.record(networkFailure)
+		return nil, fmt.Errorf("invoke %s lỗi: %w", fn, err)
+	case <-ctx.Done():
+		fc.breaker.record(true)
+		fc.conn.reset(contract)
+		return nil, fmt.Errorf("invoke %s lỗi: %w: %v", fn, ErrTimeout, ctx.Err())
+	}
 }
 
-func (fc *FabricClient) IssueCertificate(cert any) (string, error) {
+// classifyFabricError gắn lỗi SDK vào ErrNotFound, ErrEndorsementFailed, ErrTimeout hoặc ErrUnavailable
+func classifyFabricError(err error) error {
+	kind := fabricErrorKind(err)
+	if kind == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %v", kind, err)
+}
+
+func fabricErrorKind(err error) error {
+	msg := err.Error()
+	if strings.Contains(msg, certificate.ErrNotFound.Error()) {
+		return ErrNotFound
+	}
+	if strings.Contains(msg, certificate.ErrPrivateDataDenied.Error()) || strings.Contains(msg, "does not have read access") {
+		return ErrPrivateDataDenied
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Group {
+		case status.GRPCTransportStatus:
+			if grpccodes.Code(s.Code) == grpccodes.DeadlineExceeded {
+				return ErrTimeout
+			}
+			return ErrUnavailable
+		case status.ChaincodeStatus, status.EndorserServerStatus:
+			return ErrEndorsementFailed
+		case status.EndorserClientStatus, status.OrdererClientStatus, status.ClientStatus:
+			switch status.Code(s.Code) {
+			case status.Timeout:
+				return ErrTimeout
+			case status.ConnectionFailed, status.NoPeersFound:
+				return ErrUnavailable
+			case status.MultipleErrors:
+				for _, detail := range s.Details {
+					if detailErr, ok := detail.(error); ok {
+						if kind := fabricErrorKind(detailErr); kind != nil {
+							return kind
+						}
+					}
+				}
+			}
+			return ErrEndorsementFailed
+		case status.OrdererServerStatus, status.EventServerStatus, status.DiscoveryServerStatus:
+			return ErrUnavailable
+		}
+	}
+
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "deadline exceeded"), strings.Contains(lower, "timeout"), strings.Contains(lower, "timed out"):
+		return ErrTimeout
+	case strings.Contains(lower, "endorse"), strings.Contains(lower, "chaincode"):
+		return ErrEndorsementFailed
+	case strings.Contains(lower, "connection"), strings.Contains(lower, "unavailable"):
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// privateDataOptions đưa dữ liệu riêng vào transient map để không nằm trong giao dịch, chưa cấu hình collection thì bỏ qua
+func (fc *FabricClient) privateDataOptions(private *models.CertificatePrivateDetails) ([]gateway.TransactionOption, error) {
+	if private == nil || fc.cfg.PrivateCollection == "" {
+		return nil, nil
+	}
+	raw, err := json.Marshal(private)
+	if err != nil {
+		return nil, fmt.Errorf("marshal lỗi: %v", err)
+	}
+	opts := []gateway.TransactionOption{gateway.WithTransient(map[string][]byte{
+		certificate.TransientPrivateCollection: []byte(fc.cfg.PrivateCollection),
+		certificate.TransientPrivateDetails:    raw,
+	})}
+	if len(fc.cfg.PrivatePeers) > 0 {
+		opts = append(opts, gateway.WithEndorsingPeers(fc.cfg.PrivatePeers...))
+	}
+	return opts, nil
+}
+
+func (fc *FabricClient) IssueCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error) {
 	certBytes, err := json.Marshal(cert)
 	if err != nil {
 		return "", fmt.Errorf("marshal lỗi: %v", err)
 	}
-
-	result, err := fc.contract.SubmitTransaction("IssueCertificate", string(certBytes))
+	opts, err := fc.privateDataOptions(private)
 	if err != nil {
-		return "", fmt.Errorf("invoke IssueCertificate lỗi: %v", err)
+		return "", err
+	}
+	result, err := fc.call(ctx, true, opts, "IssueCertificate", string(certBytes))
+	if err != nil {
+		return "", err
 	}
-
 	return string(result), nil
 }
 
-func (fc *FabricClient) GetCertificateByID(certID string) (*models.CertificateOnChain, error) {
-	result, err := fc.contract.EvaluateTransaction("ReadCertificate", certID)
+func (fc *FabricClient) GetCertificateByID(ctx context.Context, certID string) (*models.CertificateOnChain, error) {
+	result, err := fc.call(ctx, false, nil, "ReadCertificate", certID)
 	if err != nil {
-		return nil, fmt.Errorf("invoke ReadCertificate lỗi: %v", err)
+		return nil, err
 	}
 	var cert models.CertificateOnChain
 	if err := json.Unmarshal(result, &cert); err != nil {
@@ -128,14 +396,84 @@ func (fc *FabricClient) GetCertificateByID(certID string) (*models.CertificateOn
 	return &cert, nil
 }
 
-func (fc *FabricClient) UpdateCertificate(cert any) error {
+func (fc *FabricClient) GetAllCertificates(ctx context.Context) ([]*models.CertificateOnChain, error) {
+	result, err := fc.call(ctx, false, nil, "GetAllCertificates")
+	if err != nil {
+		return nil, err
+	}
+	var certs []*models.CertificateOnChain
+	if err := json.Unmarshal(result, &certs); err != nil {
+		return nil, fmt.Errorf("unmarshal lỗi: %v", err)
+	}
+	return certs, nil
+}
+
+func (fc *FabricClient) UpdateCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error) {
 	certBytes, err := json.Marshal(cert)
 	if err != nil {
-		return fmt.Errorf("marshal lỗi: %v", err)
+		return "", fmt.Errorf("marshal lỗi: %v", err)
 	}
-	_, err = fc.contract.SubmitTransaction("UpdateCertificate", string(certBytes))
+	opts, err := fc.privateDataOptions(private)
 	if err != nil {
-		return fmt.Errorf("invoke UpdateCertificate lỗi: %v", err)
+		return "", err
 	}
-	return nil
+	result, err := fc.call(ctx, true, opts, "UpdateCertificate", string(certBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func (fc *FabricClient) RevokeCertificate(ctx context.Context, certID, reasonCode, decisionNumber, revokedDate string) (string, error) {
+	result, err := fc.call(ctx, true, nil, "RevokeCertificate", certID, reasonCode, decisionNumber, revokedDate)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func (fc *FabricClient) AnchorBatch(ctx context.Context, batch any) (string, error) {
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return "", fmt.Errorf("marshal lỗi: %v", err)
+	}
+	result, err := fc.call(ctx, true, nil, "AnchorBatch", string(batchBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func (fc *FabricClient) GetBatchByID(ctx context.Context, batchID string) (*models.CertificateBatchOnChain, error) {
+	result, err := fc.call(ctx, false, nil, "ReadBatch", batchID)
+	if err != nil {
+		return nil, err
+	}
+	var batch models.CertificateBatchOnChain
+	if err := json.Unmarshal(result, &batch); err != nil {
+		return nil, fmt.Errorf("unmarshal lỗi: %v", err)
+	}
+	return &batch, nil
+}
+
+func (fc *FabricClient) GetCertificateHistory(ctx context.Context, certID string) ([]*models.LedgerHistoryEntry, error) {
+	result, err := fc.call(ctx, false, nil, "GetCertificateHistory", certID)
+	if err != nil {
+		return nil, err
+	}
+	var history []*models.LedgerHistoryEntry
+	if err := json.Unmarshal(result, &history); err != nil {
+		return nil, fmt.Errorf("unmarshal lỗi: %v", err)
+	}
+	return history, nil
+}
+
+// GetCertificatePrivateDetails đọc dữ liệu riêng qua peer thuộc collection (FABRIC_PRIVATE_PEERS).
+// Tổ chức của client không thuộc collection thì chaincode từ chối và trả ErrPrivateDataDenied.
+func (fc *FabricClient) GetCertificatePrivateDetails(ctx context.Context, certID string) ([]byte, error) {
+	var opts []gateway.TransactionOption
+	if len(fc.cfg.PrivatePeers) > 0 {
+		opts = append(opts, gateway.WithEndorsingPeers(fc.cfg.PrivatePeers...))
+	}
+	return fc.call(ctx, false, opts, "ReadCertificatePrivateDetails", certID)
 }
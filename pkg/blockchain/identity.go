@@ -0,0 +1,105 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// UniversityCodeAttribute là thuộc tính trong chứng thư định danh Fabric cho biết định danh thuộc trường nào
+const UniversityCodeAttribute = "university_code"
+
+// Enroller cấp chứng thư X.509 cho CSR của một định danh, cài đặt bởi Fabric CA hoặc CA cục bộ khi phát triển
+type Enroller interface {
+	Enroll(enrollmentID string, attrs map[string]string, csrPEM []byte) ([]byte, error)
+}
+
+// NewEnroller dùng Fabric CA khi có FABRIC_CA_URL, ngược lại dùng CA cục bộ
+func NewEnroller(cfg *FabricConfig) (Enroller, error) {
+	if cfg.CAURL == "" {
+		return NewLocalCA(cfg.LocalCAPath), nil
+	}
+	return NewFabricCAClient(cfg)
+}
+
+// newEnrollmentRequest sinh khóa ECDSA P-256 và CSR cho định danh, khóa bí mật không rời khỏi server
+func newEnrollmentRequest(enrollmentID string) (keyPEM, csrPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi sinh khóa: %w", err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: enrollmentID},
+	}, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi tạo CSR: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi mã hóa private key: %w", err)
+	}
+
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	csrPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+	return keyPEM, csrPEM, nil
+}
+
+// fabricIdentities giữ định danh Fabric của từng trường trong wallet (nhãn là university_code) và client đã kết nối tương ứng
+type fabricIdentities struct {
+	cfg      *FabricConfig
+	wallet   *gateway.Wallet
+	enroller Enroller
+	breaker  *circuitBreaker
+
+	mu      sync.Mutex
+	clients map[string]*FabricClient
+}
+
+func (ids *fabricIdentities) client(universityCode string) (*FabricClient, error) {
+	if universityCode == "" {
+		return nil, fmt.Errorf("thiếu mã trường để chọn định danh Fabric")
+	}
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	if client, ok := ids.clients[universityCode]; ok {
+		return client, nil
+	}
+
+	if !ids.wallet.Exists(universityCode) {
+		if err := ids.enroll(universityCode); err != nil {
+			return nil, err
+		}
+	}
+	client := &FabricClient{
+		cfg:        ids.cfg,
+		conn:       &fabricConnection{cfg: ids.cfg, wallet: ids.wallet, label: universityCode},
+		breaker:    ids.breaker,
+		identities: ids,
+	}
+	ids.clients[universityCode] = client
+	return client, nil
+}
+
+// enroll đăng ký định danh cho trường với CA, chứng thư mang thuộc tính university_code để chaincode xác định bên cấp
+func (ids *fabricIdentities) enroll(universityCode string) error {
+	keyPEM, csrPEM, err := newEnrollmentRequest(universityCode)
+	if err != nil {
+		return err
+	}
+	certPEM, err := ids.enroller.Enroll(universityCode, map[string]string{UniversityCodeAttribute: universityCode}, csrPEM)
+	if err != nil {
+		return fmt.Errorf("không thể đăng ký định danh Fabric cho trường %s: %w", universityCode, err)
+	}
+	identity := gateway.NewX509Identity(ids.cfg.MSPID, string(certPEM), string(keyPEM))
+	if err := ids.wallet.Put(universityCode, identity); err != nil {
+		return fmt.Errorf("lỗi lưu định danh vào wallet: %w", err)
+	}
+	return nil
+}
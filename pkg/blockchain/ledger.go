@@ -0,0 +1,119 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+)
+
+// Ledger là sổ cái lưu văn bằng, được cài đặt bởi FabricClient hoặc sổ cái cục bộ khi phát triển.
+// Lỗi trả về được phân loại bằng ErrNotFound, ErrEndorsementFailed, ErrTimeout, ErrUnavailable và ErrPrivateDataDenied.
+// Dữ liệu riêng truyền vào IssueCertificate, UpdateCertificate được ghi vào private data collection nếu sổ cái có cấu hình collection,
+// bản ghi công khai chỉ giữ mã băm; truyền nil khi không có dữ liệu riêng.
+type Ledger interface {
+	IssueCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error)
+	GetCertificateByID(ctx context.Context, certID string) (*models.CertificateOnChain, error)
+	GetAllCertificates(ctx context.Context) ([]*models.CertificateOnChain, error)
+	UpdateCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error)
+	RevokeCertificate(ctx context.Context, certID, reasonCode, decisionNumber, revokedDate string) (string, error)
+	GetCertificateHistory(ctx context.Context, certID string) ([]*models.LedgerHistoryEntry, error)
+	AnchorBatch(ctx context.Context, batch any) (string, error)
+	GetBatchByID(ctx context.Context, batchID string) (*models.CertificateBatchOnChain, error)
+	// GetCertificatePrivateDetails trả nguyên dữ liệu riêng như đã lưu để bên đọc tự đối chiếu với private_data_hash
+	GetCertificatePrivateDetails(ctx context.Context, certID string) ([]byte, error)
+	// ForUniversity trả sổ cái gửi giao dịch dưới định danh của trường để giao dịch ghi nhận đúng trường cấp
+	ForUniversity(universityCode string) (Ledger, error)
+}
+
+const (
+	LedgerDriverFabric = "fabric"
+	LedgerDriverLocal  = "local"
+)
+
+type LedgerConfig struct {
+	Driver                 string
+	LocalPath              string
+	LocalIdentity          string
+	LocalPrivateCollection string // Tên collection ghi dữ liệu riêng trên sổ cái cục bộ, để trống thì không ghi
+	Fabric                 *FabricConfig
+}
+
+func NewLedgerConfigFromEnv() *LedgerConfig {
+	return &LedgerConfig{
+		Driver:                 strings.ToLower(getEnv("LEDGER_DRIVER", LedgerDriverFabric)),
+		LocalPath:              getEnv("LEDGER_LOCAL_PATH", "./data/ledger.jsonl"),
+		LocalIdentity:          getEnv("LEDGER_LOCAL_IDENTITY", "local"),
+		LocalPrivateCollection: getEnv("LEDGER_LOCAL_PRIVATE_COLLECTION", ""),
+		Fabric:                 NewFabricConfigFromEnv(),
+	}
+}
+
+// NewLedger khởi tạo sổ cái theo cấu hình.
+// Không kết nối được Fabric thì FabricClient tự kết nối lại ở lời gọi sau; chỉ khi cấu hình Fabric sai thì server chạy với sổ cái không khả dụng.
+func NewLedger(cfg *LedgerConfig) (Ledger, error) {
+	switch cfg.Driver {
+	case LedgerDriverLocal:
+		return NewLocalLedger(cfg.LocalPath, cfg.LocalIdentity, cfg.LocalPrivateCollection)
+	case LedgerDriverFabric, "":
+		client, err := NewFabricClient(cfg.Fabric)
+		if err != nil {
+			log.Printf("Không khởi tạo được Fabric, sổ cái không khả dụng: %v", err)
+			return &unavailableLedger{cause: err}, nil
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("LEDGER_DRIVER không hợp lệ: %s", cfg.Driver)
+	}
+}
+
+// unavailableLedger được dùng khi không khởi tạo được Fabric lúc khởi động
+type unavailableLedger struct {
+	cause error
+}
+
+func (l *unavailableLedger) err() error {
+	return fmt.Errorf("%w: %v", ErrUnavailable, l.cause)
+}
+
+func (l *unavailableLedger) IssueCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error) {
+	return "", l.err()
+}
+
+func (l *unavailableLedger) GetCertificateByID(ctx context.Context, certID string) (*models.CertificateOnChain, error) {
+	return nil, l.err()
+}
+
+func (l *unavailableLedger) GetAllCertificates(ctx context.Context) ([]*models.CertificateOnChain, error) {
+	return nil, l.err()
+}
+
+func (l *unavailableLedger) UpdateCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error) {
+	return "", l.err()
+}
+
+func (l *unavailableLedger) RevokeCertificate(ctx context.Context, certID, reasonCode, decisionNumber, revokedDate string) (string, error) {
+	return "", l.err()
+}
+
+func (l *unavailableLedger) GetCertificateHistory(ctx context.Context, certID string) ([]*models.LedgerHistoryEntry, error) {
+	return nil, l.err()
+}
+
+func (l *unavailableLedger) AnchorBatch(ctx context.Context, batch any) (string, error) {
+	return "", l.err()
+}
+
+func (l *unavailableLedger) GetBatchByID(ctx context.Context, batchID string) (*models.CertificateBatchOnChain, error) {
+	return nil, l.err()
+}
+
+func (l *unavailableLedger) GetCertificatePrivateDetails(ctx context.Context, certID string) ([]byte, error) {
+	return nil, l.err()
+}
+
+func (l *unavailableLedger) ForUniversity(universityCode string) (Ledger, error) {
+	return nil, l.err()
+}
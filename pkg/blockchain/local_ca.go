@@ -0,0 +1,198 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fabricAttributesOID là extension Fabric CA dùng để nhúng thuộc tính vào chứng thư, chaincode đọc qua thư viện cid
+var fabricAttributesOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// LocalCA là CA tự quản thay Fabric CA khi phát triển.
+// Chứng thư ca-cert.pem phải được thêm vào cacerts của MSP thì peer mới chấp nhận định danh do CA này cấp.
+type LocalCA struct {
+	dir string
+
+	mu   sync.Mutex
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func NewLocalCA(dir string) *LocalCA {
+	return &LocalCA{dir: dir}
+}
+
+func (ca *LocalCA) certPath() string {
+	return filepath.Join(ca.dir, "ca-cert.pem")
+}
+
+func (ca *LocalCA) keyPath() string {
+	return filepath.Join(ca.dir, "ca-key.pem")
+}
+
+// load đọc khóa và chứng thư CA, lần đầu chạy thì tạo mới
+func (ca *LocalCA) load() error {
+	if ca.cert != nil {
+		return nil
+	}
+	certPEM, err := os.ReadFile(ca.certPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return ca.create()
+	}
+	if err != nil {
+		return fmt.Errorf("lỗi đọc chứng thư CA: %w", err)
+	}
+	keyPEM, err := os.ReadFile(ca.keyPath())
+	if err != nil {
+		return fmt.Errorf("lỗi đọc khóa CA: %w", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return fmt.Errorf("chứng thư hoặc khóa CA không đúng định dạng PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("lỗi đọc chứng thư CA: %w", err)
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("lỗi đọc khóa CA: %w", err)
+	}
+	ca.cert, ca.key = cert, key
+	return nil
+}
+
+func (ca *LocalCA) create() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("lỗi sinh khóa CA: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "local-ca", Organization: []string{"certificate-management-system"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          subjectKeyID(&key.PublicKey),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("lỗi tạo chứng thư CA: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("lỗi mã hóa khóa CA: %w", err)
+	}
+
+	if err := os.MkdirAll(ca.dir, 0o700); err != nil {
+		return fmt.Errorf("lỗi tạo thư mục CA: %w", err)
+	}
+	if err := os.WriteFile(ca.keyPath(), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return fmt.Errorf("lỗi lưu khóa CA: %w", err)
+	}
+	if err := os.WriteFile(ca.certPath(), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return fmt.Errorf("lỗi lưu chứng thư CA: %w", err)
+	}
+	ca.cert, ca.key = cert, key
+	return nil
+}
+
+// Enroll ký CSR thành chứng thư client theo định dạng của Fabric CA: OU=client và thuộc tính nhúng trong extension
+func (ca *LocalCA) Enroll(enrollmentID string, attrs map[string]string, csrPEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, fmt.Errorf("CSR không đúng định dạng PEM")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc CSR: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("chữ ký CSR không hợp lệ: %w", err)
+	}
+	pub, ok := csr.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("CSR phải dùng khóa ECDSA")
+	}
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if err := ca.load(); err != nil {
+		return nil, err
+	}
+
+	attrValues := map[string]string{"hf.EnrollmentID": enrollmentID, "hf.Type": "client"}
+	for name, value := range attrs {
+		attrValues[name] = value
+	}
+	attrJSON, err := json.Marshal(map[string]any{"attrs": attrValues})
+	if err != nil {
+		return nil, err
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: enrollmentID, OrganizationalUnit: []string{"client"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		SubjectKeyId:          subjectKeyID(pub),
+		ExtraExtensions:       []pkix.Extension{{Id: fabricAttributesOID, Value: attrJSON}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi cấp chứng thư: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("lỗi sinh serial: %w", err)
+	}
+	return serial, nil
+}
+
+// subjectKeyID tính SKI theo cách của Fabric: SHA-256 của điểm công khai dạng không nén
+func subjectKeyID(pub *ecdsa.PublicKey) []byte {
+	point, err := pub.ECDH()
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(point.Bytes())
+	return sum[:]
+}
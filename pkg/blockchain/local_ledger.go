@@ -0,0 +1,483 @@
+package blockchain
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+)
+
+const (
+	localRecordCertificate = "certificate"
+	localRecordBatch       = "batch"
+)
+
+// localRecord là một giao dịch trong file sổ cái cục bộ, mỗi bản ghi nối mã băm với bản ghi trước
+type localRecord struct {
+	TxID      string          `json:"tx_id"`
+	PrevTxID  string          `json:"prev_tx_id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Kind      string          `json:"kind"`
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+}
+
+func (r *localRecord) computeTxID() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s|", r.PrevTxID, r.Kind, r.Key, r.Timestamp.UTC().Format(time.RFC3339Nano))
+	h.Write(r.Value)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// localPrivateRecord là một lần ghi dữ liệu riêng, lưu ở file riêng vì file sổ cái chỉ giữ mã băm như kênh công khai của Fabric
+type localPrivateRecord struct {
+	Collection string          `json:"collection"`
+	Key        string          `json:"key"`
+	Value      json.RawMessage `json:"value"`
+}
+
+// LocalLedger là sổ cái chỉ ghi thêm trên file JSON Lines, dùng thay Fabric khi phát triển và chạy offline
+type LocalLedger struct {
+	*localStore
+	identity       string // Danh tính ghi vào submitted_by của các giao dịch gửi qua sổ cái này
+	universityCode string // Trường được ghi dữ liệu qua sổ cái này, như thuộc tính university_code của định danh Fabric
+}
+
+// localStore là trạng thái dùng chung giữa sổ cái mặc định và sổ cái của từng trường
+type localStore struct {
+	mu       sync.RWMutex
+	file     *os.File
+	lastTxID string
+	certs    map[string]*models.CertificateOnChain
+	batches  map[string]*models.CertificateBatchOnChain
+	history  map[string][]*models.LedgerHistoryEntry
+	serials  map[string]string // Chỉ mục số hiệu theo trường giống chaincode, khóa là mã trường và số hiệu, giá trị là cert_id
+
+	// Sổ cái cục bộ là một nút duy nhất nên thuộc mọi collection có dữ liệu trong file dữ liệu riêng
+	privateCollection string
+	privateFile       *os.File
+	private           map[string][]byte // Khóa là collection và cert_id
+}
+
+func NewLocalLedger(path, identity, privateCollection string) (*LocalLedger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("không thể tạo thư mục sổ cái cục bộ: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("không thể mở sổ cái cục bộ: %w", err)
+	}
+
+	store := &localStore{
+		file:              file,
+		certs:             make(map[string]*models.CertificateOnChain),
+		batches:           make(map[string]*models.CertificateBatchOnChain),
+		history:           make(map[string][]*models.LedgerHistoryEntry),
+		serials:           make(map[string]string),
+		privateCollection: privateCollection,
+		private:           make(map[string][]byte),
+	}
+	if err := store.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if privateCollection != "" {
+		if err := store.openPrivate(localPrivatePath(path)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return &LocalLedger{localStore: store, identity: identity}, nil
+}
+
+// localPrivatePath đặt file dữ liệu riêng cạnh file sổ cái, ví dụ ledger.jsonl thành ledger.private.jsonl
+func localPrivatePath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".private" + ext
+}
+
+func (l *localStore) openPrivate(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("không thể mở file dữ liệu riêng của sổ cái cục bộ: %w", err)
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec localPrivateRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			file.Close()
+			return fmt.Errorf("file dữ liệu riêng lỗi ở dòng %d: %w", line, err)
+		}
+		l.private[localPrivateKey(rec.Collection, rec.Key)] = rec.Value
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return err
+	}
+	l.privateFile = file
+	return nil
+}
+
+func localPrivateKey(collection, certID string) string {
+	return collection + "/" + certID
+}
+
+// putPrivate ghi dữ liệu riêng trước bản ghi công khai rồi đặt collection và mã băm vào bản ghi, phải giữ khóa ghi khi gọi
+func (l *localStore) putPrivate(cert *models.CertificateOnChain, private *models.CertificatePrivateDetails) error {
+	cert.PrivateCollection = ""
+	cert.PrivateDataHash = ""
+	if private == nil || l.privateFile == nil {
+		return nil
+	}
+	if private.CertID != cert.CertID {
+		return fmt.Errorf("dữ liệu riêng không thuộc văn bằng %s", cert.CertID)
+	}
+	raw, err := json.Marshal(private)
+	if err != nil {
+		return fmt.Errorf("marshal lỗi: %v", err)
+	}
+	line, err := json.Marshal(&localPrivateRecord{Collection: l.privateCollection, Key: cert.CertID, Value: raw})
+	if err != nil {
+		return fmt.Errorf("marshal lỗi: %v", err)
+	}
+	if _, err := l.privateFile.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("không thể ghi dữ liệu riêng: %w", err)
+	}
+	if err := l.privateFile.Sync(); err != nil {
+		return fmt.Errorf("không thể ghi dữ liệu riêng: %w", err)
+	}
+	l.private[localPrivateKey(l.privateCollection, cert.CertID)] = raw
+	cert.PrivateCollection = l.privateCollection
+	cert.PrivateDataHash = privateDataHash(raw)
+	return nil
+}
+
+func privateDataHash(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// ForUniversity trả sổ cái dùng chung file nhưng ghi mã trường làm định danh gửi giao dịch
+func (l *LocalLedger) ForUniversity(universityCode string) (Ledger, error) {
+	if universityCode == "" {
+		return nil, fmt.Errorf("thiếu mã trường để chọn định danh sổ cái")
+	}
+	return &LocalLedger{localStore: l.localStore, identity: universityCode, universityCode: universityCode}, nil
+}
+
+// authorizeIssuer giữ quy tắc của chaincode: chỉ sổ cái của trường ghi được dữ liệu của trường đó,
+// sổ cái mặc định không gắn trường nên chỉ dùng để đọc
+func (l *LocalLedger) authorizeIssuer(universityCode string) error {
+	if universityCode == "" {
+		return fmt.Errorf("%w: thiếu university_code", ErrEndorsementFailed)
+	}
+	if l.universityCode != universityCode {
+		return fmt.Errorf("%w: định danh %s không được ghi dữ liệu của trường %s", ErrEndorsementFailed, l.identity, universityCode)
+	}
+	return nil
+}
+
+func localSerialKey(universityCode, serial string) string {
+	return universityCode + "/" + serial
+}
+
+// checkSerial từ chối số hiệu đã thuộc văn bằng khác của cùng trường như claimSerial của chaincode, phải giữ khóa khi gọi.
+// Số hiệu cũ khi đổi vẫn nằm trong chỉ mục nên không được cấp lại.
+func (l *localStore) checkSerial(cert *models.CertificateOnChain) error {
+	if cert.SerialNumber == "" {
+		return nil
+	}
+	owner, ok := l.serials[localSerialKey(cert.UniversityCode, cert.SerialNumber)]
+	if ok && owner != cert.CertID {
+		return fmt.Errorf("%w: số hiệu %s của trường %s đã được cấp cho văn bằng %s", ErrEndorsementFailed, cert.SerialNumber, cert.UniversityCode, owner)
+	}
+	return nil
+}
+
+// replay đọc lại toàn bộ file và kiểm tra chuỗi mã băm, file bị sửa thì từ chối khởi động
+func (l *localStore) replay() error {
+	scanner := bufio.NewScanner(l.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec localRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("sổ cái cục bộ lỗi ở dòng %d: %w", line, err)
+		}
+		if rec.PrevTxID != l.lastTxID || rec.computeTxID() != rec.TxID {
+			return fmt.Errorf("sổ cái cục bộ bị thay đổi ở dòng %d", line)
+		}
+		if err := l.apply(&rec); err != nil {
+			return fmt.Errorf("sổ cái cục bộ lỗi ở dòng %d: %w", line, err)
+		}
+		l.lastTxID = rec.TxID
+	}
+	return scanner.Err()
+}
+
+func (l *localStore) apply(rec *localRecord) error {
+	switch rec.Kind {
+	case localRecordCertificate:
+		var cert models.CertificateOnChain
+		if err := json.Unmarshal(rec.Value, &cert); err != nil {
+			return err
+		}
+		l.certs[rec.Key] = &cert
+		if cert.SerialNumber != "" {
+			l.serials[localSerialKey(cert.UniversityCode, cert.SerialNumber)] = cert.CertID
+		}
+		value := cert
+		l.history[rec.Key] = append(l.history[rec.Key], &models.LedgerHistoryEntry{
+			TxID:      rec.TxID,
+			Timestamp: rec.Timestamp,
+			Value:     &value,
+		})
+	case localRecordBatch:
+		var batch models.CertificateBatchOnChain
+		if err := json.Unmarshal(rec.Value, &batch); err != nil {
+			return err
+		}
+		l.batches[rec.Key] = &batch
+	default:
+		return fmt.Errorf("loại bản ghi không hợp lệ: %s", rec.Kind)
+	}
+	return nil
+}
+
+// commit ghi một bản ghi mới xuống file rồi mới cập nhật trạng thái trong bộ nhớ, phải giữ khóa ghi khi gọi
+func (l *localStore) commit(kind, key string, value any) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("marshal lỗi: %v", err)
+	}
+	rec := &localRecord{
+		PrevTxID:  l.lastTxID,
+		Timestamp: time.Now().UTC(),
+		Kind:      kind,
+		Key:       key,
+		Value:     raw,
+	}
+	rec.TxID = rec.computeTxID()
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return "", fmt.Errorf("marshal lỗi: %v", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return "", fmt.Errorf("không thể ghi sổ cái cục bộ: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return "", fmt.Errorf("không thể ghi sổ cái cục bộ: %w", err)
+	}
+	if err := l.apply(rec); err != nil {
+		return "", err
+	}
+	l.lastTxID = rec.TxID
+	return rec.TxID, nil
+}
+
+func toCertificateOnChain(cert any) (*models.CertificateOnChain, error) {
+	raw, err := json.Marshal(cert)
+	if err != nil {
+		return nil, fmt.Errorf("marshal lỗi: %v", err)
+	}
+	var onChain models.CertificateOnChain
+	if err := json.Unmarshal(raw, &onChain); err != nil {
+		return nil, fmt.Errorf("unmarshal lỗi: %v", err)
+	}
+	if onChain.CertID == "" {
+		return nil, fmt.Errorf("thiếu cert_id")
+	}
+	return &onChain, nil
+}
+
+func (l *LocalLedger) IssueCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error) {
+	onChain, err := toCertificateOnChain(cert)
+	if err != nil {
+		return "", err
+	}
+	if err := l.authorizeIssuer(onChain.UniversityCode); err != nil {
+		return "", err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.certs[onChain.CertID]; ok {
+		return "", fmt.Errorf("%w: văn bằng %s đã tồn tại trên sổ cái", ErrEndorsementFailed, onChain.CertID)
+	}
+	if err := l.checkSerial(onChain); err != nil {
+		return "", err
+	}
+	if err := l.putPrivate(onChain, private); err != nil {
+		return "", err
+	}
+	onChain.SubmittedBy = l.identity
+	return l.commit(localRecordCertificate, onChain.CertID, onChain)
+}
+
+func (l *LocalLedger) GetCertificateByID(ctx context.Context, certID string) (*models.CertificateOnChain, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	cert, ok := l.certs[certID]
+	if !ok {
+		return nil, fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, certID)
+	}
+	copied := *cert
+	return &copied, nil
+}
+
+func (l *LocalLedger) GetAllCertificates(ctx context.Context) ([]*models.CertificateOnChain, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	certs := make([]*models.CertificateOnChain, 0, len(l.certs))
+	for _, cert := range l.certs {
+		copied := *cert
+		certs = append(certs, &copied)
+	}
+	return certs, nil
+}
+
+func (l *LocalLedger) UpdateCertificate(ctx context.Context, cert any, private *models.CertificatePrivateDetails) (string, error) {
+	onChain, err := toCertificateOnChain(cert)
+	if err != nil {
+		return "", err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current, ok := l.certs[onChain.CertID]
+	if !ok {
+		return "", fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, onChain.CertID)
+	}
+	if current.UniversityCode != "" && onChain.UniversityCode != current.UniversityCode {
+		return "", fmt.Errorf("%w: không được đổi trường cấp của văn bằng %s", ErrEndorsementFailed, onChain.CertID)
+	}
+	if err := l.authorizeIssuer(onChain.UniversityCode); err != nil {
+		return "", err
+	}
+	if current.Revoked {
+		return "", fmt.Errorf("%w: văn bằng %s đã bị thu hồi", ErrEndorsementFailed, onChain.CertID)
+	}
+	if err := l.checkSerial(onChain); err != nil {
+		return "", err
+	}
+	if err := l.putPrivate(onChain, private); err != nil {
+		return "", err
+	}
+	onChain.SubmittedBy = l.identity
+	return l.commit(localRecordCertificate, onChain.CertID, onChain)
+}
+
+func (l *LocalLedger) RevokeCertificate(ctx context.Context, certID, reasonCode, decisionNumber, revokedDate string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current, ok := l.certs[certID]
+	if !ok {
+		return "", fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, certID)
+	}
+	if err := l.authorizeIssuer(current.UniversityCode); err != nil {
+		return "", err
+	}
+	if current.Revoked {
+		return "", fmt.Errorf("%w: văn bằng %s đã bị thu hồi", ErrEndorsementFailed, certID)
+	}
+	if reasonCode == "" || revokedDate == "" {
+		return "", fmt.Errorf("%w: thiếu mã lý do hoặc ngày thu hồi", ErrEndorsementFailed)
+	}
+	revoked := *current
+	revoked.Revoked = true
+	revoked.RevokeReasonCode = reasonCode
+	revoked.RevokedDate = revokedDate
+	revoked.SubmittedBy = l.identity
+	return l.commit(localRecordCertificate, certID, &revoked)
+}
+
+func (l *LocalLedger) GetCertificateHistory(ctx context.Context, certID string) ([]*models.LedgerHistoryEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	entries, ok := l.history[certID]
+	if !ok {
+		return nil, fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, certID)
+	}
+	history := make([]*models.LedgerHistoryEntry, len(entries))
+	copy(history, entries)
+	return history, nil
+}
+
+func (l *LocalLedger) AnchorBatch(ctx context.Context, batch any) (string, error) {
+	raw, err := json.Marshal(batch)
+	if err != nil {
+		return "", fmt.Errorf("marshal lỗi: %v", err)
+	}
+	var onChain models.CertificateBatchOnChain
+	if err := json.Unmarshal(raw, &onChain); err != nil {
+		return "", fmt.Errorf("unmarshal lỗi: %v", err)
+	}
+	if onChain.BatchID == "" || onChain.MerkleRoot == "" || onChain.UniversityCode == "" {
+		return "", fmt.Errorf("%w: thiếu batch_id, merkle_root hoặc university_code", ErrEndorsementFailed)
+	}
+	if err := l.authorizeIssuer(onChain.UniversityCode); err != nil {
+		return "", err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.batches[onChain.BatchID]; ok {
+		return "", fmt.Errorf("%w: lô %s đã tồn tại trên sổ cái", ErrEndorsementFailed, onChain.BatchID)
+	}
+	onChain.SubmittedBy = l.identity
+	return l.commit(localRecordBatch, onChain.BatchID, &onChain)
+}
+
+func (l *LocalLedger) GetBatchByID(ctx context.Context, batchID string) (*models.CertificateBatchOnChain, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	batch, ok := l.batches[batchID]
+	if !ok {
+		return nil, fmt.Errorf("%w: không tìm thấy lô %s trên sổ cái", ErrNotFound, batchID)
+	}
+	copied := *batch
+	return &copied, nil
+}
+
+func (l *LocalLedger) GetCertificatePrivateDetails(ctx context.Context, certID string) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	cert, ok := l.certs[certID]
+	if !ok {
+		return nil, fmt.Errorf("%w: không tìm thấy văn bằng %s trên sổ cái", ErrNotFound, certID)
+	}
+	if cert.PrivateCollection == "" {
+		return nil, fmt.Errorf("%w: văn bằng %s không có dữ liệu riêng trên sổ cái", ErrNotFound, certID)
+	}
+	raw, ok := l.private[localPrivateKey(cert.PrivateCollection, certID)]
+	if !ok {
+		return nil, fmt.Errorf("%w: không có dữ liệu riêng của văn bằng %s trong collection %s", ErrPrivateDataDenied, certID, cert.PrivateCollection)
+	}
+	copied := make([]byte, len(raw))
+	copy(copied, raw)
+	return copied, nil
+}
+
+func (l *LocalLedger) Close() error {
+	if l.privateFile != nil {
+		l.privateFile.Close()
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,326 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vnkmasc/Kmasc/app/backend/internal/models"
+)
+
+const testLocalCollection = "certificatePrivateDetails"
+
+func newTestLocalLedger(t *testing.T, path string) *LocalLedger {
+	t.Helper()
+	ledger, err := NewLocalLedger(path, "local", testLocalCollection)
+	if err != nil {
+		t.Fatalf("NewLocalLedger: %v", err)
+	}
+	t.Cleanup(func() { ledger.Close() })
+	return ledger
+}
+
+func forUniversity(t *testing.T, ledger *LocalLedger, universityCode string) Ledger {
+	t.Helper()
+	scoped, err := ledger.ForUniversity(universityCode)
+	if err != nil {
+		t.Fatalf("ForUniversity: %v", err)
+	}
+	return scoped
+}
+
+func newLocalCertificate(certID, universityCode, serial string) *models.CertificateOnChain {
+	return &models.CertificateOnChain{
+		CertID:         certID,
+		UniversityCode: universityCode,
+		CertHash:       "hash-" + certID,
+		SerialNumber:   serial,
+		Version:        1,
+	}
+}
+
+func TestLocalLedgerReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ledger := newTestLocalLedger(t, path)
+	kma := forUniversity(t, ledger, "KMA")
+
+	private := &models.CertificatePrivateDetails{CertID: "cert-1", Fields: map[string]string{models.DisclosureFieldStudentName: "Nguyễn Văn A"}, Salt: "00ff"}
+	if _, err := kma.IssueCertificate(ctx, newLocalCertificate("cert-1", "KMA", "S001"), private); err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+	update := newLocalCertificate("cert-1", "KMA", "S001")
+	update.Version = 2
+	if _, err := kma.UpdateCertificate(ctx, update, private); err != nil {
+		t.Fatalf("UpdateCertificate: %v", err)
+	}
+	if _, err := kma.RevokeCertificate(ctx, "cert-1", "fraud", "QD-01", "2025-01-01"); err != nil {
+		t.Fatalf("RevokeCertificate: %v", err)
+	}
+	batchTxID, err := kma.AnchorBatch(ctx, &models.CertificateBatchOnChain{BatchID: "batch-1", UniversityCode: "KMA", MerkleRoot: "root"})
+	if err != nil {
+		t.Fatalf("AnchorBatch: %v", err)
+	}
+	wantHistory, err := ledger.GetCertificateHistory(ctx, "cert-1")
+	if err != nil {
+		t.Fatalf("GetCertificateHistory: %v", err)
+	}
+	ledger.Close()
+
+	reopened := newTestLocalLedger(t, path)
+	if reopened.lastTxID != batchTxID {
+		t.Fatalf("lastTxID sau khi đọc lại = %s, muốn %s", reopened.lastTxID, batchTxID)
+	}
+	cert, err := reopened.GetCertificateByID(ctx, "cert-1")
+	if err != nil {
+		t.Fatalf("GetCertificateByID: %v", err)
+	}
+	if !cert.Revoked || cert.Version != 2 || cert.SubmittedBy != "KMA" {
+		t.Fatalf("văn bằng đọc lại = %+v", cert)
+	}
+	history, err := reopened.GetCertificateHistory(ctx, "cert-1")
+	if err != nil {
+		t.Fatalf("GetCertificateHistory: %v", err)
+	}
+	if len(history) != len(wantHistory) {
+		t.Fatalf("số bản ghi lịch sử = %d, muốn %d", len(history), len(wantHistory))
+	}
+	for i := range history {
+		if history[i].TxID != wantHistory[i].TxID {
+			t.Fatalf("lịch sử[%d].tx_id = %s, muốn %s", i, history[i].TxID, wantHistory[i].TxID)
+		}
+	}
+	if _, err := reopened.GetBatchByID(ctx, "batch-1"); err != nil {
+		t.Fatalf("GetBatchByID: %v", err)
+	}
+	raw, err := reopened.GetCertificatePrivateDetails(ctx, "cert-1")
+	if err != nil {
+		t.Fatalf("GetCertificatePrivateDetails: %v", err)
+	}
+	if privateDataHash(raw) != cert.PrivateDataHash {
+		t.Fatal("dữ liệu riêng đọc lại không khớp mã băm trên sổ cái")
+	}
+
+	// Chỉ mục số hiệu được dựng lại khi đọc file
+	if _, err := forUniversity(t, reopened, "KMA").IssueCertificate(ctx, newLocalCertificate("cert-2", "KMA", "S001"), nil); !errors.Is(err, ErrEndorsementFailed) {
+		t.Fatalf("lỗi = %v, muốn từ chối số hiệu trùng sau khi đọc lại", err)
+	}
+	// Giao dịch mới nối tiếp chuỗi mã băm của file cũ
+	if _, err := forUniversity(t, reopened, "KMA").IssueCertificate(ctx, newLocalCertificate("cert-3", "KMA", "S003"), nil); err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+	reopened.Close()
+	newTestLocalLedger(t, path)
+}
+
+func TestLocalLedgerDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+	}{
+		{"sửa nội dung giao dịch", func(lines []string) []string {
+			lines[0] = strings.Replace(lines[0], "hash-cert-1", "hash-forged", 1)
+			return lines
+		}},
+		{"xóa giao dịch", func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}},
+		{"đảo thứ tự giao dịch", func(lines []string) []string {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "ledger.jsonl")
+			ledger, err := NewLocalLedger(path, "local", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			kma := forUniversity(t, ledger, "KMA")
+			for _, certID := range []string{"cert-1", "cert-2", "cert-3"} {
+				if _, err := kma.IssueCertificate(ctx, newLocalCertificate(certID, "KMA", ""), nil); err != nil {
+					t.Fatalf("IssueCertificate: %v", err)
+				}
+			}
+			ledger.Close()
+
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(raw)), "\n"))
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if ledger, err := NewLocalLedger(path, "local", ""); err == nil {
+				ledger.Close()
+				t.Fatal("sổ cái bị sửa vẫn được mở")
+			} else if !strings.Contains(err.Error(), "bị thay đổi") {
+				t.Fatalf("lỗi = %v, muốn báo sổ cái bị thay đổi", err)
+			}
+		})
+	}
+}
+
+func TestLocalLedgerWriteRules(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		write   func(ledger *LocalLedger) error
+		wantErr error
+		errText string
+	}{
+		{
+			name: "cấp văn bằng của trường mình",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "KMA").IssueCertificate(ctx, newLocalCertificate("cert-2", "KMA", "S002"), nil)
+				return err
+			},
+		},
+		{
+			name: "cấp văn bằng của trường khác",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "HUST").IssueCertificate(ctx, newLocalCertificate("cert-2", "KMA", "S002"), nil)
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "không được ghi dữ liệu của trường KMA",
+		},
+		{
+			name: "sổ cái mặc định không gắn trường",
+			write: func(l *LocalLedger) error {
+				_, err := l.IssueCertificate(ctx, newLocalCertificate("cert-2", "KMA", "S002"), nil)
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "không được ghi dữ liệu của trường KMA",
+		},
+		{
+			name: "văn bằng thiếu university_code",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "KMA").IssueCertificate(ctx, newLocalCertificate("cert-2", "", "S002"), nil)
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "thiếu university_code",
+		},
+		{
+			name: "văn bằng đã tồn tại",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "KMA").IssueCertificate(ctx, newLocalCertificate("cert-1", "KMA", "S002"), nil)
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "đã tồn tại",
+		},
+		{
+			name: "số hiệu trùng trong trường",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "KMA").IssueCertificate(ctx, newLocalCertificate("cert-2", "KMA", "S001"), nil)
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "đã được cấp cho văn bằng cert-1",
+		},
+		{
+			name: "cùng số hiệu ở trường khác",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "HUST").IssueCertificate(ctx, newLocalCertificate("cert-2", "HUST", "S001"), nil)
+				return err
+			},
+		},
+		{
+			name: "cập nhật giữ số hiệu của chính văn bằng",
+			write: func(l *LocalLedger) error {
+				update := newLocalCertificate("cert-1", "KMA", "S001")
+				update.Version = 2
+				_, err := forUniversity(t, l, "KMA").UpdateCertificate(ctx, update, nil)
+				return err
+			},
+		},
+		{
+			name: "cập nhật đổi trường cấp",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "HUST").UpdateCertificate(ctx, newLocalCertificate("cert-1", "HUST", "S001"), nil)
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "không được đổi trường cấp",
+		},
+		{
+			name: "cập nhật văn bằng không tồn tại",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "KMA").UpdateCertificate(ctx, newLocalCertificate("cert-9", "KMA", ""), nil)
+				return err
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "thu hồi bởi trường khác",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "HUST").RevokeCertificate(ctx, "cert-1", "fraud", "QD-01", "2025-01-01")
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "không được ghi dữ liệu của trường KMA",
+		},
+		{
+			name: "thu hồi thiếu lý do",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "KMA").RevokeCertificate(ctx, "cert-1", "", "QD-01", "2025-01-01")
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "thiếu mã lý do",
+		},
+		{
+			name: "cập nhật văn bằng đã thu hồi",
+			write: func(l *LocalLedger) error {
+				kma := forUniversity(t, l, "KMA")
+				if _, err := kma.RevokeCertificate(ctx, "cert-1", "fraud", "QD-01", "2025-01-01"); err != nil {
+					return err
+				}
+				_, err := kma.UpdateCertificate(ctx, newLocalCertificate("cert-1", "KMA", "S001"), nil)
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "đã bị thu hồi",
+		},
+		{
+			name: "neo lô của trường khác",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "HUST").AnchorBatch(ctx, &models.CertificateBatchOnChain{BatchID: "batch-1", UniversityCode: "KMA", MerkleRoot: "root"})
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "không được ghi dữ liệu của trường KMA",
+		},
+		{
+			name: "neo lô thiếu university_code",
+			write: func(l *LocalLedger) error {
+				_, err := forUniversity(t, l, "KMA").AnchorBatch(ctx, &models.CertificateBatchOnChain{BatchID: "batch-1", MerkleRoot: "root"})
+				return err
+			},
+			wantErr: ErrEndorsementFailed, errText: "thiếu batch_id, merkle_root hoặc university_code",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := newTestLocalLedger(t, filepath.Join(t.TempDir(), "ledger.jsonl"))
+			if _, err := forUniversity(t, ledger, "KMA").IssueCertificate(ctx, newLocalCertificate("cert-1", "KMA", "S001"), nil); err != nil {
+				t.Fatalf("IssueCertificate: %v", err)
+			}
+			lastTxID := ledger.lastTxID
+
+			err := tt.write(ledger)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("lỗi = %v, muốn nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.errText) {
+				t.Fatalf("lỗi = %v, muốn %v chứa %q", err, tt.wantErr, tt.errText)
+			}
+			if tt.errText != "đã bị thu hồi" && ledger.lastTxID != lastTxID {
+				t.Fatal("giao dịch bị từ chối vẫn được ghi vào sổ cái")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package credential
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ContextV1                = "https://www.w3.org/2018/credentials/v1"
+	TypeVerifiableCredential = "VerifiableCredential"
+	// MediaTypeJWT là kiểu nội dung của VC dạng JWT theo VC-JWT
+	MediaTypeJWT = "application/vc+jwt"
+)
+
+var ErrInvalidProof = errors.New("invalid_credential_proof")
+
+// Claims là payload JWT-VC theo VC Data Model 1.1 mục 6.3.1, credential JSON-LD nằm trong claim vc
+type Claims struct {
+	jwt.RegisteredClaims
+	VC json.RawMessage `json:"vc"`
+}
+
+// Sign ký credential bằng ES256 với khóa của trường; kid trỏ tới nơi công bố chứng thư khóa công khai
+func Sign(key *ecdsa.PrivateKey, keyID string, claims *Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = keyID
+	token.Header["typ"] = "JWT"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("lỗi ký credential: %w", err)
+	}
+	return signed, nil
+}
+
+// ParseUnverified đọc payload khi chưa biết khóa của bên phát hành, chỉ dùng để tìm bên phát hành
+func ParseUnverified(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenStr, claims); err != nil {
+		return nil, fmt.Errorf("credential không đúng định dạng JWT: %w", err)
+	}
+	if len(claims.VC) == 0 {
+		return nil, fmt.Errorf("credential thiếu claim vc")
+	}
+	return claims, nil
+}
+
+// Verify kiểm tra chữ ký ES256 và thời hạn của credential với khóa công khai của bên phát hành
+func Verify(tokenStr string, pub *ecdsa.PublicKey) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return pub, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidProof
+	}
+	return claims, nil
+}
+
+// BaseURLFromEnv là địa chỉ công khai của hệ thống, dùng làm tiền tố cho id của credential và bên phát hành
+func BaseURLFromEnv() string {
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimRight(base, "/")
+}
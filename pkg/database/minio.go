@@ -3,6 +3,7 @@ package database
 import (
 	"bytes"
 	"context"
+	"io"
 	"log"
 	"os"
 	"strconv"
@@ -85,3 +86,12 @@ func (m *MinioClient) GetFileURL(objectName string) string {
 	}
 	return scheme + "://" + endpoint + "/" + m.Bucket + "/" + objectName
 }
+
+func (m *MinioClient) DownloadFile(ctx context.Context, objectName string) ([]byte, error) {
+	object, err := m.Client.GetObject(ctx, m.Bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	return io.ReadAll(object)
+}
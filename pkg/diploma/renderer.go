@@ -0,0 +1,273 @@
+package diploma
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+//go:embed fonts/*.ttf
+var fontFS embed.FS
+
+const fontFamily = "DejaVu"
+
+// Data chứa toàn bộ thông tin cần in trên văn bằng/chứng chỉ
+type Data struct {
+	UniversityName  string
+	UniversityCode  string
+	CertificateType string
+	CertificateName string
+	StudentName     string
+	StudentCode     string
+	DateOfBirth     string
+	FacultyName     string
+	Major           string
+	Course          string
+	GraduationRank  string
+	EducationType   string
+	SerialNumber    string
+	RegNo           string
+	IssueDate       time.Time
+	VerifyToken     string // Token ký bởi hệ thống, nếu có sẽ được dùng thay cho mã trường + số hiệu trong QR
+}
+
+// Template là mẫu tiêu đề song ngữ theo loại văn bằng
+type Template struct {
+	TitleVI string
+	TitleEN string
+}
+
+var templates = map[string]Template{
+	"Cử nhân": {TitleVI: "BẰNG CỬ NHÂN", TitleEN: "THE DEGREE OF BACHELOR"},
+	"Kỹ sư":   {TitleVI: "BẰNG KỸ SƯ", TitleEN: "THE DEGREE OF ENGINEER"},
+	"Thạc sĩ": {TitleVI: "BẰNG THẠC SĨ", TitleEN: "THE DEGREE OF MASTER"},
+	"Tiến sĩ": {TitleVI: "BẰNG TIẾN SĨ", TitleEN: "THE DEGREE OF DOCTOR OF PHILOSOPHY"},
+}
+
+var rankEN = map[string]string{
+	"Xuất sắc":       "Excellent",
+	"Giỏi":           "Very good",
+	"Khá":            "Good",
+	"Trung bình khá": "Fairly good",
+	"Trung bình":     "Average",
+}
+
+var educationTypeEN = map[string]string{
+	"Chính quy":           "Full-time",
+	"Tại chức":            "Part-time",
+	"Vừa làm vừa học":     "Part-time",
+	"Từ xa":               "Distance learning",
+	"Liên thông":          "Articulation",
+	"Văn bằng hai":        "Second degree",
+	"Đào tạo từ xa":       "Distance learning",
+	"Chính quy tập trung": "Full-time",
+}
+
+// TemplateFor trả về mẫu của loại văn bằng, loại chưa có mẫu riêng dùng mẫu chứng chỉ chung
+func TemplateFor(certificateType, certificateName string) Template {
+	if t, ok := templates[strings.TrimSpace(certificateType)]; ok {
+		return t
+	}
+	title := strings.ToUpper(strings.TrimSpace(certificateName))
+	if title == "" {
+		title = "CHỨNG CHỈ"
+	}
+	return Template{TitleVI: title, TitleEN: "CERTIFICATE"}
+}
+
+type Renderer struct {
+	verifyBaseURL string
+}
+
+func NewRenderer(verifyBaseURL string) *Renderer {
+	return &Renderer{verifyBaseURL: verifyBaseURL}
+}
+
+func NewRendererFromEnv() *Renderer {
+	base := os.Getenv("PUBLIC_VERIFY_URL")
+	if base == "" {
+		base = "http://localhost:8080/api/v1/public/verify"
+	}
+	return NewRenderer(base)
+}
+
+// VerifyURL là đường dẫn xác minh công khai được mã hóa trong QR in trên văn bằng
+func (r *Renderer) VerifyURL(d *Data) string {
+	q := url.Values{}
+	if d.VerifyToken != "" {
+		q.Set("token", d.VerifyToken)
+	} else {
+		q.Set("university_code", d.UniversityCode)
+		q.Set("serial_number", d.SerialNumber)
+	}
+	return r.verifyBaseURL + "?" + q.Encode()
+}
+
+func (r *Renderer) Render(d *Data) ([]byte, error) {
+	regular, err := fontFS.ReadFile("fonts/DejaVuSansCondensed.ttf")
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc font: %w", err)
+	}
+	bold, err := fontFS.ReadFile("fonts/DejaVuSansCondensed-Bold.ttf")
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc font: %w", err)
+	}
+
+	qrPNG, err := qrcode.Encode(r.VerifyURL(d), qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi tạo mã QR: %w", err)
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("%s - %s", d.SerialNumber, d.StudentName), true)
+	pdf.SetAuthor(d.UniversityName, true)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", regular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", bold)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	pageW, pageH := pdf.GetPageSize()
+	colW := (pageW - 40) / 2
+	leftX := 15.0
+	rightX := leftX + colW + 10
+
+	// Khung viền
+	pdf.SetDrawColor(150, 20, 20)
+	pdf.SetLineWidth(1.2)
+	pdf.Rect(8, 8, pageW-16, pageH-16, "D")
+	pdf.SetLineWidth(0.4)
+	pdf.Rect(11, 11, pageW-22, pageH-22, "D")
+
+	tpl := TemplateFor(d.CertificateType, d.CertificateName)
+
+	// Cột tiếng Anh (trái)
+	r.writeColumn(pdf, leftX, colW, []line{
+		{text: "SOCIALIST REPUBLIC OF VIETNAM", style: "B", size: 11},
+		{text: "Independence - Freedom - Happiness", size: 10},
+		{gap: 6},
+		{text: "THE RECTOR OF", size: 10},
+		{text: strings.ToUpper(d.UniversityName), style: "B", size: 11},
+		{text: "confers", size: 10},
+		{gap: 2},
+		{text: tpl.TitleEN, style: "B", size: 16, red: true},
+		{gap: 2},
+		{label: "Major", text: d.Major},
+		{label: "Upon", text: d.StudentName, style: "B"},
+		{label: "Date of birth", text: d.DateOfBirth},
+		{label: "Student ID", text: d.StudentCode},
+		{label: "Degree classification", text: translate(rankEN, d.GraduationRank)},
+		{label: "Mode of study", text: translate(educationTypeEN, d.EducationType)},
+		{label: "Course", text: d.Course},
+	})
+
+	// Cột tiếng Việt (phải)
+	r.writeColumn(pdf, rightX, colW, []line{
+		{text: "CỘNG HÒA XÃ HỘI CHỦ NGHĨA VIỆT NAM", style: "B", size: 11},
+		{text: "Độc lập - Tự do - Hạnh phúc", size: 10},
+		{gap: 6},
+		{text: "HIỆU TRƯỞNG", size: 10},
+		{text: strings.ToUpper(d.UniversityName), style: "B", size: 11},
+		{text: "cấp", size: 10},
+		{gap: 2},
+		{text: tpl.TitleVI, style: "B", size: 16, red: true},
+		{gap: 2},
+		{label: "Ngành", text: d.Major},
+		{label: "Cho", text: d.StudentName, style: "B"},
+		{label: "Ngày sinh", text: d.DateOfBirth},
+		{label: "Mã sinh viên", text: d.StudentCode},
+		{label: "Xếp loại tốt nghiệp", text: d.GraduationRank},
+		{label: "Hình thức đào tạo", text: d.EducationType},
+		{label: "Khoa", text: d.FacultyName},
+	})
+
+	// Ngày cấp, số hiệu, số vào sổ và mã QR
+	bottomY := pageH - 50
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.SetXY(leftX, bottomY)
+	pdf.CellFormat(colW, 6, "Serial No.: "+d.SerialNumber, "", 2, "L", false, 0, "")
+	pdf.CellFormat(colW, 6, "Reg. No.: "+d.RegNo, "", 2, "L", false, 0, "")
+	pdf.CellFormat(colW, 6, "Date of issue: "+d.IssueDate.Format("January 2, 2006"), "", 2, "L", false, 0, "")
+
+	pdf.SetXY(rightX, bottomY)
+	issued := fmt.Sprintf("Ngày %02d tháng %02d năm %d", d.IssueDate.Day(), int(d.IssueDate.Month()), d.IssueDate.Year())
+	pdf.CellFormat(colW, 6, issued, "", 2, "R", false, 0, "")
+	pdf.CellFormat(colW, 6, "Số hiệu: "+d.SerialNumber, "", 2, "R", false, 0, "")
+	pdf.CellFormat(colW, 6, "Số vào sổ cấp bằng: "+d.RegNo, "", 2, "R", false, 0, "")
+
+	qrSize := 28.0
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("qr", opts, bytes.NewReader(qrPNG))
+	pdf.ImageOptions("qr", (pageW-qrSize)/2, bottomY-4, qrSize, qrSize, false, opts, 0, "")
+	pdf.SetFont(fontFamily, "", 7)
+	pdf.SetXY((pageW-60)/2, bottomY-4+qrSize)
+	pdf.CellFormat(60, 4, "Quét mã để xác minh / Scan to verify", "", 0, "C", false, 0, "")
+
+	if pdf.Err() {
+		return nil, fmt.Errorf("lỗi tạo PDF: %w", pdf.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("lỗi xuất PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type line struct {
+	label string
+	text  string
+	style string
+	size  float64
+	red   bool
+	gap   float64
+}
+
+func (r *Renderer) writeColumn(pdf *fpdf.Fpdf, x, w float64, lines []line) {
+	y := 20.0
+	for _, l := range lines {
+		if l.gap > 0 {
+			y += l.gap
+			continue
+		}
+		size := l.size
+		if size == 0 {
+			size = 11
+		}
+		if l.red {
+			pdf.SetTextColor(150, 20, 20)
+		} else {
+			pdf.SetTextColor(0, 0, 0)
+		}
+
+		pdf.SetXY(x, y)
+		if l.label == "" {
+			pdf.SetFont(fontFamily, l.style, size)
+			pdf.CellFormat(w, size*0.6, l.text, "", 0, "C", false, 0, "")
+			y += size*0.6 + 1
+			continue
+		}
+
+		labelW := w * 0.4
+		pdf.SetFont(fontFamily, "", size)
+		pdf.CellFormat(labelW, 7, l.label+":", "", 0, "L", false, 0, "")
+		pdf.SetFont(fontFamily, l.style, size)
+		pdf.CellFormat(w-labelW, 7, l.text, "", 0, "L", false, 0, "")
+		y += 7
+	}
+	pdf.SetTextColor(0, 0, 0)
+}
+
+func translate(dict map[string]string, value string) string {
+	if v, ok := dict[strings.TrimSpace(value)]; ok {
+		return v
+	}
+	return value
+}
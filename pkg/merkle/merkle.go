@@ -0,0 +1,126 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Tiền tố phân biệt nút lá và nút trong để không thể giả mạo một nút trong thành lá (second preimage)
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+var ErrEmptyTree = errors.New("không có lá nào để dựng cây Merkle")
+
+// ProofStep là một nút anh em trên đường từ lá lên gốc
+type ProofStep struct {
+	Hash string `json:"hash" bson:"hash"` // Mã băm hex của nút anh em
+	Left bool   `json:"left" bson:"left"` // true nếu nút anh em nằm bên trái
+}
+
+type Tree struct {
+	levels [][][]byte // levels[0] là các lá, phần tử cuối là gốc
+}
+
+// Build dựng cây từ các mã băm hex theo đúng thứ tự truyền vào.
+// Tầng có số nút lẻ thì nút cuối được đưa thẳng lên tầng trên, không nhân đôi.
+func Build(leaves []string) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, ErrEmptyTree
+	}
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		raw, err := hex.DecodeString(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("lá thứ %d không phải mã hex hợp lệ: %w", i, err)
+		}
+		level[i] = hashLeaf(raw)
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashNode(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return &Tree{levels: levels}, nil
+}
+
+func (t *Tree) Root() string {
+	return hex.EncodeToString(t.levels[len(t.levels)-1][0])
+}
+
+func (t *Tree) Size() int {
+	return len(t.levels[0])
+}
+
+// Proof trả về đường chứng minh cho lá ở vị trí index
+func (t *Tree) Proof(index int) ([]ProofStep, error) {
+	if index < 0 || index >= t.Size() {
+		return nil, fmt.Errorf("vị trí lá %d nằm ngoài cây", index)
+	}
+	proof := []ProofStep{}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, ProofStep{
+				Hash: hex.EncodeToString(level[sibling]),
+				Left: sibling < index,
+			})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Verify kiểm tra lá (mã băm hex) thuộc cây có gốc root theo đường chứng minh proof
+func Verify(leaf string, proof []ProofStep, root string) bool {
+	raw, err := hex.DecodeString(leaf)
+	if err != nil {
+		return false
+	}
+	expected, err := hex.DecodeString(root)
+	if err != nil {
+		return false
+	}
+
+	current := hashLeaf(raw)
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false
+		}
+		if step.Left {
+			current = hashNode(sibling, current)
+		} else {
+			current = hashNode(current, sibling)
+		}
+	}
+	return bytes.Equal(current, expected)
+}
+
+func hashLeaf(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hashNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
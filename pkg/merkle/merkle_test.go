@@ -0,0 +1,165 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Các hàm băm viết lại độc lập với merkle.go để gốc và đường chứng minh mong đợi không phụ thuộc cài đặt đang kiểm tra
+func testLeaf(leaf string) []byte {
+	raw, _ := hex.DecodeString(leaf)
+	sum := sha256.Sum256(append([]byte{0x00}, raw...))
+	return sum[:]
+}
+
+func testNode(left, right []byte) []byte {
+	sum := sha256.Sum256(append(append([]byte{0x01}, left...), right...))
+	return sum[:]
+}
+
+func testLeaves(n int) []string {
+	leaves := make([]string, n)
+	for i := range leaves {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("cert-%d", i)))
+		leaves[i] = hex.EncodeToString(sum[:])
+	}
+	return leaves
+}
+
+func TestBuildRootAndProof(t *testing.T) {
+	leaves := testLeaves(5)
+	l := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		l[i] = testLeaf(leaf)
+	}
+	n01 := testNode(l[0], l[1])
+	n23 := testNode(l[2], l[3])
+	step := func(hash []byte, left bool) ProofStep {
+		return ProofStep{Hash: hex.EncodeToString(hash), Left: left}
+	}
+
+	tests := []struct {
+		name  string
+		size  int
+		root  []byte
+		index int
+		proof []ProofStep
+	}{
+		{name: "một lá", size: 1, root: l[0], index: 0, proof: []ProofStep{}},
+		{name: "hai lá, lá trái", size: 2, root: n01, index: 0, proof: []ProofStep{step(l[1], false)}},
+		{name: "hai lá, lá phải", size: 2, root: n01, index: 1, proof: []ProofStep{step(l[0], true)}},
+		// Tầng lẻ: lá cuối được đưa thẳng lên, không nhân đôi nên không có bước nào ở tầng lá
+		{name: "ba lá, lá lẻ cuối", size: 3, root: testNode(n01, l[2]), index: 2, proof: []ProofStep{step(n01, true)}},
+		{name: "ba lá, lá giữa", size: 3, root: testNode(n01, l[2]), index: 1, proof: []ProofStep{step(l[0], true), step(l[2], false)}},
+		{name: "bốn lá", size: 4, root: testNode(n01, n23), index: 2, proof: []ProofStep{step(l[3], false), step(n01, true)}},
+		{name: "năm lá, lá lẻ đi qua hai tầng", size: 5, root: testNode(testNode(n01, n23), l[4]), index: 4, proof: []ProofStep{step(testNode(n01, n23), true)}},
+		{name: "năm lá, lá đầu", size: 5, root: testNode(testNode(n01, n23), l[4]), index: 0, proof: []ProofStep{step(l[1], false), step(n23, false), step(l[4], false)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := Build(leaves[:tt.size])
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if got, want := tree.Root(), hex.EncodeToString(tt.root); got != want {
+				t.Fatalf("gốc = %s, muốn %s", got, want)
+			}
+			proof, err := tree.Proof(tt.index)
+			if err != nil {
+				t.Fatalf("Proof: %v", err)
+			}
+			if !reflect.DeepEqual(proof, tt.proof) {
+				t.Fatalf("đường chứng minh = %+v, muốn %+v", proof, tt.proof)
+			}
+			if !Verify(leaves[tt.index], proof, tree.Root()) {
+				t.Fatal("Verify từ chối đường chứng minh đúng")
+			}
+		})
+	}
+}
+
+func TestVerifyEveryLeaf(t *testing.T) {
+	for size := 1; size <= 9; size++ {
+		leaves := testLeaves(size)
+		tree, err := Build(leaves)
+		if err != nil {
+			t.Fatalf("Build(%d): %v", size, err)
+		}
+		for i, leaf := range leaves {
+			proof, err := tree.Proof(i)
+			if err != nil {
+				t.Fatalf("Proof(%d) của cây %d lá: %v", i, size, err)
+			}
+			if !Verify(leaf, proof, tree.Root()) {
+				t.Fatalf("lá %d của cây %d lá không xác minh được", i, size)
+			}
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	leaves := testLeaves(4)
+	tree, err := Build(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := tree.Proof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherProof, err := tree.Proof(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipped := append([]ProofStep(nil), proof...)
+	flipped[0].Left = !flipped[0].Left
+	// Nút trong không được chấp nhận như một lá nhờ tiền tố phân biệt lá và nút trong
+	internal := hex.EncodeToString(testNode(testLeaf(leaves[0]), testLeaf(leaves[1])))
+
+	tests := []struct {
+		name  string
+		leaf  string
+		proof []ProofStep
+		root  string
+	}{
+		{name: "đảo hướng nút anh em", leaf: leaves[1], proof: flipped, root: tree.Root()},
+		{name: "lá khác", leaf: leaves[3], proof: proof, root: tree.Root()},
+		{name: "đường chứng minh của lá khác", leaf: leaves[1], proof: otherProof, root: tree.Root()},
+		{name: "thiếu bước", leaf: leaves[1], proof: proof[:1], root: tree.Root()},
+		{name: "gốc khác", leaf: leaves[1], proof: proof, root: hex.EncodeToString(testLeaf(leaves[1]))},
+		{name: "nút trong giả làm lá", leaf: internal, proof: proof[1:], root: tree.Root()},
+		{name: "lá không phải hex", leaf: "zz", proof: proof, root: tree.Root()},
+		{name: "gốc không phải hex", leaf: leaves[1], proof: proof, root: "zz"},
+		{name: "nút anh em không phải hex", leaf: leaves[1], proof: []ProofStep{{Hash: "zz"}}, root: tree.Root()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Verify(tt.leaf, tt.proof, tt.root) {
+				t.Fatal("Verify chấp nhận đường chứng minh sai")
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	if _, err := Build(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Fatalf("lỗi = %v, muốn %v", err, ErrEmptyTree)
+	}
+	if _, err := Build([]string{testLeaves(1)[0], "not-hex"}); err == nil {
+		t.Fatal("Build chấp nhận lá không phải hex")
+	}
+
+	tree, err := Build(testLeaves(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range []int{-1, 3} {
+		if _, err := tree.Proof(index); err == nil {
+			t.Fatalf("Proof(%d) không báo lỗi vị trí ngoài cây", index)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrInvalidSignature      = errors.New("invalid_signature")
+	ErrKeyNotFound           = errors.New("signing_key_not_found")
+	ErrInvalidUniversityCode = errors.New("invalid_university_code")
+)
+
+// universityCodePattern giới hạn mã trường dùng làm tên file khóa, chặn đường dẫn thoát khỏi thư mục keystore
+var universityCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// KeyStore lưu khóa bí mật ký văn bằng của từng trường, mỗi trường một file PEM đặt tên theo mã trường
+type KeyStore struct {
+	dir string
+}
+
+func NewKeyStore(dir string) *KeyStore {
+	return &KeyStore{dir: dir}
+}
+
+func NewKeyStoreFromEnv() *KeyStore {
+	dir := os.Getenv("UNIVERSITY_KEYSTORE_PATH")
+	if dir == "" {
+		dir = "./keys/universities"
+	}
+	return NewKeyStore(dir)
+}
+
+func (ks *KeyStore) keyPath(universityCode string) (string, error) {
+	if !universityCodePattern.MatchString(universityCode) {
+		return "", ErrInvalidUniversityCode
+	}
+	return filepath.Join(ks.dir, universityCode+"_sk.pem"), nil
+}
+
+func (ks *KeyStore) Exists(universityCode string) bool {
+	path, err := ks.keyPath(universityCode)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+func (ks *KeyStore) Save(universityCode string, keyPEM []byte) error {
+	path, err := ks.keyPath(universityCode)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ks.dir, 0o700); err != nil {
+		return fmt.Errorf("lỗi tạo thư mục keystore: %w", err)
+	}
+	return os.WriteFile(path, keyPEM, 0o600)
+}
+
+// Delete xóa file khóa của trường, không lỗi nếu file không tồn tại
+func (ks *KeyStore) Delete(universityCode string) error {
+	path, err := ks.keyPath(universityCode)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("lỗi xóa private key: %w", err)
+	}
+	return nil
+}
+
+func (ks *KeyStore) Load(universityCode string) (*ecdsa.PrivateKey, error) {
+	path, err := ks.keyPath(universityCode)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("lỗi đọc private key: %w", err)
+	}
+	return ParsePrivateKey(data)
+}
+
+// GenerateKey tạo cặp khóa ECDSA P-256 và chứng thư X.509 tự ký cho trường
+func GenerateKey(universityCode, universityName string) (keyPEM, certPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi sinh khóa: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi sinh serial: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:         universityCode,
+			Organization:       []string{universityName},
+			OrganizationalUnit: []string{"certificate-issuer"},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi tạo chứng thư: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lỗi mã hóa private key: %w", err)
+	}
+
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return keyPEM, certPEM, nil
+}
+
+func ParsePrivateKey(keyPEM []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("private key không đúng định dạng PEM")
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key không phải ECDSA")
+	}
+	return key, nil
+}
+
+func ParsePublicKey(certPEM string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, fmt.Errorf("chứng thư không đúng định dạng PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("lỗi đọc chứng thư: %w", err)
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("chứng thư không chứa khóa ECDSA")
+	}
+	return pub, nil
+}
+
+// SignHash ký mã băm SHA-256 (dạng hex) và trả về chữ ký ASN.1 mã hóa base64
+func SignHash(key *ecdsa.PrivateKey, hashHex string) (string, error) {
+	digest, err := hex.DecodeString(hashHex)
+	if err != nil {
+		return "", fmt.Errorf("mã băm không hợp lệ: %w", err)
+	}
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest)
+	if err != nil {
+		return "", fmt.Errorf("lỗi ký số: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyHash kiểm tra chữ ký của mã băm với khóa công khai trong chứng thư của trường
+func VerifyHash(certPEM, hashHex, signature string) error {
+	pub, err := ParsePublicKey(certPEM)
+	if err != nil {
+		return err
+	}
+	digest, err := hex.DecodeString(hashHex)
+	if err != nil {
+		return fmt.Errorf("mã băm không hợp lệ: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !ecdsa.VerifyASN1(pub, digest, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
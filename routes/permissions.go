@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/middleware"
+)
+
+const apiPrefix = "/api/v1"
+
+// routePermissions là ma trận phân quyền theo route: mỗi route của /api/v1 khai báo quyền cần có,
+// vai trò được cấp quyền nằm trong common.RolePermissions. SetupRouter từ chối khởi động nếu thiếu route nào.
+var routePermissions = map[string]common.Permission{
+	// Auth
+	route(http.MethodPost, "/auth/login"):                common.PermPublic,
+	route(http.MethodPost, "/auth/request-otp"):          common.PermPublic,
+	route(http.MethodPost, "/auth/verify-otp"):           common.PermPublic,
+	route(http.MethodPost, "/auth/register"):             common.PermPublic,
+	route(http.MethodPost, "/auth/verification"):         common.PermPublic,
+	route(http.MethodGet, "/auth/accounts"):              common.PermAccountManage,
+	route(http.MethodDelete, "/auth/accounts"):           common.PermAccountManage,
+	route(http.MethodPost, "/auth/change-password"):      common.PermProfile,
+	route(http.MethodGet, "/auth/university-admin-info"): common.PermAccountManage,
+	route(http.MethodGet, "/auth/students-info"):         common.PermAccountManage,
+	route(http.MethodPost, "/auth/staff-accounts"):       common.PermStaffAccountManage,
+
+	// Users
+	route(http.MethodPost, "/users/import-excel"):         common.PermUserWrite,
+	route(http.MethodGet, "/users"):                       common.PermUserRead,
+	route(http.MethodPost, "/users"):                      common.PermUserWrite,
+	route(http.MethodGet, "/users/:id"):                   common.PermUserRead,
+	route(http.MethodPut, "/users/:id"):                   common.PermUserWrite,
+	route(http.MethodGet, "/users/search"):                common.PermUserRead,
+	route(http.MethodGet, "/users/me"):                    common.PermProfile,
+	route(http.MethodDelete, "/users/:id"):                common.PermUserWrite,
+	route(http.MethodGet, "/users/faculty/:faculty_code"): common.PermUserRead,
+
+	// Certificates
+	route(http.MethodGet, "/certificates"):                      common.PermCertificateRead,
+	route(http.MethodPost, "/certificates"):                     common.PermCertificateIssue,
+	route(http.MethodGet, "/certificates/:id"):                  common.PermCertificateView,
+	route(http.MethodPost, "/certificates/import-excel"):        common.PermCertificateIssue,
+	route(http.MethodPost, "/certificates/upload-pdf"):          common.PermCertificateIssue,
+	route(http.MethodPost, "/certificates/upload-zip"):          common.PermCertificateIssue,
+	route(http.MethodGet, "/certificates/file/:id"):             common.PermCertificateView,
+	route(http.MethodGet, "/certificates/student/:id"):          common.PermCertificateRead,
+	route(http.MethodGet, "/certificates/search"):               common.PermCertificateRead,
+	route(http.MethodGet, "/certificates/my-certificate"):       common.PermCertificateReadOwn,
+	route(http.MethodDelete, "/certificates/:id"):               common.PermCertificateDelete,
+	route(http.MethodPost, "/certificates/:id/revoke"):          common.PermCertificateRevoke,
+	route(http.MethodPut, "/certificates/:id/amend"):            common.PermCertificateAmend,
+	route(http.MethodGet, "/certificates/:id/versions"):         common.PermCertificateExport,
+	route(http.MethodPost, "/certificates/:id/transition"):      common.PermCertificateTransition,
+	route(http.MethodGet, "/certificates/:id/status-history"):   common.PermCertificateExport,
+	route(http.MethodPost, "/certificates/:id/sign"):            common.PermCertificateSign,
+	route(http.MethodGet, "/certificates/:id/verify-signature"): common.PermCertificateView,
+	route(http.MethodPost, "/certificates/:id/generate-pdf"):    common.PermCertificateIssue,
+	route(http.MethodGet, "/certificates/simple"):               common.PermCertificateReadOwn,
+	route(http.MethodGet, "/certificates/:id/vc"):               common.PermCertificateExport,
+	route(http.MethodGet, "/certificates/:id/badge"):            common.PermCertificateExport,
+
+	// Certificate types
+	route(http.MethodGet, "/certificate-types"):        common.PermCertificateTypeRead,
+	route(http.MethodPost, "/certificate-types"):       common.PermCertificateTypeWrite,
+	route(http.MethodPut, "/certificate-types/:id"):    common.PermCertificateTypeWrite,
+	route(http.MethodDelete, "/certificate-types/:id"): common.PermCertificateTypeWrite,
+
+	// Universities
+	route(http.MethodPost, "/universities"):                          common.PermPublic,
+	route(http.MethodPost, "/universities/approve-or-reject"):        common.PermUniversityManage,
+	route(http.MethodGet, "/universities"):                           common.PermPublic,
+	route(http.MethodGet, "/universities/status"):                    common.PermUniversityManage,
+	route(http.MethodPost, "/universities/signing-key"):              common.PermSigningKeyManage,
+	route(http.MethodGet, "/universities/:code/signing-certificate"): common.PermPublic,
+
+	// Faculties
+	route(http.MethodPost, "/faculties"):       common.PermFacultyWrite,
+	route(http.MethodGet, "/faculties"):        common.PermFacultyRead,
+	route(http.MethodPut, "/faculties/:id"):    common.PermFacultyWrite,
+	route(http.MethodDelete, "/faculties/:id"): common.PermFacultyWrite,
+	route(http.MethodGet, "/faculties/:id"):    common.PermFacultyRead,
+
+	route(http.MethodPost, "/upload"): common.PermCertificateIssue,
+
+	// Verification codes
+	route(http.MethodPost, "/verification/create"):  common.PermVerificationCodeManage,
+	route(http.MethodGet, "/verification/my-codes"): common.PermVerificationCodeManage,
+
+	// Reward/Discipline
+	route(http.MethodPost, "/reward-disciplines"):                      common.PermRewardDisciplineWrite,
+	route(http.MethodGet, "/reward-disciplines"):                       common.PermRewardDisciplineRead,
+	route(http.MethodGet, "/reward-disciplines/:id"):                   common.PermRewardDisciplineRead,
+	route(http.MethodPut, "/reward-disciplines/:id"):                   common.PermRewardDisciplineWrite,
+	route(http.MethodDelete, "/reward-disciplines/:id"):                common.PermRewardDisciplineWrite,
+	route(http.MethodGet, "/reward-disciplines/search"):                common.PermRewardDisciplineRead,
+	route(http.MethodGet, "/reward-disciplines/my-reward-disciplines"): common.PermRewardDisciplineReadOwn,
+	route(http.MethodPost, "/reward-disciplines/import-excel"):         common.PermRewardDisciplineWrite,
+
+	// Blockchain
+	route(http.MethodPost, "/blockchain/push-chain/:id"):            common.PermLedgerWrite,
+	route(http.MethodGet, "/blockchain/certificate-on-chain/:id"):   common.PermPublic,
+	route(http.MethodGet, "/blockchain/verify/:id"):                 common.PermPublic,
+	route(http.MethodGet, "/blockchain/history/:id"):                common.PermCertificateExport,
+	route(http.MethodGet, "/blockchain/private/:id"):                common.PermLedgerPrivateRead,
+	route(http.MethodGet, "/blockchain/jobs"):                       common.PermLedgerRead,
+	route(http.MethodGet, "/blockchain/jobs/:id"):                   common.PermLedgerRead,
+	route(http.MethodPost, "/blockchain/jobs/:id/retry"):            common.PermLedgerWrite,
+	route(http.MethodPost, "/blockchain/batches"):                   common.PermLedgerWrite,
+	route(http.MethodGet, "/blockchain/batches/:id"):                common.PermLedgerRead,
+	route(http.MethodPost, "/blockchain/reconciliation/run"):        common.PermReconciliation,
+	route(http.MethodGet, "/blockchain/reconciliation/reports"):     common.PermReconciliation,
+	route(http.MethodGet, "/blockchain/reconciliation/reports/:id"): common.PermReconciliation,
+
+	// Verifiable credentials, xác minh công khai và Open Badges
+	route(http.MethodPost, "/vc/verify"):                             common.PermPublic,
+	route(http.MethodGet, "/public/verify"):                          common.PermPublic,
+	route(http.MethodPost, "/public/verify-file"):                    common.PermPublic,
+	route(http.MethodPost, "/public/verify-disclosure"):              common.PermPublic,
+	route(http.MethodGet, "/public/badges/credentials/:id"):          common.PermPublic,
+	route(http.MethodGet, "/public/badges/issuers/:code"):            common.PermPublic,
+	route(http.MethodGet, "/public/badges/achievements/:code/:type"): common.PermPublic,
+	route(http.MethodPost, "/public/badges/verify"):                  common.PermPublic,
+}
+
+func route(method, path string) string {
+	return middleware.RouteKey(method, apiPrefix+path)
+}
+
+// checkRoutePermissions đối chiếu route đã đăng ký với ma trận phân quyền, trả lỗi liệt kê route thiếu quyền hoặc quyền khai báo thừa
+func checkRoutePermissions(r *gin.Engine, permissions map[string]common.Permission) error {
+	registered := make(map[string]bool)
+	var problems []string
+	for _, info := range r.Routes() {
+		if !strings.HasPrefix(info.Path, apiPrefix) {
+			continue
+		}
+		key := middleware.RouteKey(info.Method, info.Path)
+		registered[key] = true
+		perm, ok := permissions[key]
+		switch {
+		case !ok:
+			problems = append(problems, "chưa khai báo quyền: "+key)
+		case perm != common.PermPublic && len(common.RolePermissions[perm]) == 0:
+			problems = append(problems, fmt.Sprintf("quyền %s của %s không được cấp cho vai trò nào", perm, key))
+		}
+	}
+	for key := range permissions {
+		if !registered[key] {
+			problems = append(problems, "khai báo quyền cho route không tồn tại: "+key)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("ma trận phân quyền không khớp với route:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
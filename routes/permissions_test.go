@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/common"
+	"github.com/vnkmasc/Kmasc/app/backend/internal/middleware"
+	"github.com/vnkmasc/Kmasc/app/backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupTestRouter dựng router thật, handler nil vì test chỉ đọc bảng route chứ không gọi handler
+func setupTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, err := SetupRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("SetupRouter lỗi = %v", err)
+	}
+	return r
+}
+
+func TestRoutePermissionsCoverRouter(t *testing.T) {
+	r := setupTestRouter(t)
+	for _, info := range r.Routes() {
+		if !strings.HasPrefix(info.Path, apiPrefix) {
+			continue
+		}
+		if _, ok := routePermissions[middleware.RouteKey(info.Method, info.Path)]; !ok {
+			t.Fatalf("route %s %s chưa khai báo quyền", info.Method, info.Path)
+		}
+	}
+}
+
+func TestCheckRoutePermissions(t *testing.T) {
+	newEngine := func() *gin.Engine {
+		r := gin.New()
+		ok := func(c *gin.Context) {}
+		r.GET(apiPrefix+"/certificates", ok)
+		r.POST(apiPrefix+"/auth/login", ok)
+		r.GET("/healthz", ok)
+		return r
+	}
+	base := map[string]common.Permission{
+		route(http.MethodGet, "/certificates"): common.PermCertificateRead,
+		route(http.MethodPost, "/auth/login"):  common.PermPublic,
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(map[string]common.Permission)
+		wantErr string
+	}{
+		{"khớp", func(map[string]common.Permission) {}, ""},
+		{"thiếu route", func(m map[string]common.Permission) {
+			delete(m, route(http.MethodGet, "/certificates"))
+		}, "chưa khai báo quyền: GET /api/v1/certificates"},
+		{"route không tồn tại", func(m map[string]common.Permission) {
+			m[route(http.MethodDelete, "/certificates/:id")] = common.PermCertificateDelete
+		}, "khai báo quyền cho route không tồn tại: DELETE /api/v1/certificates/:id"},
+		{"quyền không cấp cho vai trò nào", func(m map[string]common.Permission) {
+			m[route(http.MethodGet, "/certificates")] = common.Permission("unknown:perm")
+		}, "quyền unknown:perm của GET /api/v1/certificates không được cấp cho vai trò nào"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := make(map[string]common.Permission, len(base))
+			for k, v := range base {
+				permissions[k] = v
+			}
+			tt.mutate(permissions)
+
+			err := checkRoutePermissions(newEngine(), permissions)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("lỗi = %v, muốn nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("lỗi = %v, muốn chứa %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAuthorizeFollowsMatrix gọi mọi route của router với từng vai trò, handler giả trả 200 nếu qua được kiểm tra quyền
+func TestAuthorizeFollowsMatrix(t *testing.T) {
+	r := gin.New()
+	api := r.Group(apiPrefix)
+	api.Use(middleware.Authorize(routePermissions))
+	var routes gin.RoutesInfo
+	for _, info := range setupTestRouter(t).Routes() {
+		if !strings.HasPrefix(info.Path, apiPrefix) {
+			continue
+		}
+		routes = append(routes, info)
+		api.Handle(info.Method, strings.TrimPrefix(info.Path, apiPrefix), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	}
+
+	tokens := map[string]string{}
+	for _, role := range common.AllRoles {
+		token, err := utils.GenerateToken(primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), role, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[role] = token
+	}
+
+	serve := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, info := range routes {
+		perm := routePermissions[middleware.RouteKey(info.Method, info.Path)]
+		path := requestPath(info.Path)
+
+		wantAnonymous := http.StatusUnauthorized
+		if perm == common.PermPublic {
+			wantAnonymous = http.StatusOK
+		}
+		if got := serve(info.Method, path, ""); got != wantAnonymous {
+			t.Fatalf("%s %s không có token: mã = %d, muốn %d", info.Method, path, got, wantAnonymous)
+		}
+
+		for _, role := range common.AllRoles {
+			want := http.StatusForbidden
+			if perm == common.PermPublic || common.HasPermission(role, perm) {
+				want = http.StatusOK
+			}
+			if got := serve(info.Method, path, tokens[role]); got != want {
+				t.Fatalf("%s %s với vai trò %s: mã = %d, muốn %d", info.Method, path, role, got, want)
+			}
+		}
+	}
+}
+
+// requestPath thay tham số của mẫu route bằng giá trị cụ thể để gửi request
+func requestPath(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = primitive.NewObjectID().Hex()
+		}
+	}
+	return strings.Join(segments, "/")
+}
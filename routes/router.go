@@ -7,6 +7,7 @@ import (
 	"github.com/vnkmasc/Kmasc/app/backend/internal/middleware"
 )
 
+// SetupRouter đăng ký route của API, trả lỗi nếu route không khớp ma trận phân quyền routePermissions
 func SetupRouter(
 	userHandler *handlers.UserHandler,
 	authHandler *handlers.AuthHandler,
@@ -17,8 +18,12 @@ func SetupRouter(
 	verificationHandler *handlers.VerificationHandler,
 	rewardDisciplineHandler *handlers.RewardDisciplineHandler,
 	blockchainHandler *handlers.BlockchainHandler,
+	certificateTypeHandler *handlers.CertificateTypeHandler,
+	reconciliationHandler *handlers.ReconciliationHandler,
+	credentialHandler *handlers.CredentialHandler,
+	badgeHandler *handlers.BadgeHandler,
 
-) *gin.Engine {
+) (*gin.Engine, error) {
 	r := gin.Default()
 
 	// CORS setup
@@ -29,7 +34,9 @@ func SetupRouter(
 		AllowCredentials: true,
 	}))
 
-	api := r.Group("/api/v1")
+	// Mọi route của API đi qua kiểm tra quyền theo routePermissions, route công khai cũng phải khai báo
+	api := r.Group(apiPrefix)
+	api.Use(middleware.Authorize(routePermissions))
 
 	// ===== Auth routes =====
 	authPublic := api.Group("/auth")
@@ -40,16 +47,15 @@ func SetupRouter(
 	authPublic.POST("/verification", verificationHandler.VerifyCode)
 
 	authPrivate := api.Group("/auth")
-	authPrivate.Use(middleware.JWTAuthMiddleware())
 	authPrivate.GET("/accounts", authHandler.GetAllAccounts)
 	authPrivate.DELETE("/accounts", authHandler.DeleteAccount)
 	authPrivate.POST("/change-password", authHandler.ChangePassword)
-	authPrivate.GET("/university-admin-info", middleware.JWTAuthMiddleware(), authHandler.GetUniversityAdmins)
-	authPrivate.GET("/students-info", middleware.JWTAuthMiddleware(), authHandler.GetStudentAccounts)
+	authPrivate.GET("/university-admin-info", authHandler.GetUniversityAdmins)
+	authPrivate.GET("/students-info", authHandler.GetStudentAccounts)
+	authPrivate.POST("/staff-accounts", authHandler.CreateStaffAccount)
 
 	// ===== User routes =====
 	userGroup := api.Group("/users")
-	userGroup.Use(middleware.JWTAuthMiddleware())
 	userGroup.POST("/import-excel", userHandler.ImportUsersFromExcel)
 	userGroup.GET("", userHandler.GetAllUsers)
 	userGroup.POST("", userHandler.CreateUser)
@@ -62,17 +68,35 @@ func SetupRouter(
 
 	// ===== Certificate routes =====
 	certificateGroup := api.Group("/certificates")
-	certificateGroup.Use(middleware.JWTAuthMiddleware())
 	certificateGroup.GET("", certificateHandler.GetAllCertificates)
 	certificateGroup.POST("", certificateHandler.CreateCertificate)
 	certificateGroup.GET("/:id", certificateHandler.GetCertificateByID)
+	certificateGroup.POST("/import-excel", certificateHandler.ImportCertificatesFromExcel)
 	certificateGroup.POST("/upload-pdf", certificateHandler.UploadCertificateFile)
+	certificateGroup.POST("/upload-zip", certificateHandler.UploadCertificateFilesZip)
 	certificateGroup.GET("/file/:id", certificateHandler.GetCertificateFile)
 	certificateGroup.GET("/student/:id", certificateHandler.GetCertificatesByStudentID)
 	certificateGroup.GET("/search", certificateHandler.SearchCertificates)
 	certificateGroup.GET("/my-certificate", certificateHandler.GetMyCertificates)
 	certificateGroup.DELETE("/:id", certificateHandler.DeleteCertificate)
+	certificateGroup.POST("/:id/revoke", certificateHandler.RevokeCertificate)
+	certificateGroup.PUT("/:id/amend", certificateHandler.AmendCertificate)
+	certificateGroup.GET("/:id/versions", certificateHandler.GetCertificateVersions)
+	certificateGroup.POST("/:id/transition", certificateHandler.TransitionCertificate)
+	certificateGroup.GET("/:id/status-history", certificateHandler.GetCertificateStatusHistory)
+	certificateGroup.POST("/:id/sign", certificateHandler.SignCertificate)
+	certificateGroup.GET("/:id/verify-signature", certificateHandler.VerifyCertificateSignature)
+	certificateGroup.POST("/:id/generate-pdf", certificateHandler.GenerateCertificateFile)
 	certificateGroup.GET("/simple", certificateHandler.GetMyCertificateNames)
+	certificateGroup.GET("/:id/vc", credentialHandler.ExportCertificateCredential)
+	certificateGroup.GET("/:id/badge", badgeHandler.ExportCertificateBadge)
+
+	// ===== Certificate type routes =====
+	certificateTypeGroup := api.Group("/certificate-types")
+	certificateTypeGroup.GET("", certificateTypeHandler.GetCertificateTypes)
+	certificateTypeGroup.POST("", certificateTypeHandler.CreateCertificateType)
+	certificateTypeGroup.PUT("/:id", certificateTypeHandler.UpdateCertificateType)
+	certificateTypeGroup.DELETE("/:id", certificateTypeHandler.DeleteCertificateType)
 
 	// ===== University routes =====
 	universityGroup := api.Group("/universities")
@@ -80,10 +104,11 @@ func SetupRouter(
 	universityGroup.POST("/approve-or-reject", universityHandler.ApproveOrRejectUniversity)
 	universityGroup.GET("", universityHandler.GetAllUniversities)
 	universityGroup.GET("/status", universityHandler.GetUniversities)
+	universityGroup.POST("/signing-key", universityHandler.GenerateSigningKey)
+	universityGroup.GET("/:code/signing-certificate", universityHandler.GetSigningCertificate)
 
 	//Faculty
 	facultyGroup := api.Group("/faculties")
-	facultyGroup.Use(middleware.JWTAuthMiddleware())
 	facultyGroup.POST("", facultyHandler.CreateFaculty)
 	facultyGroup.GET("", facultyHandler.GetAllFaculties)
 	facultyGroup.PUT("/:id", facultyHandler.UpdateFaculty)
@@ -94,13 +119,12 @@ func SetupRouter(
 	api.POST("/upload", fileHandler.UploadFile)
 
 	//verification
-	auth := api.Group("/verification").Use(middleware.JWTAuthMiddleware())
+	auth := api.Group("/verification")
 	auth.POST("/create", verificationHandler.CreateVerificationCode)
 	auth.GET("/my-codes", verificationHandler.GetMyCodes)
 
 	// Reward/Discipline routes
 	rdGroup := api.Group("/reward-disciplines")
-	rdGroup.Use(middleware.JWTAuthMiddleware())
 	rdGroup.POST("", rewardDisciplineHandler.CreateRewardDiscipline)
 	rdGroup.GET("", rewardDisciplineHandler.GetAllRewardDisciplines)
 	rdGroup.GET("/:id", rewardDisciplineHandler.GetRewardDisciplineByID)
@@ -115,6 +139,42 @@ func SetupRouter(
 	blockchainGroup.POST("/push-chain/:id", blockchainHandler.PushCertificateToChain)
 	blockchainGroup.GET("/certificate-on-chain/:id", blockchainHandler.GetCertificateByID)
 	blockchainGroup.GET("/verify/:id", blockchainHandler.VerifyCertificateIntegrity)
-
-	return r
+	blockchainGroup.GET("/history/:id", blockchainHandler.GetCertificateHistory)
+	blockchainGroup.GET("/private/:id", blockchainHandler.GetCertificatePrivateDetails)
+
+	blockchainJobGroup := api.Group("/blockchain/jobs")
+	blockchainJobGroup.GET("", blockchainHandler.SearchJobs)
+	blockchainJobGroup.GET("/:id", blockchainHandler.GetJob)
+	blockchainJobGroup.POST("/:id/retry", blockchainHandler.RetryJob)
+
+	blockchainBatchGroup := api.Group("/blockchain/batches")
+	blockchainBatchGroup.POST("", blockchainHandler.AnchorCertificateBatch)
+	blockchainBatchGroup.GET("/:id", blockchainHandler.GetBatch)
+
+	reconciliationGroup := api.Group("/blockchain/reconciliation")
+	reconciliationGroup.POST("/run", reconciliationHandler.RunReconciliation)
+	reconciliationGroup.GET("/reports", reconciliationHandler.SearchReports)
+	reconciliationGroup.GET("/reports/:id", reconciliationHandler.GetReport)
+
+	// ===== Verifiable credential routes =====
+	vcGroup := api.Group("/vc")
+	vcGroup.POST("/verify", credentialHandler.VerifyCredential)
+
+	// ===== Public routes =====
+	publicGroup := api.Group("/public")
+	publicGroup.GET("/verify", blockchainHandler.PublicVerify)
+	publicGroup.POST("/verify-file", blockchainHandler.VerifyCertificateFile)
+	publicGroup.POST("/verify-disclosure", blockchainHandler.VerifyDisclosure)
+
+	// Open Badges: id của huy hiệu, bên cấp và định nghĩa huy hiệu trỏ tới các endpoint này
+	badgeGroup := publicGroup.Group("/badges")
+	badgeGroup.GET("/credentials/:id", badgeHandler.GetHostedBadge)
+	badgeGroup.GET("/issuers/:code", badgeHandler.GetIssuerProfile)
+	badgeGroup.GET("/achievements/:code/:type", badgeHandler.GetAchievement)
+	badgeGroup.POST("/verify", badgeHandler.VerifyBadge)
+
+	if err := checkRoutePermissions(r, routePermissions); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
@@ -52,3 +52,46 @@ func ParseToken(tokenStr string) (*CustomClaims, error) {
 
 	return claims, nil
 }
+
+// verifyTokenSecret là khóa riêng cho token xác minh, tách khỏi JWT_SECRET để lộ token trên văn bằng in không ảnh hưởng token đăng nhập
+// và có thể đổi JWT_SECRET mà không làm hỏng mã QR đã in
+func verifyTokenSecret() ([]byte, error) {
+	secret := os.Getenv("VERIFY_TOKEN_SECRET")
+	if secret == "" {
+		return nil, errors.New("chưa cấu hình VERIFY_TOKEN_SECRET")
+	}
+	return []byte(secret), nil
+}
+
+// Token xác minh công khai in trong mã QR trên văn bằng, chỉ chứa ID văn bằng.
+// Token cố ý không có exp vì văn bằng in ra phải xác minh được suốt đời; văn bằng bị thu hồi vẫn bị phát hiện khi tra cứu trạng thái.
+func GenerateVerifyToken(certificateID primitive.ObjectID) (string, error) {
+	secret, err := verifyTokenSecret()
+	if err != nil {
+		return "", err
+	}
+	claims := jwt.RegisteredClaims{
+		Subject:  certificateID.Hex(),
+		Audience: jwt.ClaimStrings{"certificate-verify"},
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+func ParseVerifyToken(tokenStr string) (primitive.ObjectID, error) {
+	secret, err := verifyTokenSecret()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithAudience("certificate-verify"), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return primitive.NilObjectID, errors.New("token xác minh không hợp lệ")
+	}
+
+	return primitive.ObjectIDFromHex(claims.Subject)
+}